@@ -1,23 +1,29 @@
 package main
 
 import (
+	"context"
 	httpSwagger "github.com/swaggo/http-swagger"
 	"go.uber.org/zap"
 	"log"
 	"net/http"
+	"os"
 	_ "taskTestEffectMobile/docs"
 	"taskTestEffectMobile/internal/core/configs"
 	"taskTestEffectMobile/internal/core/database"
 	"taskTestEffectMobile/internal/handler"
 	"taskTestEffectMobile/internal/repository"
 	"taskTestEffectMobile/internal/service"
+	"time"
+	// tzdata embeds the time zone database for images without one.
+	_ "time/tzdata"
 )
 
 func enableCORS(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, PATCH, DELETE")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, If-Match, Idempotency-Key, X-Request-ID, X-Actor")
+		w.Header().Set("Access-Control-Expose-Headers", "ETag, X-Request-ID")
 
 		if r.Method == "OPTIONS" {
 			return
@@ -27,8 +33,13 @@ func enableCORS(next http.Handler) http.Handler {
 	})
 }
 
-func initRouters(app *http.ServeMux, handler *handler.SubscriptionHandler) {
+func initRouters(app *http.ServeMux, handler *handler.SubscriptionHandler, exchangeRateHandler *handler.ExchangeRateHandler, userSettingsHandler *handler.UserSettingsHandler, calendarHandler *handler.CalendarHandler, budgetHandler *handler.BudgetHandler, webhookHandler *handler.WebhookHandler) {
 	handler.CreateSubscriptionsRoutes(app)
+	exchangeRateHandler.CreateExchangeRateRoutes(app)
+	userSettingsHandler.CreateUserSettingsRoutes(app)
+	calendarHandler.CreateCalendarRoutes(app)
+	budgetHandler.CreateBudgetRoutes(app)
+	webhookHandler.CreateWebhookRoutes(app)
 	log.Println("Router initialized")
 }
 
@@ -49,24 +60,105 @@ func main() {
 	}()
 
 	cfg := configs.Init()
-	err = database.RunMigrations(cfg.DB.DBUrl())
-	if err != nil {
-		log.Fatal(err)
+	app := http.NewServeMux()
+
+	var subscriptionRepo repository.SubscriptionStorage
+	var outboxRepo repository.OutboxStorage
+	var idempotencyRepo repository.IdempotencyStorage
+	var exchangeRateRepo repository.ExchangeRateStorage
+	var userSettingsRepo repository.UserSettingsStorage
+	var calendarTokenRepo repository.CalendarTokenStorage
+	var budgetRepo repository.BudgetStorage
+	var webhookRepo repository.WebhookStorage
+	if cfg.App.Storage == "memory" {
+		log.Println("Using in-memory storage")
+		memorySubscriptionRepo := repository.NewInMemorySubscriptionRepository(logger)
+		subscriptionRepo = memorySubscriptionRepo
+		outboxRepo = memorySubscriptionRepo
+		idempotencyRepo = repository.NewInMemoryIdempotencyRepository(logger)
+		exchangeRateRepo = repository.NewInMemoryExchangeRateRepository(logger)
+		userSettingsRepo = repository.NewInMemoryUserSettingsRepository(logger)
+		calendarTokenRepo = repository.NewInMemoryCalendarTokenRepository(logger)
+		budgetRepo = repository.NewInMemoryBudgetRepository(logger)
+		webhookRepo = repository.NewInMemoryWebhookRepository(logger)
+	} else {
+		err = database.RunMigrations(cfg.DB.DBUrl())
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		db, err := database.CreateDBConnection()
+		if err != nil {
+			log.Fatal(err)
+		}
+		postgresSubscriptionRepo := repository.NewSubscriptionRepository(db, logger)
+		subscriptionRepo = postgresSubscriptionRepo
+		outboxRepo = postgresSubscriptionRepo
+		idempotencyRepo = repository.NewIdempotencyRepository(db, logger)
+		exchangeRateRepo = repository.NewExchangeRateRepository(db, logger)
+		userSettingsRepo = repository.NewUserSettingsRepository(db, logger)
+		calendarTokenRepo = repository.NewCalendarTokenRepository(db, logger)
+		budgetRepo = repository.NewBudgetRepository(db, logger)
+		webhookRepo = repository.NewWebhookRepository(db, logger)
 	}
 
-	db, err := database.CreateDBConnection()
+	defaultTimeZone, err := time.LoadLocation(cfg.App.DefaultTimeZone)
 	if err != nil {
-		log.Fatal(err)
+		log.Fatalf("invalid DEFAULT_TIME_ZONE: %v", err)
 	}
-	app := http.NewServeMux()
 
-	subscriptionRepo := repository.NewSubscriptionRepository(db, logger)
-	subscriptionService := service.NewSubscriptionService(*subscriptionRepo, logger)
-	subscriptionHandler := handler.NewSubscriptionHandler(*subscriptionService, logger)
+	exchangeRateService := service.NewExchangeRateService(exchangeRateRepo, cfg.App.BaseCurrency, logger)
+	userSettingsService := service.NewUserSettingsService(userSettingsRepo, defaultTimeZone, logger)
+	budgetService := service.NewBudgetService(budgetRepo, subscriptionRepo, *exchangeRateService, *userSettingsService, cfg.App.ProrationPolicy, logger)
+	webhookService := service.NewWebhookService(webhookRepo, subscriptionRepo, *exchangeRateService, cfg.App.ProrationPolicy, cfg.Webhooks, logger)
+	subscriptionService := service.NewSubscriptionService(subscriptionRepo, *exchangeRateService, *userSettingsService, *budgetService, cfg.App.ProrationPolicy, logger)
+	calendarService := service.NewCalendarService(calendarTokenRepo, *subscriptionService, *userSettingsService, logger)
+	idempotencyService := service.NewIdempotencyService(idempotencyRepo, cfg.App.IdempotencyTTL, logger)
+	subscriptionHandler := handler.NewSubscriptionHandler(*subscriptionService, *idempotencyService, logger)
+	exchangeRateHandler := handler.NewExchangeRateHandler(*exchangeRateService, logger)
+	userSettingsHandler := handler.NewUserSettingsHandler(*userSettingsService, logger)
+	calendarHandler := handler.NewCalendarHandler(*calendarService, logger)
+	budgetHandler := handler.NewBudgetHandler(*budgetService, logger)
+	webhookHandler := handler.NewWebhookHandler(*webhookService, logger)
+
+	if cfg.App.IdempotencyCleanupInterval > 0 {
+		go idempotencyService.RunCleanup(context.Background(), cfg.App.IdempotencyCleanupInterval)
+	}
+	// Budgets are always evaluated in the background; the interval only adds
+	// the periodic pass over all users.
+	go budgetService.RunEvaluation(context.Background(), cfg.App.BudgetEvaluationInterval)
+	if cfg.App.SoftDeleteRetention > 0 && cfg.App.PurgeInterval > 0 {
+		go subscriptionService.RunPurge(context.Background(), cfg.App.PurgeInterval, cfg.App.SoftDeleteRetention)
+	}
+
+	// Webhooks and the optional local publisher receive the subscription
+	// events through the outbox.
+	publishers := service.MultiPublisher{webhookService}
+	switch cfg.Outbox.Publisher {
+	case "stdout":
+		publishers = append(publishers, service.NewWriterPublisher(os.Stdout))
+	case "file":
+		outboxFile, err := os.OpenFile(cfg.Outbox.File, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			log.Fatalf("can't open OUTBOX_FILE: %v", err)
+		}
+		defer outboxFile.Close()
+		publishers = append(publishers, service.NewWriterPublisher(outboxFile))
+	}
+	outboxService := service.NewOutboxService(outboxRepo, publishers, *userSettingsService, cfg.Outbox.Retention, logger)
+	if cfg.Outbox.RelayInterval > 0 {
+		go outboxService.RunRelay(context.Background(), cfg.Outbox.RelayInterval)
+	}
+	if cfg.Webhooks.DispatchInterval > 0 {
+		go webhookService.RunDispatcher(context.Background())
+	}
+	if cfg.Webhooks.RenewalNoticeDays > 0 && cfg.Webhooks.RenewalScanInterval > 0 {
+		go webhookService.RunRenewalNotices(context.Background())
+	}
 
-	initRouters(app, subscriptionHandler)
+	initRouters(app, subscriptionHandler, exchangeRateHandler, userSettingsHandler, calendarHandler, budgetHandler, webhookHandler)
 	app.Handle("/swagger/", httpSwagger.WrapHandler)
-	handlerWithCORS := enableCORS(app)
+	handlerWithCORS := enableCORS(handler.RequestMetadata(app))
 
 	err = http.ListenAndServe(":8080", handlerWithCORS)
 	if err != nil {
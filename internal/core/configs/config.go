@@ -5,11 +5,68 @@ import (
 	"github.com/joho/godotenv"
 	"log"
 	"os"
+	"strconv"
+	"time"
 )
 
 type Configs struct {
-	DB    DatabaseConfig
-	Redis RedisConfig
+	App      AppConfig
+	DB       DatabaseConfig
+	Redis    RedisConfig
+	Webhooks WebhookConfig
+	Outbox   OutboxConfig
+}
+
+type AppConfig struct {
+	// Storage selects the subscription store: "postgres" or "memory".
+	Storage string
+	// IdempotencyTTL is how long responses to Idempotency-Key requests are replayed.
+	IdempotencyTTL time.Duration
+	// IdempotencyCleanupInterval is how often expired keys are deleted.
+	IdempotencyCleanupInterval time.Duration
+	// SoftDeleteRetention is how long deleted subscriptions can be restored
+	// before the purge removes them. Zero disables the purge.
+	SoftDeleteRetention time.Duration
+	// PurgeInterval is how often the purge looks for expired deletions.
+	PurgeInterval time.Duration
+	// BaseCurrency is the default subscription currency; exchange rates are quoted in it.
+	BaseCurrency string
+	// ProrationPolicy is how cost reports charge partly covered periods:
+	// "none" charges them in full, "daily" by the share of covered days.
+	ProrationPolicy string
+	// DefaultTimeZone is the IANA time zone of users without saved settings.
+	DefaultTimeZone string
+	// BudgetEvaluationInterval is how often the budgets of all users are
+	// evaluated. Zero evaluates them only after subscription changes.
+	BudgetEvaluationInterval time.Duration
+}
+
+type WebhookConfig struct {
+	// DispatchInterval is how often due deliveries are sent.
+	DispatchInterval time.Duration
+	// Timeout bounds one delivery attempt.
+	Timeout time.Duration
+	// MaxAttempts is how many times a delivery is tried before it fails.
+	MaxAttempts int
+	// RetryBackoff is the wait after the first failed attempt; it doubles
+	// with every further failure.
+	RetryBackoff time.Duration
+	// RenewalNoticeDays is how many days ahead subscription.renewing is sent.
+	// Zero disables renewal notices.
+	RenewalNoticeDays int
+	// RenewalScanInterval is how often upcoming renewals are looked up.
+	RenewalScanInterval time.Duration
+}
+
+type OutboxConfig struct {
+	// RelayInterval is how often pending outbox events are published.
+	RelayInterval time.Duration
+	// Retention is how long published events are kept. Zero keeps them.
+	Retention time.Duration
+	// Publisher receives the events besides webhooks: "none", "stdout" or "file".
+	Publisher string
+	// File is the JSON lines file of the "file" publisher.
+	File string
 }
 
 type DatabaseConfig struct {
@@ -32,6 +89,34 @@ func Init() *Configs {
 	}
 	config := &Configs{}
 
+	config.App = AppConfig{
+		Storage:                    getEnv("STORAGE", "postgres"),
+		IdempotencyTTL:             getDurationEnv("IDEMPOTENCY_TTL", 24*time.Hour),
+		IdempotencyCleanupInterval: getDurationEnv("IDEMPOTENCY_CLEANUP_INTERVAL", time.Hour),
+		SoftDeleteRetention:        time.Duration(getIntEnv("SOFT_DELETE_RETENTION_DAYS", 30)) * 24 * time.Hour,
+		PurgeInterval:              getDurationEnv("PURGE_INTERVAL", time.Hour),
+		BaseCurrency:               getEnv("BASE_CURRENCY", "RUB"),
+		ProrationPolicy:            getChoiceEnv("PRORATION_POLICY", "none", "daily"),
+		DefaultTimeZone:            getEnv("DEFAULT_TIME_ZONE", "UTC"),
+		BudgetEvaluationInterval:   getDurationEnv("BUDGET_EVALUATION_INTERVAL", time.Hour),
+	}
+
+	config.Webhooks = WebhookConfig{
+		DispatchInterval:    getDurationEnv("WEBHOOK_DISPATCH_INTERVAL", 5*time.Second),
+		Timeout:             getDurationEnv("WEBHOOK_TIMEOUT", 10*time.Second),
+		MaxAttempts:         getIntEnv("WEBHOOK_MAX_ATTEMPTS", 8),
+		RetryBackoff:        getDurationEnv("WEBHOOK_RETRY_BACKOFF", 30*time.Second),
+		RenewalNoticeDays:   getIntEnv("WEBHOOK_RENEWAL_NOTICE_DAYS", 3),
+		RenewalScanInterval: getDurationEnv("WEBHOOK_RENEWAL_SCAN_INTERVAL", time.Hour),
+	}
+
+	config.Outbox = OutboxConfig{
+		RelayInterval: getDurationEnv("OUTBOX_RELAY_INTERVAL", time.Second),
+		Retention:     getDurationEnv("OUTBOX_RETENTION", 7*24*time.Hour),
+		Publisher:     getChoiceEnv("OUTBOX_PUBLISHER", "none", "stdout", "file"),
+		File:          getEnv("OUTBOX_FILE", "outbox.jsonl"),
+	}
+
 	config.DB = DatabaseConfig{
 		Host:     getEnv("DB_HOST", "localhost"),
 		Port:     getEnv("DB_PORT", "5432"),
@@ -69,3 +154,45 @@ func getEnv(key, defaultValue string) string {
 	}
 	return value
 }
+
+func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Invalid duration in %s, using %s", key, defaultValue)
+		return defaultValue
+	}
+	return duration
+}
+
+// getChoiceEnv returns the value of key if it is one of the allowed values,
+// or defaultValue otherwise.
+func getChoiceEnv(key, defaultValue string, allowed ...string) string {
+	value, exists := os.LookupEnv(key)
+	if !exists || value == defaultValue {
+		return defaultValue
+	}
+	for _, choice := range allowed {
+		if value == choice {
+			return value
+		}
+	}
+	log.Printf("Invalid value in %s, using %s", key, defaultValue)
+	return defaultValue
+}
+
+func getIntEnv(key string, defaultValue int) int {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil || number < 0 {
+		log.Printf("Invalid number in %s, using %d", key, defaultValue)
+		return defaultValue
+	}
+	return number
+}
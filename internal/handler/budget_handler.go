@@ -0,0 +1,190 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"net/http"
+	"taskTestEffectMobile/internal/models/json_models"
+	"taskTestEffectMobile/internal/service"
+)
+
+type BudgetHandler struct {
+	service  service.BudgetService
+	validate *validator.Validate
+	logger   *zap.Logger
+}
+
+func NewBudgetHandler(s service.BudgetService, logger *zap.Logger) *BudgetHandler {
+	return &BudgetHandler{
+		service:  s,
+		validate: newValidator(),
+		logger:   logger,
+	}
+}
+
+func (budgetHandler *BudgetHandler) CreateBudgetRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /api/v1/users/{id}/budgets", budgetHandler.listBudgets)
+	mux.HandleFunc("PUT /api/v1/users/{id}/budgets", budgetHandler.setBudget)
+	mux.HandleFunc("DELETE /api/v1/users/{id}/budgets/{budgetID}", budgetHandler.deleteBudget)
+	mux.HandleFunc("GET /api/v1/users/{id}/budget-alerts", budgetHandler.listBudgetAlerts)
+}
+
+// listBudgets returns the budgets of a user
+// @Summary List budgets
+// @Description Returns the monthly budgets of the user with the projected spend of the current month
+// @Tags Budgets
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} json_models.Problem "Invalid UUID format"
+// @Failure 422 {object} json_models.Problem "Exchange rate is missing"
+// @Failure 500 {object} json_models.Problem "Internal server error"
+// @Router /users/{id}/budgets [get]
+func (budgetHandler *BudgetHandler) listBudgets(w http.ResponseWriter, r *http.Request) {
+	userUUID, ok := budgetHandler.parseUserID(w, r)
+	if !ok {
+		return
+	}
+
+	budgets, err := budgetHandler.service.ListBudgets(r.Context(), userUUID)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	budgetHandler.writeJSON(w, http.StatusOK, map[string]interface{}{"budgets": budgets})
+}
+
+// setBudget creates or replaces a budget of a user
+// @Summary Set budget
+// @Description Sets the monthly budget of the user for one service, or for all subscriptions when service_name is omitted.
+// @Description An existing budget with the same scope is replaced. Alerts are recorded when the projected spend of a month reaches 80% and 100% of the budget
+// @Tags Budgets
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param budget body json_models.BudgetInput true "Budget"
+// @Success 200 {object} sql_models.Budget
+// @Failure 400 {object} json_models.Problem "Invalid request format"
+// @Failure 422 {object} json_models.Problem "Validation error"
+// @Failure 500 {object} json_models.Problem "Internal server error"
+// @Router /users/{id}/budgets [put]
+func (budgetHandler *BudgetHandler) setBudget(w http.ResponseWriter, r *http.Request) {
+	userUUID, ok := budgetHandler.parseUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var input json_models.BudgetInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		budgetHandler.logger.Error("Failed to decode JSON request",
+			zap.Error(err))
+		writeBadRequest(w, r, "Invalid JSON format")
+		return
+	}
+
+	if err := budgetHandler.validate.Struct(input); err != nil {
+		budgetHandler.logger.Warn("Validation failed",
+			zap.Error(err),
+			zap.Any("budget", input))
+		writeValidationError(w, r, err)
+		return
+	}
+
+	budget, err := budgetHandler.service.SetBudget(r.Context(), userUUID, input)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	budgetHandler.writeJSON(w, http.StatusOK, budget)
+}
+
+// deleteBudget removes a budget of a user
+// @Summary Delete budget
+// @Description Deletes the budget together with its alerts
+// @Tags Budgets
+// @Param id path string true "User ID"
+// @Param budgetID path string true "Budget ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} json_models.Problem "Invalid UUID format"
+// @Failure 404 {object} json_models.Problem "Budget not found"
+// @Failure 500 {object} json_models.Problem "Internal server error"
+// @Router /users/{id}/budgets/{budgetID} [delete]
+func (budgetHandler *BudgetHandler) deleteBudget(w http.ResponseWriter, r *http.Request) {
+	userUUID, ok := budgetHandler.parseUserID(w, r)
+	if !ok {
+		return
+	}
+
+	budgetID := r.PathValue("budgetID")
+	budgetUUID, err := uuid.Parse(budgetID)
+	if err != nil {
+		budgetHandler.logger.Warn("Invalid budget ID format",
+			zap.String("budgetID", budgetID),
+			zap.Error(err))
+		writeBadRequest(w, r, "Invalid budget ID format")
+		return
+	}
+
+	if err := budgetHandler.service.DeleteBudget(r.Context(), userUUID, budgetUUID); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listBudgetAlerts returns the budget alerts of a user
+// @Summary List budget alerts
+// @Description Returns the alerts recorded when the projected spend of a month reached a budget threshold, newest first
+// @Tags Budgets
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} json_models.Problem "Invalid UUID format"
+// @Failure 500 {object} json_models.Problem "Internal server error"
+// @Router /users/{id}/budget-alerts [get]
+func (budgetHandler *BudgetHandler) listBudgetAlerts(w http.ResponseWriter, r *http.Request) {
+	userUUID, ok := budgetHandler.parseUserID(w, r)
+	if !ok {
+		return
+	}
+
+	alerts, err := budgetHandler.service.ListBudgetAlerts(r.Context(), userUUID)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	budgetHandler.writeJSON(w, http.StatusOK, map[string]interface{}{"alerts": alerts})
+}
+
+func (budgetHandler *BudgetHandler) parseUserID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	userID := r.PathValue("id")
+
+	budgetHandler.logger.Info("Budget request",
+		zap.String("method", r.Method),
+		zap.String("userID", userID))
+
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		budgetHandler.logger.Warn("Invalid user ID format",
+			zap.String("userID", userID),
+			zap.Error(err))
+		writeBadRequest(w, r, "Invalid user ID format")
+		return uuid.UUID{}, false
+	}
+	return userUUID, true
+}
+
+func (budgetHandler *BudgetHandler) writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		budgetHandler.logger.Error("Failed to encode response",
+			zap.Error(err))
+	}
+}
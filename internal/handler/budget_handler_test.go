@@ -0,0 +1,64 @@
+package handler_test
+
+import (
+	"net/http"
+	"taskTestEffectMobile/internal/models/json_models"
+	"taskTestEffectMobile/internal/models/sql_models"
+	"testing"
+	"time"
+)
+
+func TestBudgetAlerts(t *testing.T) {
+	router := newTestRouter(t)
+	createSubscription(t, router, "Yandex Plus")
+	budgets := "/api/v1/users/" + testUserID + "/budgets"
+	alerts := "/api/v1/users/" + testUserID + "/budget-alerts"
+
+	recorder := serve(router, http.MethodPut, budgets, `{"amount": 500}`)
+	expectStatus(t, recorder, http.StatusOK)
+	if budget := decode[sql_models.Budget](t, recorder); budget.Amount != 500 || budget.Currency != "RUB" || budget.ServiceName != nil {
+		t.Errorf("budget = %+v", budget)
+	}
+
+	recorder = serve(router, http.MethodGet, alerts, "")
+	expectStatus(t, recorder, http.StatusOK)
+	if got := decode[map[string][]sql_models.BudgetAlert](t, recorder)["alerts"]; len(got) != 0 {
+		t.Errorf("alerts under the budget = %+v", got)
+	}
+
+	createSubscription(t, router, "Kinopoisk")
+
+	recorder = serve(router, http.MethodGet, budgets, "")
+	expectStatus(t, recorder, http.StatusOK)
+	statuses := decode[map[string][]json_models.BudgetStatus](t, recorder)["budgets"]
+	if len(statuses) != 1 || statuses[0].Spent != 600 || statuses[0].Percent != 120 {
+		t.Errorf("budgets = %+v", statuses)
+	}
+
+	got := waitForBudgetAlerts(t, router, alerts, 2)
+	thresholds := map[int]bool{got[0].Threshold: true, got[1].Threshold: true}
+	if !thresholds[80] || !thresholds[100] {
+		t.Errorf("alert thresholds = %v, want 80 and 100", thresholds)
+	}
+
+	expectProblem(t, serve(router, http.MethodPut, budgets, `{"amount": 0}`), http.StatusUnprocessableEntity, "/problems/validation-error")
+}
+
+// waitForBudgetAlerts polls the alerts of the user until there are want of
+// them, since budgets are evaluated in the background.
+func waitForBudgetAlerts(t *testing.T, router http.Handler, target string, want int) []sql_models.BudgetAlert {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		recorder := serve(router, http.MethodGet, target, "")
+		expectStatus(t, recorder, http.StatusOK)
+		alerts := decode[map[string][]sql_models.BudgetAlert](t, recorder)["alerts"]
+		if len(alerts) >= want || time.Now().After(deadline) {
+			if len(alerts) != want {
+				t.Fatalf("alerts = %+v, want %d", alerts, want)
+			}
+			return alerts
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"strings"
+	"taskTestEffectMobile/internal/models/json_models"
+	"taskTestEffectMobile/internal/service"
+)
+
+const calendarFeedSuffix = ".ics"
+
+type CalendarHandler struct {
+	service  service.CalendarService
+	validate *validator.Validate
+	logger   *zap.Logger
+}
+
+func NewCalendarHandler(s service.CalendarService, logger *zap.Logger) *CalendarHandler {
+	return &CalendarHandler{
+		service:  s,
+		validate: newValidator(),
+		logger:   logger,
+	}
+}
+
+func (calendarHandler *CalendarHandler) CreateCalendarRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("POST /api/v1/users/{id}/calendar-token", calendarHandler.issueCalendarToken)
+	mux.HandleFunc("DELETE /api/v1/users/{id}/calendar-token", calendarHandler.revokeCalendarToken)
+	mux.HandleFunc("GET /api/v1/calendar/{token}", calendarHandler.getCalendarFeed)
+}
+
+// issueCalendarToken creates the secret feed URL of a user
+// @Summary Issue calendar feed token
+// @Description Creates a secret iCalendar feed URL with the renewals of the user's subscriptions. Issuing a new token revokes the previous one; the token is only shown once
+// @Tags Calendar
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param options body json_models.CalendarTokenInput false "Feed options"
+// @Success 201 {object} json_models.CalendarFeed
+// @Failure 400 {object} json_models.Problem "Invalid request format"
+// @Failure 422 {object} json_models.Problem "Validation error"
+// @Failure 500 {object} json_models.Problem "Internal server error"
+// @Router /users/{id}/calendar-token [post]
+func (calendarHandler *CalendarHandler) issueCalendarToken(w http.ResponseWriter, r *http.Request) {
+	userID := r.PathValue("id")
+
+	calendarHandler.logger.Info("Issue calendar token request",
+		zap.String("userID", userID))
+
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		calendarHandler.logger.Warn("Invalid user ID format",
+			zap.String("userID", userID),
+			zap.Error(err))
+		writeBadRequest(w, r, "Invalid user ID format")
+		return
+	}
+
+	var input json_models.CalendarTokenInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil && !errors.Is(err, io.EOF) {
+		calendarHandler.logger.Error("Failed to decode JSON request",
+			zap.Error(err))
+		writeBadRequest(w, r, "Invalid JSON format")
+		return
+	}
+
+	if err := calendarHandler.validate.Struct(input); err != nil {
+		calendarHandler.logger.Warn("Validation failed",
+			zap.Error(err),
+			zap.Any("options", input))
+		writeValidationError(w, r, err)
+		return
+	}
+
+	token, err := calendarHandler.service.IssueCalendarToken(r.Context(), userUUID, input)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	feed := json_models.CalendarFeed{
+		Token: token,
+		URL:   requestBaseURL(r) + "/api/v1/calendar/" + token + calendarFeedSuffix,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(feed); err != nil {
+		calendarHandler.logger.Error("Failed to encode response",
+			zap.Error(err))
+	}
+}
+
+// revokeCalendarToken disables the feed URL of a user
+// @Summary Revoke calendar feed token
+// @Description Revokes the user's feed token; the feed URL stops working
+// @Tags Calendar
+// @Param id path string true "User ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} json_models.Problem "Invalid UUID format"
+// @Failure 404 {object} json_models.Problem "Calendar feed not found"
+// @Failure 500 {object} json_models.Problem "Internal server error"
+// @Router /users/{id}/calendar-token [delete]
+func (calendarHandler *CalendarHandler) revokeCalendarToken(w http.ResponseWriter, r *http.Request) {
+	userID := r.PathValue("id")
+
+	calendarHandler.logger.Info("Revoke calendar token request",
+		zap.String("userID", userID))
+
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		calendarHandler.logger.Warn("Invalid user ID format",
+			zap.String("userID", userID),
+			zap.Error(err))
+		writeBadRequest(w, r, "Invalid user ID format")
+		return
+	}
+
+	if err := calendarHandler.service.RevokeCalendarToken(r.Context(), userUUID); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getCalendarFeed serves the iCalendar feed a token grants access to
+// @Summary Get calendar feed
+// @Description Returns the renewals of the token owner's subscriptions as recurring iCalendar events
+// @Tags Calendar
+// @Produce text/calendar
+// @Param token path string true "Feed token, optionally with the .ics suffix"
+// @Success 200 {string} string "iCalendar feed"
+// @Failure 404 {object} json_models.Problem "Calendar feed not found"
+// @Failure 500 {object} json_models.Problem "Internal server error"
+// @Router /calendar/{token} [get]
+func (calendarHandler *CalendarHandler) getCalendarFeed(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimSuffix(r.PathValue("token"), calendarFeedSuffix)
+
+	calendarHandler.logger.Info("Get calendar feed request")
+
+	feed, err := calendarHandler.service.CalendarFeed(r.Context(), token)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(feed); err != nil {
+		calendarHandler.logger.Error("Failed to write calendar feed",
+			zap.Error(err))
+	}
+}
+
+// requestBaseURL returns the scheme and host the request was made to,
+// honouring X-Forwarded-Proto set by a proxy.
+func requestBaseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	return scheme + "://" + r.Host
+}
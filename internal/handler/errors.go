@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"net/http"
+	"taskTestEffectMobile/internal/models/domain_errors"
+	"taskTestEffectMobile/internal/models/json_models"
+)
+
+const problemContentType = "application/problem+json"
+
+// Problem types returned in the "type" member of problem+json bodies.
+const (
+	problemBadRequest       = "/problems/bad-request"
+	problemValidation       = "/problems/validation-error"
+	problemNotFound         = "/problems/not-found"
+	problemConflict         = "/problems/conflict"
+	problemInvalidDateRange = "/problems/invalid-date-range"
+	problemPrecondition     = "/problems/precondition-failed"
+	problemTransition       = "/problems/invalid-status-transition"
+	problemMissingRate      = "/problems/missing-exchange-rate"
+	problemKeyReused        = "/problems/idempotency-key-reused"
+	problemKeyInProgress    = "/problems/idempotency-key-in-progress"
+	problemInternal         = "/problems/internal-error"
+)
+
+// notFoundTitles names the missing resource in 404 responses.
+var notFoundTitles = []struct {
+	err   error
+	title string
+}{
+	{domain_errors.ErrSubscriptionNotFound, "Subscription not found"},
+	{domain_errors.ErrUserSettingsNotFound, "User settings not found"},
+	{domain_errors.ErrCalendarFeedNotFound, "Calendar feed not found"},
+	{domain_errors.ErrBudgetNotFound, "Budget not found"},
+	{domain_errors.ErrWebhookEndpointNotFound, "Webhook endpoint not found"},
+	{domain_errors.ErrWebhookDeliveryNotFound, "Webhook delivery not found"},
+}
+
+// problemFromError maps domain errors returned by the service to problem
+// details. The detail is a fixed message per error, or the one attached with
+// domain_errors.WithDetail; wrapped error text never reaches the client.
+func problemFromError(err error) json_models.Problem {
+	var duplicate *domain_errors.DuplicateSubscriptionError
+	if errors.As(err, &duplicate) {
+		return json_models.Problem{
+			Type:                 problemConflict,
+			Title:                "Subscription already exists",
+			Status:               http.StatusConflict,
+			Detail:               duplicate.Error() + "; end the existing subscription first or create with \"force\": true",
+			ExistingSubscription: "/api/v1/subscriptions/" + duplicate.ExistingID,
+		}
+	}
+
+	var problem json_models.Problem
+	switch {
+	case errors.Is(err, domain_errors.ErrNotFound):
+		problem = json_models.Problem{Type: problemNotFound, Title: "Not found", Status: http.StatusNotFound, Detail: "The requested resource does not exist"}
+		for _, notFound := range notFoundTitles {
+			if errors.Is(err, notFound.err) {
+				problem.Title = notFound.title
+				break
+			}
+		}
+	case errors.Is(err, domain_errors.ErrConflict):
+		problem = json_models.Problem{Type: problemConflict, Title: "Resource already exists", Status: http.StatusConflict, Detail: "The request conflicts with an existing resource"}
+	case errors.Is(err, domain_errors.ErrInvalidTransition):
+		problem = json_models.Problem{Type: problemTransition, Title: "Status transition is not allowed", Status: http.StatusConflict, Detail: "The current status does not allow this change"}
+	case errors.Is(err, domain_errors.ErrMissingExchangeRate):
+		problem = json_models.Problem{Type: problemMissingRate, Title: "Exchange rate is missing", Status: http.StatusUnprocessableEntity, Detail: "An amount cannot be converted for lack of an exchange rate"}
+	case errors.Is(err, domain_errors.ErrPreconditionFailed):
+		problem = json_models.Problem{Type: problemPrecondition, Title: "Subscription was modified", Status: http.StatusPreconditionFailed, Detail: "If-Match does not match the current ETag"}
+	case errors.Is(err, domain_errors.ErrIdempotencyKeyReused):
+		problem = json_models.Problem{Type: problemKeyReused, Title: "Idempotency-Key reused", Status: http.StatusUnprocessableEntity, Detail: "The key was already used with a different request"}
+	case errors.Is(err, domain_errors.ErrIdempotencyInProgress):
+		problem = json_models.Problem{Type: problemKeyInProgress, Title: "Request is in progress", Status: http.StatusConflict, Detail: "The first request with this key has not finished yet"}
+	case errors.Is(err, domain_errors.ErrInvalidDateRange):
+		problem = json_models.Problem{Type: problemInvalidDateRange, Title: "End date is before start date", Status: http.StatusUnprocessableEntity, Detail: "The end date must not be before the start date"}
+	case errors.Is(err, domain_errors.ErrValidation):
+		problem = json_models.Problem{Type: problemValidation, Title: "Validation error", Status: http.StatusUnprocessableEntity, Detail: "The request is invalid"}
+	default:
+		return json_models.Problem{Type: problemInternal, Title: "Internal server error", Status: http.StatusInternalServerError}
+	}
+
+	var detailed *domain_errors.DetailedError
+	if errors.As(err, &detailed) {
+		problem.Detail = detailed.Detail
+	}
+	return problem
+}
+
+func writeProblem(w http.ResponseWriter, r *http.Request, problem json_models.Problem) {
+	problem.Instance = r.URL.RequestURI()
+	w.Header().Set("Content-Type", problemContentType)
+	w.WriteHeader(problem.Status)
+	_ = json.NewEncoder(w).Encode(problem)
+}
+
+func writeServiceError(w http.ResponseWriter, r *http.Request, err error) {
+	writeProblem(w, r, problemFromError(err))
+}
+
+func writeBadRequest(w http.ResponseWriter, r *http.Request, detail string) {
+	writeProblem(w, r, json_models.Problem{
+		Type:   problemBadRequest,
+		Title:  "Bad request",
+		Status: http.StatusBadRequest,
+		Detail: detail,
+	})
+}
+
+// writeValidationError reports every failed field of a validator error.
+func writeValidationError(w http.ResponseWriter, r *http.Request, err error) {
+	var fieldErrors []json_models.FieldError
+
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		for _, fieldErr := range validationErrors {
+			fieldErrors = append(fieldErrors, json_models.FieldError{
+				Field:   fieldErr.Field(),
+				Rule:    fieldErr.Tag(),
+				Message: validationMessage(fieldErr),
+			})
+		}
+	}
+
+	writeFieldErrors(w, r, fieldErrors)
+}
+
+func writeFieldErrors(w http.ResponseWriter, r *http.Request, fieldErrors []json_models.FieldError) {
+	writeProblem(w, r, json_models.Problem{
+		Type:   problemValidation,
+		Title:  "Validation error",
+		Status: http.StatusUnprocessableEntity,
+		Detail: "One or more fields are invalid",
+		Errors: fieldErrors,
+	})
+}
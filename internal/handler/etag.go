@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"taskTestEffectMobile/internal/models/sql_models"
+)
+
+func setETag(w http.ResponseWriter, subscription sql_models.Subscription) {
+	w.Header().Set("ETag", strconv.Quote(strconv.Itoa(subscription.Version)))
+}
+
+// parseIfMatch returns the version required by the If-Match header.
+// A missing header or "*" means no version check.
+func parseIfMatch(r *http.Request) (*int, bool) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" || value == "*" {
+		return nil, true
+	}
+
+	value = strings.TrimPrefix(value, "W/")
+	unquoted, err := strconv.Unquote(value)
+	if err != nil {
+		return nil, false
+	}
+	version, err := strconv.Atoi(unquoted)
+	if err != nil {
+		return nil, false
+	}
+	return &version, true
+}
@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"taskTestEffectMobile/internal/models/json_models"
+	"taskTestEffectMobile/internal/service"
+)
+
+// maxExchangeRatesUpload limits the size of an uploaded rates file.
+const maxExchangeRatesUpload = 1 << 20
+
+type ExchangeRateHandler struct {
+	service  service.ExchangeRateService
+	validate *validator.Validate
+	logger   *zap.Logger
+}
+
+func NewExchangeRateHandler(s service.ExchangeRateService, logger *zap.Logger) *ExchangeRateHandler {
+	return &ExchangeRateHandler{
+		service:  s,
+		validate: newValidator(),
+		logger:   logger,
+	}
+}
+
+func (exchangeRateHandler *ExchangeRateHandler) CreateExchangeRateRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /api/v1/admin/exchange-rates", exchangeRateHandler.listExchangeRates)
+	mux.HandleFunc("POST /api/v1/admin/exchange-rates", exchangeRateHandler.importExchangeRates)
+}
+
+// listExchangeRates returns the stored exchange rates
+// @Summary List exchange rates
+// @Description Returns every stored rate, quoted in the base currency
+// @Tags Admin
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} json_models.Problem "Internal server error"
+// @Router /admin/exchange-rates [get]
+func (exchangeRateHandler *ExchangeRateHandler) listExchangeRates(w http.ResponseWriter, r *http.Request) {
+	exchangeRateHandler.logger.Info("List exchange rates request received")
+
+	rates, err := exchangeRateHandler.service.ListExchangeRates(r.Context())
+	if err != nil {
+		exchangeRateHandler.logger.Error("Failed to list exchange rates",
+			zap.Error(err))
+		writeServiceError(w, r, err)
+		return
+	}
+
+	response := map[string]interface{}{
+		"base_currency": exchangeRateHandler.service.BaseCurrency(),
+		"rates":         rates,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		exchangeRateHandler.logger.Error("Failed to encode response",
+			zap.Error(err))
+	}
+}
+
+// importExchangeRates loads exchange rates from a CSV or JSON file
+// @Summary Import exchange rates
+// @Description Loads rates from a text/csv body with "currency,valid_from,rate" rows (the header row is optional)
+// @Description or from a JSON array of rates. Rates of the same currency and month are replaced.
+// @Tags Admin
+// @Accept json
+// @Accept plain
+// @Produce json
+// @Param rates body []json_models.ExchangeRateInput true "Exchange rates"
+// @Success 200 {object} map[string]int
+// @Failure 400 {object} json_models.Problem "Malformed file"
+// @Failure 422 {object} json_models.Problem "Validation error"
+// @Failure 500 {object} json_models.Problem "Internal server error"
+// @Router /admin/exchange-rates [post]
+func (exchangeRateHandler *ExchangeRateHandler) importExchangeRates(w http.ResponseWriter, r *http.Request) {
+	exchangeRateHandler.logger.Info("Import exchange rates request received",
+		zap.String("contentType", r.Header.Get("Content-Type")))
+
+	body := http.MaxBytesReader(w, r.Body, maxExchangeRatesUpload)
+
+	var inputs []json_models.ExchangeRateInput
+	var err error
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "text/csv" {
+		inputs, err = parseExchangeRatesCSV(body)
+	} else {
+		err = json.NewDecoder(body).Decode(&inputs)
+	}
+	if err != nil {
+		exchangeRateHandler.logger.Warn("Failed to parse exchange rates",
+			zap.Error(err))
+		writeBadRequest(w, r, "Invalid exchange rates file: "+err.Error())
+		return
+	}
+
+	var fieldErrors []json_models.FieldError
+	for i, input := range inputs {
+		var validationErrors validator.ValidationErrors
+		if err := exchangeRateHandler.validate.Struct(input); errors.As(err, &validationErrors) {
+			for _, fieldErr := range validationErrors {
+				fieldErrors = append(fieldErrors, json_models.FieldError{
+					Field:   fmt.Sprintf("rates[%d].%s", i, fieldErr.Field()),
+					Rule:    fieldErr.Tag(),
+					Message: validationMessage(fieldErr),
+				})
+			}
+		}
+	}
+	if len(fieldErrors) > 0 {
+		exchangeRateHandler.logger.Warn("Validation failed",
+			zap.Any("errors", fieldErrors))
+		writeFieldErrors(w, r, fieldErrors)
+		return
+	}
+
+	imported, err := exchangeRateHandler.service.ImportExchangeRates(r.Context(), inputs)
+	if err != nil {
+		exchangeRateHandler.logger.Error("Failed to import exchange rates",
+			zap.Error(err))
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(map[string]int{"imported": imported}); err != nil {
+		exchangeRateHandler.logger.Error("Failed to encode response",
+			zap.Error(err))
+	}
+}
+
+// parseExchangeRatesCSV reads "currency,valid_from,rate" rows. A first row
+// starting with "currency" is treated as a header.
+func parseExchangeRatesCSV(body io.Reader) ([]json_models.ExchangeRateInput, error) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = 3
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) > 0 && strings.EqualFold(records[0][0], "currency") {
+		records = records[1:]
+	}
+
+	inputs := make([]json_models.ExchangeRateInput, 0, len(records))
+	for i, record := range records {
+		rate, err := strconv.ParseFloat(record[2], 64)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: invalid rate %q", i+1, record[2])
+		}
+		inputs = append(inputs, json_models.ExchangeRateInput{
+			Currency:  strings.ToUpper(record[0]),
+			ValidFrom: record[1],
+			Rate:      rate,
+		})
+	}
+	return inputs, nil
+}
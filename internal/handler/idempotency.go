@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"taskTestEffectMobile/internal/service"
+)
+
+const (
+	idempotencyKeyHeader = "Idempotency-Key"
+	maxIdempotencyKeyLen = 255
+)
+
+// recordingResponseWriter keeps a copy of the response so it can be stored for replay.
+type recordingResponseWriter struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (recorder *recordingResponseWriter) WriteHeader(statusCode int) {
+	recorder.statusCode = statusCode
+	recorder.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (recorder *recordingResponseWriter) Write(data []byte) (int, error) {
+	if recorder.statusCode == 0 {
+		recorder.statusCode = http.StatusOK
+	}
+	recorder.body.Write(data)
+	return recorder.ResponseWriter.Write(data)
+}
+
+// idempotent replays the stored response when a request is retried with the
+// same Idempotency-Key. Requests without the header are passed through.
+func (subscriptionHandler *SubscriptionHandler) idempotent(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if key == "" {
+			next(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLen {
+			writeBadRequest(w, r, "Idempotency-Key is too long")
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			subscriptionHandler.logger.Error("Failed to read request body",
+				zap.Error(err))
+			writeBadRequest(w, r, "Failed to read request body")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		record, err := subscriptionHandler.idempotency.Begin(r.Context(), key, service.HashRequest(r.Method, r.URL.Path, body))
+		if err != nil {
+			writeServiceError(w, r, err)
+			return
+		}
+		if record != nil {
+			w.Header().Set("Content-Type", record.ContentType)
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(record.StatusCode)
+			if _, err := w.Write(record.ResponseBody); err != nil {
+				subscriptionHandler.logger.Error("Failed to write replayed response",
+					zap.Error(err))
+			}
+			return
+		}
+
+		recorder := &recordingResponseWriter{ResponseWriter: w}
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				// Free the key so retries are not rejected as in progress
+				// until it expires; net/http still handles the panic.
+				subscriptionHandler.finishIdempotent(r, key, http.StatusInternalServerError, "", nil)
+				panic(recovered)
+			}
+		}()
+		next(recorder, r)
+
+		if recorder.statusCode == 0 {
+			recorder.statusCode = http.StatusOK
+		}
+		subscriptionHandler.finishIdempotent(r, key, recorder.statusCode, recorder.Header().Get("Content-Type"), recorder.body.Bytes())
+	}
+}
+
+// finishIdempotent stores the response for replay, or releases the key after
+// a server error. It runs even when the client has gone away.
+func (subscriptionHandler *SubscriptionHandler) finishIdempotent(r *http.Request, key string, statusCode int, contentType string, body []byte) {
+	err := subscriptionHandler.idempotency.Finish(context.WithoutCancel(r.Context()), key, statusCode, contentType, body)
+	if err != nil {
+		subscriptionHandler.logger.Error("Failed to finish idempotent request",
+			zap.String("key", key),
+			zap.Error(err))
+	}
+}
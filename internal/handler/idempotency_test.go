@@ -0,0 +1,77 @@
+package handler_test
+
+import (
+	"fmt"
+	"net/http"
+	"taskTestEffectMobile/internal/models/sql_models"
+	"testing"
+)
+
+func TestIdempotentCreate(t *testing.T) {
+	router := newTestRouter(t)
+	body := fmt.Sprintf(`{"service_name": "Yandex Plus", "price": 300, "user_id": %q, "start_date": "01-2025"}`, testUserID)
+
+	first := serve(router, http.MethodPost, "/api/v1/subscriptions/create-subscription", body, "Idempotency-Key", "create-1")
+	expectStatus(t, first, http.StatusCreated)
+	if replayed := first.Header().Get("Idempotent-Replayed"); replayed != "" {
+		t.Errorf("first response has Idempotent-Replayed: %s", replayed)
+	}
+
+	retry := serve(router, http.MethodPost, "/api/v1/subscriptions/create-subscription", body, "Idempotency-Key", "create-1")
+	expectStatus(t, retry, http.StatusCreated)
+	if replayed := retry.Header().Get("Idempotent-Replayed"); replayed != "true" {
+		t.Errorf("Idempotent-Replayed = %q, want true", replayed)
+	}
+	if retry.Body.String() != first.Body.String() {
+		t.Errorf("replayed body = %s, want %s", retry.Body.String(), first.Body.String())
+	}
+	if contentType := retry.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("replayed Content-Type = %q", contentType)
+	}
+
+	recorder := serve(router, http.MethodGet, "/api/v1/subscriptions/get-subscription?user-id="+testUserID, "")
+	expectStatus(t, recorder, http.StatusOK)
+	if subs := decode[[]sql_models.Subscription](t, recorder); len(subs) != 1 {
+		t.Errorf("retry created %d subscriptions, want 1", len(subs))
+	}
+}
+
+func TestIdempotentCreateReplaysErrors(t *testing.T) {
+	router := newTestRouter(t)
+	body := `{"service_name": "Yandex Plus", "price": -1}`
+
+	first := serve(router, http.MethodPost, "/api/v1/subscriptions/create-subscription", body, "Idempotency-Key", "invalid-1")
+	expectProblem(t, first, http.StatusUnprocessableEntity, "/problems/validation-error")
+
+	retry := serve(router, http.MethodPost, "/api/v1/subscriptions/create-subscription", body, "Idempotency-Key", "invalid-1")
+	expectProblem(t, retry, http.StatusUnprocessableEntity, "/problems/validation-error")
+	if replayed := retry.Header().Get("Idempotent-Replayed"); replayed != "true" {
+		t.Errorf("Idempotent-Replayed = %q, want true", replayed)
+	}
+}
+
+func TestIdempotencyKeyReused(t *testing.T) {
+	router := newTestRouter(t)
+	body := fmt.Sprintf(`{"service_name": "Yandex Plus", "price": 300, "user_id": %q, "start_date": "01-2025"}`, testUserID)
+	otherBody := fmt.Sprintf(`{"service_name": "Netflix", "price": 300, "user_id": %q, "start_date": "01-2025"}`, testUserID)
+
+	recorder := serve(router, http.MethodPost, "/api/v1/subscriptions/create-subscription", body, "Idempotency-Key", "create-1")
+	expectStatus(t, recorder, http.StatusCreated)
+
+	recorder = serve(router, http.MethodPost, "/api/v1/subscriptions/create-subscription", otherBody, "Idempotency-Key", "create-1")
+	expectProblem(t, recorder, http.StatusUnprocessableEntity, "/problems/idempotency-key-reused")
+
+	recorder = serve(router, http.MethodGet, "/api/v1/subscriptions/get-subscription?user-id="+testUserID, "")
+	expectStatus(t, recorder, http.StatusOK)
+	if subs := decode[[]sql_models.Subscription](t, recorder); len(subs) != 1 || subs[0].ServiceName != "Yandex Plus" {
+		t.Errorf("subscriptions after a reused key = %+v", subs)
+	}
+}
+
+func TestIdempotencyKeyTooLong(t *testing.T) {
+	router := newTestRouter(t)
+	key := fmt.Sprintf("%0256d", 0)
+
+	recorder := serve(router, http.MethodPost, "/api/v1/subscriptions/create-subscription", `{}`, "Idempotency-Key", key)
+	expectProblem(t, recorder, http.StatusBadRequest, "/problems/bad-request")
+}
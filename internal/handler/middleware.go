@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"github.com/google/uuid"
+	"net/http"
+	"taskTestEffectMobile/internal/utils"
+)
+
+const (
+	requestIDHeader = "X-Request-ID"
+	actorHeader     = "X-Actor"
+	anonymousActor  = "anonymous"
+	// assertedActorPrefix marks actors taken from X-Actor. The header is not
+	// authenticated, so the stored value is only what the client claimed.
+	assertedActorPrefix = "client:"
+)
+
+// RequestMetadata attaches a request ID and the actor to the request context.
+// The request ID is taken from X-Request-ID or generated, and echoed back.
+// The actor is the unverified X-Actor header stored as "client:<value>".
+func RequestMetadata(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(requestIDHeader)
+		if requestID == "" {
+			requestID = uuid.New().String()
+		}
+		actor := anonymousActor
+		if asserted := r.Header.Get(actorHeader); asserted != "" {
+			actor = assertedActorPrefix + asserted
+		}
+
+		w.Header().Set(requestIDHeader, requestID)
+		next.ServeHTTP(w, r.WithContext(utils.WithRequestMetadata(r.Context(), requestID, actor)))
+	})
+}
@@ -1,36 +1,51 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 	"net/http"
+	"strconv"
 	"taskTestEffectMobile/internal/models/json_models"
+	"taskTestEffectMobile/internal/models/sql_models"
 	"taskTestEffectMobile/internal/service"
 	"taskTestEffectMobile/internal/utils"
 )
 
 type SubscriptionHandler struct {
-	service  service.SubscriptionService
-	validate *validator.Validate
-	logger   *zap.Logger
+	service     service.SubscriptionService
+	idempotency service.IdempotencyService
+	validate    *validator.Validate
+	logger      *zap.Logger
 }
 
-func NewSubscriptionHandler(s service.SubscriptionService, logger *zap.Logger) *SubscriptionHandler {
+func NewSubscriptionHandler(s service.SubscriptionService, idempotency service.IdempotencyService, logger *zap.Logger) *SubscriptionHandler {
 	return &SubscriptionHandler{
-		service:  s,
-		validate: validator.New(),
-		logger:   logger,
+		service:     s,
+		idempotency: idempotency,
+		validate:    newValidator(),
+		logger:      logger,
 	}
 }
 
 func (subscriptionHandler *SubscriptionHandler) CreateSubscriptionsRoutes(mux *http.ServeMux) {
-	mux.HandleFunc("POST /api/v1/subscriptions/create-subscription", subscriptionHandler.createSubscription)
+	mux.HandleFunc("POST /api/v1/subscriptions/create-subscription", subscriptionHandler.idempotent(subscriptionHandler.createSubscription))
 	mux.HandleFunc("GET /api/v1/subscriptions/get-subscription", subscriptionHandler.getSubscription)
+	mux.HandleFunc("GET /api/v1/subscriptions", subscriptionHandler.listSubscriptions)
+	mux.HandleFunc("GET /api/v1/subscriptions/{id}", subscriptionHandler.getSubscriptionByID)
+	mux.HandleFunc("GET /api/v1/subscriptions/{id}/history", subscriptionHandler.getSubscriptionHistory)
+	mux.HandleFunc("GET /api/v1/subscriptions/{id}/prices", subscriptionHandler.getSubscriptionPrices)
+	mux.HandleFunc("POST /api/v1/subscriptions/{id}/restore", subscriptionHandler.restoreSubscription)
+	mux.HandleFunc("POST /api/v1/subscriptions/{id}/pause", subscriptionHandler.pauseSubscription)
+	mux.HandleFunc("POST /api/v1/subscriptions/{id}/resume", subscriptionHandler.resumeSubscription)
+	mux.HandleFunc("POST /api/v1/subscriptions/{id}/cancel", subscriptionHandler.cancelSubscription)
 	mux.HandleFunc("PUT /api/v1/subscriptions/update-subscription", subscriptionHandler.updateSubscription)
+	mux.HandleFunc("PATCH /api/v1/subscriptions/{id}", subscriptionHandler.patchSubscription)
 	mux.HandleFunc("DELETE /api/v1/subscriptions/delete-subscription", subscriptionHandler.deleteSubscription)
 	mux.HandleFunc("GET /api/v1/subscriptions/calculate-cost", subscriptionHandler.calculateSubscriptionsCost)
+	mux.HandleFunc("GET /api/v1/subscriptions/upcoming-charges", subscriptionHandler.upcomingCharges)
 }
 
 // createSubscription creates a new subscription
@@ -40,10 +55,12 @@ func (subscriptionHandler *SubscriptionHandler) CreateSubscriptionsRoutes(mux *h
 // @Accept json
 // @Produce json
 // @Param subscription body json_models.CreateSubscription true "Subscription data"
+// @Param Idempotency-Key header string false "Key to safely retry the request; the first response is replayed"
 // @Success 201 {object} map[string]string
-// @Failure 400 {string} string "Failed to decode JSON request"
-// @Failure 422 {string} string "Validation error"
-// @Failure 500 {string} string "Internal server error"
+// @Failure 400 {object} json_models.Problem "Failed to decode JSON request"
+// @Failure 409 {object} json_models.Problem "Overlapping subscription exists or request with this Idempotency-Key is in progress"
+// @Failure 422 {object} json_models.Problem "Validation error or Idempotency-Key reused with another body"
+// @Failure 500 {object} json_models.Problem "Internal server error"
 // @Router /subscriptions/create-subscription [post]
 func (subscriptionHandler *SubscriptionHandler) createSubscription(w http.ResponseWriter, r *http.Request) {
 	subscriptionHandler.logger.Info("Create subscription request received")
@@ -53,7 +70,7 @@ func (subscriptionHandler *SubscriptionHandler) createSubscription(w http.Respon
 		subscriptionHandler.logger.Error("Failed to decode JSON request",
 			zap.Error(err),
 			zap.String("path", r.URL.Path))
-		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		writeBadRequest(w, r, "Invalid JSON format")
 		return
 	}
 
@@ -61,7 +78,7 @@ func (subscriptionHandler *SubscriptionHandler) createSubscription(w http.Respon
 		subscriptionHandler.logger.Warn("Validation error",
 			zap.Error(err),
 			zap.Any("subscription", subscription))
-		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		writeValidationError(w, r, err)
 		return
 	}
 
@@ -74,15 +91,7 @@ func (subscriptionHandler *SubscriptionHandler) createSubscription(w http.Respon
 		subscriptionHandler.logger.Error("Failed to create subscription",
 			zap.Error(err),
 			zap.Any("subscription", subscription))
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	if userUUID == "" {
-		subscriptionHandler.logger.Warn("Subscription already exists",
-			zap.String("userID", subscription.UserID),
-			zap.String("serviceName", subscription.ServiceName))
-		http.Error(w, "Subscription already exists", http.StatusConflict)
+		writeServiceError(w, r, err)
 		return
 	}
 
@@ -110,8 +119,8 @@ func (subscriptionHandler *SubscriptionHandler) createSubscription(w http.Respon
 // @Produce json
 // @Param user-id query string true "User ID"
 // @Success 200 {array} sql_models.Subscription "List of subscriptions"
-// @Failure 400 {string} string "Invalid UUID format"
-// @Failure 500 {string} string "Internal server error"
+// @Failure 400 {object} json_models.Problem "Invalid UUID format"
+// @Failure 500 {object} json_models.Problem "Internal server error"
 // @Router /subscriptions/get-subscription [get]
 func (subscriptionHandler *SubscriptionHandler) getSubscription(w http.ResponseWriter, r *http.Request) {
 	params := r.URL.Query()
@@ -122,7 +131,7 @@ func (subscriptionHandler *SubscriptionHandler) getSubscription(w http.ResponseW
 
 	if userID == "" {
 		subscriptionHandler.logger.Warn("Missing user-id parameter")
-		http.Error(w, "Missing user-id", http.StatusBadRequest)
+		writeBadRequest(w, r, "Missing user-id")
 		return
 	}
 
@@ -131,7 +140,7 @@ func (subscriptionHandler *SubscriptionHandler) getSubscription(w http.ResponseW
 		subscriptionHandler.logger.Warn("Invalid UUID format",
 			zap.String("userID", userID),
 			zap.Error(err))
-		http.Error(w, "Invalid UUID", http.StatusBadRequest)
+		writeBadRequest(w, r, "Invalid UUID")
 		return
 	}
 
@@ -140,7 +149,7 @@ func (subscriptionHandler *SubscriptionHandler) getSubscription(w http.ResponseW
 		subscriptionHandler.logger.Error("Failed to get subscriptions",
 			zap.String("userID", userID),
 			zap.Error(err))
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		writeServiceError(w, r, err)
 		return
 	}
 
@@ -157,6 +166,359 @@ func (subscriptionHandler *SubscriptionHandler) getSubscription(w http.ResponseW
 	}
 }
 
+// listSubscriptions returns a page of subscriptions
+// @Summary List subscriptions
+// @Description Returns subscriptions of one or all users with filters, sorting and cursor pagination
+// @Tags Subscriptions
+// @Produce json
+// @Param user-id query string false "User ID filter"
+// @Param service-name query string false "Service name filter"
+// @Param min-price query int false "Minimum price"
+// @Param max-price query int false "Maximum price"
+// @Param active-at query string false "Active in month or on day (format: 01-2006, 2006-01-02 or RFC 3339)"
+// @Param start-from query string false "Start date from (format: 01-2006, 2006-01-02 or RFC 3339)"
+// @Param start-to query string false "Start date to, inclusive (format: 01-2006, 2006-01-02 or RFC 3339)"
+// @Param end-from query string false "End date from (format: 01-2006, 2006-01-02 or RFC 3339)"
+// @Param end-to query string false "End date to, inclusive (format: 01-2006, 2006-01-02 or RFC 3339)"
+// @Param sort query string false "Sort field" Enums(price, start_date, created_at)
+// @Param order query string false "Sort order" Enums(asc, desc)
+// @Param limit query int false "Page size (1-100, default 20)"
+// @Param cursor query string false "Cursor from the previous page"
+// @Param include-deleted query bool false "Also return soft-deleted subscriptions"
+// @Success 200 {object} json_models.SubscriptionPage
+// @Failure 400 {object} json_models.Problem "Invalid query parameters"
+// @Failure 422 {object} json_models.Problem "Validation error"
+// @Failure 500 {object} json_models.Problem "Internal server error"
+// @Router /subscriptions [get]
+func (subscriptionHandler *SubscriptionHandler) listSubscriptions(w http.ResponseWriter, r *http.Request) {
+	subscriptionHandler.logger.Info("List subscriptions request received")
+
+	var req json_models.ListSubscriptionsRequest
+	if err := utils.QueryParser(r, &req); err != nil {
+		subscriptionHandler.logger.Error("Failed to parse query params", zap.Error(err))
+		writeBadRequest(w, r, "Invalid query parameters")
+		return
+	}
+
+	if err := subscriptionHandler.validate.Struct(req); err != nil {
+		subscriptionHandler.logger.Warn("Validation failed",
+			zap.Error(err))
+		writeValidationError(w, r, err)
+		return
+	}
+
+	response, err := subscriptionHandler.service.ListSubscriptions(r.Context(), req)
+	if err != nil {
+		subscriptionHandler.logger.Error("Failed to list subscriptions",
+			zap.Error(err))
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		subscriptionHandler.logger.Error("Failed to encode response",
+			zap.Error(err))
+	}
+}
+
+// getSubscriptionByID retrieves a single subscription
+// @Summary Get subscription by ID
+// @Description Returns the subscription with the given ID
+// @Tags Subscriptions
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Param include-deleted query bool false "Also return a soft-deleted subscription"
+// @Success 200 {object} sql_models.Subscription
+// @Header 200 {string} ETag "Subscription version"
+// @Failure 400 {object} json_models.Problem "Invalid UUID format"
+// @Failure 404 {object} json_models.Problem "Subscription not found"
+// @Failure 500 {object} json_models.Problem "Internal server error"
+// @Router /subscriptions/{id} [get]
+func (subscriptionHandler *SubscriptionHandler) getSubscriptionByID(w http.ResponseWriter, r *http.Request) {
+	subscriptionID := r.PathValue("id")
+
+	subscriptionHandler.logger.Info("Get subscription by ID request",
+		zap.String("subscriptionID", subscriptionID))
+
+	subscriptionUUID, err := uuid.Parse(subscriptionID)
+	if err != nil {
+		subscriptionHandler.logger.Warn("Invalid subscription ID format",
+			zap.String("subscriptionID", subscriptionID),
+			zap.Error(err))
+		writeBadRequest(w, r, "Invalid subscription ID format")
+		return
+	}
+
+	includeDeleted := false
+	if value := r.URL.Query().Get("include-deleted"); value != "" {
+		includeDeleted, err = strconv.ParseBool(value)
+		if err != nil {
+			subscriptionHandler.logger.Warn("Invalid include-deleted parameter",
+				zap.String("includeDeleted", value))
+			writeBadRequest(w, r, "Invalid include-deleted parameter")
+			return
+		}
+	}
+
+	response, err := subscriptionHandler.service.GetSubscription(r.Context(), subscriptionUUID, includeDeleted)
+	if err != nil {
+		subscriptionHandler.logger.Error("Failed to get subscription",
+			zap.String("subscriptionID", subscriptionID),
+			zap.Error(err))
+		writeServiceError(w, r, err)
+		return
+	}
+
+	setETag(w, response)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		subscriptionHandler.logger.Error("Failed to encode response",
+			zap.Error(err),
+			zap.Any("response", response))
+	}
+}
+
+// getSubscriptionHistory returns the change history of a subscription
+// @Summary Get subscription history
+// @Description Returns every create, update and delete of the subscription with before/after snapshots, oldest first
+// @Tags Subscriptions
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Success 200 {array} sql_models.SubscriptionEvent
+// @Failure 400 {object} json_models.Problem "Invalid UUID format"
+// @Failure 404 {object} json_models.Problem "Subscription not found"
+// @Failure 500 {object} json_models.Problem "Internal server error"
+// @Router /subscriptions/{id}/history [get]
+func (subscriptionHandler *SubscriptionHandler) getSubscriptionHistory(w http.ResponseWriter, r *http.Request) {
+	subscriptionID := r.PathValue("id")
+
+	subscriptionHandler.logger.Info("Get subscription history request",
+		zap.String("subscriptionID", subscriptionID))
+
+	subscriptionUUID, err := uuid.Parse(subscriptionID)
+	if err != nil {
+		subscriptionHandler.logger.Warn("Invalid subscription ID format",
+			zap.String("subscriptionID", subscriptionID),
+			zap.Error(err))
+		writeBadRequest(w, r, "Invalid subscription ID format")
+		return
+	}
+
+	response, err := subscriptionHandler.service.GetSubscriptionHistory(r.Context(), subscriptionUUID)
+	if err != nil {
+		subscriptionHandler.logger.Error("Failed to get subscription history",
+			zap.String("subscriptionID", subscriptionID),
+			zap.Error(err))
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		subscriptionHandler.logger.Error("Failed to encode response",
+			zap.Error(err))
+	}
+}
+
+// getSubscriptionPrices returns the price history of a subscription
+// @Summary Get subscription price history
+// @Description Returns the effective-dated prices of the subscription, oldest first. Each price applies from its month until the next record
+// @Tags Subscriptions
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Success 200 {array} sql_models.SubscriptionPrice
+// @Failure 400 {object} json_models.Problem "Invalid UUID format"
+// @Failure 404 {object} json_models.Problem "Subscription not found"
+// @Failure 500 {object} json_models.Problem "Internal server error"
+// @Router /subscriptions/{id}/prices [get]
+func (subscriptionHandler *SubscriptionHandler) getSubscriptionPrices(w http.ResponseWriter, r *http.Request) {
+	subscriptionID := r.PathValue("id")
+
+	subscriptionHandler.logger.Info("Get subscription prices request",
+		zap.String("subscriptionID", subscriptionID))
+
+	subscriptionUUID, err := uuid.Parse(subscriptionID)
+	if err != nil {
+		subscriptionHandler.logger.Warn("Invalid subscription ID format",
+			zap.String("subscriptionID", subscriptionID),
+			zap.Error(err))
+		writeBadRequest(w, r, "Invalid subscription ID format")
+		return
+	}
+
+	response, err := subscriptionHandler.service.GetSubscriptionPrices(r.Context(), subscriptionUUID)
+	if err != nil {
+		subscriptionHandler.logger.Error("Failed to get subscription prices",
+			zap.String("subscriptionID", subscriptionID),
+			zap.Error(err))
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		subscriptionHandler.logger.Error("Failed to encode response",
+			zap.Error(err))
+	}
+}
+
+// restoreSubscription restores a soft-deleted subscription
+// @Summary Restore subscription
+// @Description Clears the deletion mark of a soft-deleted subscription. Restoring an active subscription is a no-op
+// @Tags Subscriptions
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Success 200 {object} sql_models.Subscription
+// @Header 200 {string} ETag "Subscription version"
+// @Failure 400 {object} json_models.Problem "Invalid UUID format"
+// @Failure 404 {object} json_models.Problem "Subscription not found or already purged"
+// @Failure 409 {object} json_models.Problem "Overlapping subscription exists"
+// @Failure 500 {object} json_models.Problem "Internal server error"
+// @Router /subscriptions/{id}/restore [post]
+func (subscriptionHandler *SubscriptionHandler) restoreSubscription(w http.ResponseWriter, r *http.Request) {
+	subscriptionID := r.PathValue("id")
+
+	subscriptionHandler.logger.Info("Restore subscription request",
+		zap.String("subscriptionID", subscriptionID))
+
+	subscriptionUUID, err := uuid.Parse(subscriptionID)
+	if err != nil {
+		subscriptionHandler.logger.Warn("Invalid subscription ID format",
+			zap.String("subscriptionID", subscriptionID),
+			zap.Error(err))
+		writeBadRequest(w, r, "Invalid subscription ID format")
+		return
+	}
+
+	response, err := subscriptionHandler.service.RestoreSubscription(r.Context(), subscriptionUUID)
+	if err != nil {
+		subscriptionHandler.logger.Error("Failed to restore subscription",
+			zap.String("subscriptionID", subscriptionID),
+			zap.Error(err))
+		writeServiceError(w, r, err)
+		return
+	}
+
+	setETag(w, response)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		subscriptionHandler.logger.Error("Failed to encode response",
+			zap.Error(err),
+			zap.Any("response", response))
+	}
+}
+
+// pauseSubscription pauses an active subscription
+// @Summary Pause subscription
+// @Description Pauses an active subscription. The current month stays billed, the following months are free until it is resumed
+// @Tags Subscriptions
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Param If-Match header string false "ETag of the version being changed"
+// @Success 200 {object} sql_models.Subscription
+// @Header 200 {string} ETag "Subscription version"
+// @Failure 400 {object} json_models.Problem "Invalid UUID format"
+// @Failure 404 {object} json_models.Problem "Subscription not found"
+// @Failure 409 {object} json_models.Problem "Status transition is not allowed"
+// @Failure 412 {object} json_models.Problem "Version mismatch"
+// @Failure 500 {object} json_models.Problem "Internal server error"
+// @Router /subscriptions/{id}/pause [post]
+func (subscriptionHandler *SubscriptionHandler) pauseSubscription(w http.ResponseWriter, r *http.Request) {
+	subscriptionHandler.changeStatus(w, r, subscriptionHandler.service.PauseSubscription)
+}
+
+// resumeSubscription resumes a paused subscription or ends a trial
+// @Summary Resume subscription
+// @Description Resumes a paused subscription or ends a trial early. Billing restarts with the current month
+// @Tags Subscriptions
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Param If-Match header string false "ETag of the version being changed"
+// @Success 200 {object} sql_models.Subscription
+// @Header 200 {string} ETag "Subscription version"
+// @Failure 400 {object} json_models.Problem "Invalid UUID format"
+// @Failure 404 {object} json_models.Problem "Subscription not found"
+// @Failure 409 {object} json_models.Problem "Status transition is not allowed"
+// @Failure 412 {object} json_models.Problem "Version mismatch"
+// @Failure 500 {object} json_models.Problem "Internal server error"
+// @Router /subscriptions/{id}/resume [post]
+func (subscriptionHandler *SubscriptionHandler) resumeSubscription(w http.ResponseWriter, r *http.Request) {
+	subscriptionHandler.changeStatus(w, r, subscriptionHandler.service.ResumeSubscription)
+}
+
+// cancelSubscription cancels a subscription
+// @Summary Cancel subscription
+// @Description Cancels the subscription. The current month becomes its last billed month
+// @Tags Subscriptions
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Param If-Match header string false "ETag of the version being changed"
+// @Success 200 {object} sql_models.Subscription
+// @Header 200 {string} ETag "Subscription version"
+// @Failure 400 {object} json_models.Problem "Invalid UUID format"
+// @Failure 404 {object} json_models.Problem "Subscription not found"
+// @Failure 409 {object} json_models.Problem "Status transition is not allowed"
+// @Failure 412 {object} json_models.Problem "Version mismatch"
+// @Failure 500 {object} json_models.Problem "Internal server error"
+// @Router /subscriptions/{id}/cancel [post]
+func (subscriptionHandler *SubscriptionHandler) cancelSubscription(w http.ResponseWriter, r *http.Request) {
+	subscriptionHandler.changeStatus(w, r, subscriptionHandler.service.CancelSubscription)
+}
+
+// changeStatus handles the status endpoints, which differ only in the service call.
+func (subscriptionHandler *SubscriptionHandler) changeStatus(
+	w http.ResponseWriter,
+	r *http.Request,
+	change func(ctx context.Context, subscriptionUUID uuid.UUID, expectedVersion *int) (sql_models.Subscription, error),
+) {
+	subscriptionID := r.PathValue("id")
+
+	subscriptionHandler.logger.Info("Change subscription status request",
+		zap.String("subscriptionID", subscriptionID),
+		zap.String("path", r.URL.Path))
+
+	subscriptionUUID, err := uuid.Parse(subscriptionID)
+	if err != nil {
+		subscriptionHandler.logger.Warn("Invalid subscription ID format",
+			zap.String("subscriptionID", subscriptionID),
+			zap.Error(err))
+		writeBadRequest(w, r, "Invalid subscription ID format")
+		return
+	}
+
+	expectedVersion, ok := parseIfMatch(r)
+	if !ok {
+		subscriptionHandler.logger.Warn("Invalid If-Match header",
+			zap.String("ifMatch", r.Header.Get("If-Match")))
+		writeBadRequest(w, r, "Invalid If-Match header")
+		return
+	}
+
+	response, err := change(r.Context(), subscriptionUUID, expectedVersion)
+	if err != nil {
+		subscriptionHandler.logger.Error("Failed to change subscription status",
+			zap.String("subscriptionID", subscriptionID),
+			zap.Error(err))
+		writeServiceError(w, r, err)
+		return
+	}
+
+	setETag(w, response)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		subscriptionHandler.logger.Error("Failed to encode response",
+			zap.Error(err),
+			zap.Any("response", response))
+	}
+}
+
 // updateSubscription updates subscription data
 // @Summary Update subscription
 // @Description Updates existing subscription data
@@ -164,9 +526,13 @@ func (subscriptionHandler *SubscriptionHandler) getSubscription(w http.ResponseW
 // @Accept json
 // @Produce json
 // @Param subscription body json_models.PutSubscription true "Update data"
+// @Param If-Match header string false "ETag of the version being updated"
 // @Success 202 {object} map[string]string
-// @Failure 400 {string} string "Invalid request format"
-// @Failure 500 {string} string "Internal server error"
+// @Failure 400 {object} json_models.Problem "Invalid request format"
+// @Failure 404 {object} json_models.Problem "Subscription not found"
+// @Failure 422 {object} json_models.Problem "Validation error"
+// @Failure 500 {object} json_models.Problem "Internal server error"
+// @Failure 412 {object} json_models.Problem "Version mismatch"
 // @Router /subscriptions/update-subscription [put]
 func (subscriptionHandler *SubscriptionHandler) updateSubscription(w http.ResponseWriter, r *http.Request) {
 	subscriptionHandler.logger.Info("Update subscription request received")
@@ -175,7 +541,7 @@ func (subscriptionHandler *SubscriptionHandler) updateSubscription(w http.Respon
 	if err := json.NewDecoder(r.Body).Decode(&subscription); err != nil {
 		subscriptionHandler.logger.Error("Failed to decode JSON request",
 			zap.Error(err))
-		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		writeBadRequest(w, r, "Invalid JSON format")
 		return
 	}
 
@@ -183,7 +549,7 @@ func (subscriptionHandler *SubscriptionHandler) updateSubscription(w http.Respon
 		subscriptionHandler.logger.Warn("Validation failed",
 			zap.Error(err),
 			zap.Any("subscription", subscription))
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeValidationError(w, r, err)
 		return
 	}
 
@@ -191,12 +557,20 @@ func (subscriptionHandler *SubscriptionHandler) updateSubscription(w http.Respon
 		zap.String("subscriptionID", subscription.SubscriptionID),
 		zap.String("serviceName", subscription.ServiceName))
 
-	err := subscriptionHandler.service.UpdateSubscription(r.Context(), subscription)
+	expectedVersion, ok := parseIfMatch(r)
+	if !ok {
+		subscriptionHandler.logger.Warn("Invalid If-Match header",
+			zap.String("ifMatch", r.Header.Get("If-Match")))
+		writeBadRequest(w, r, "Invalid If-Match header")
+		return
+	}
+
+	updated, err := subscriptionHandler.service.UpdateSubscription(r.Context(), subscription, expectedVersion)
 	if err != nil {
 		subscriptionHandler.logger.Error("Failed to update subscription",
 			zap.Error(err),
 			zap.Any("subscription", subscription))
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		writeServiceError(w, r, err)
 		return
 	}
 
@@ -204,6 +578,7 @@ func (subscriptionHandler *SubscriptionHandler) updateSubscription(w http.Respon
 		zap.String("subscriptionID", subscription.SubscriptionID),
 		zap.String("serviceName", subscription.ServiceName))
 
+	setETag(w, updated)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusAccepted)
 	response := map[string]string{
@@ -215,15 +590,96 @@ func (subscriptionHandler *SubscriptionHandler) updateSubscription(w http.Respon
 	}
 }
 
+// patchSubscription partially updates a subscription
+// @Summary Patch subscription
+// @Description Applies a JSON Merge Patch (RFC 7396): omitted fields stay unchanged, "end_date": null makes the subscription open-ended
+// @Tags Subscriptions
+// @Accept json
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Param subscription body json_models.PatchSubscription true "Fields to change"
+// @Param If-Match header string false "ETag of the version being updated"
+// @Success 200 {object} sql_models.Subscription
+// @Failure 400 {object} json_models.Problem "Invalid request format"
+// @Failure 404 {object} json_models.Problem "Subscription not found"
+// @Failure 422 {object} json_models.Problem "Validation error"
+// @Failure 500 {object} json_models.Problem "Internal server error"
+// @Failure 412 {object} json_models.Problem "Version mismatch"
+// @Router /subscriptions/{id} [patch]
+func (subscriptionHandler *SubscriptionHandler) patchSubscription(w http.ResponseWriter, r *http.Request) {
+	subscriptionID := r.PathValue("id")
+
+	subscriptionHandler.logger.Info("Patch subscription request received",
+		zap.String("subscriptionID", subscriptionID))
+
+	subscriptionUUID, err := uuid.Parse(subscriptionID)
+	if err != nil {
+		subscriptionHandler.logger.Warn("Invalid subscription ID format",
+			zap.String("subscriptionID", subscriptionID),
+			zap.Error(err))
+		writeBadRequest(w, r, "Invalid subscription ID format")
+		return
+	}
+
+	var patch json_models.PatchSubscription
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&patch); err != nil {
+		subscriptionHandler.logger.Error("Failed to decode JSON request",
+			zap.Error(err),
+			zap.String("path", r.URL.Path))
+		writeBadRequest(w, r, "Invalid JSON format or unknown field")
+		return
+	}
+
+	if fieldErrors := validatePatch(subscriptionHandler.validate, patch); len(fieldErrors) > 0 {
+		subscriptionHandler.logger.Warn("Validation failed",
+			zap.Any("errors", fieldErrors))
+		writeFieldErrors(w, r, fieldErrors)
+		return
+	}
+
+	expectedVersion, ok := parseIfMatch(r)
+	if !ok {
+		subscriptionHandler.logger.Warn("Invalid If-Match header",
+			zap.String("ifMatch", r.Header.Get("If-Match")))
+		writeBadRequest(w, r, "Invalid If-Match header")
+		return
+	}
+
+	response, err := subscriptionHandler.service.PatchSubscription(r.Context(), subscriptionUUID, patch, expectedVersion)
+	if err != nil {
+		subscriptionHandler.logger.Error("Failed to patch subscription",
+			zap.String("subscriptionID", subscriptionID),
+			zap.Error(err))
+		writeServiceError(w, r, err)
+		return
+	}
+
+	subscriptionHandler.logger.Info("Subscription patched successfully",
+		zap.String("subscriptionID", subscriptionID))
+
+	setETag(w, response)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		subscriptionHandler.logger.Error("Failed to encode response",
+			zap.Error(err),
+			zap.Any("response", response))
+	}
+}
+
 // deleteSubscription removes a subscription
 // @Summary Delete subscription
-// @Description Deletes specified subscription
+// @Description Soft-deletes specified subscription. It can be restored until it is purged
 // @Tags Subscriptions
 // @Param subscription-id query string true "Subscription ID"
+// @Param If-Match header string false "ETag of the version being deleted"
 // @Success 200 {object} map[string]string
-// @Failure 400 {string} string "Invalid parameters"
-// @Failure 404 {string} string "Subscription not found"
-// @Failure 500 {string} string "Internal server error"
+// @Failure 400 {object} json_models.Problem "Invalid parameters"
+// @Failure 404 {object} json_models.Problem "Subscription not found"
+// @Failure 500 {object} json_models.Problem "Internal server error"
+// @Failure 412 {object} json_models.Problem "Version mismatch"
 // @Router /subscriptions/delete-subscription [delete]
 func (subscriptionHandler *SubscriptionHandler) deleteSubscription(w http.ResponseWriter, r *http.Request) {
 	subscriptionIDStr := r.URL.Query().Get("subscription-id")
@@ -234,7 +690,7 @@ func (subscriptionHandler *SubscriptionHandler) deleteSubscription(w http.Respon
 	if subscriptionIDStr == "" {
 		subscriptionHandler.logger.Warn("Invalid query parameters",
 			zap.String("userID", subscriptionIDStr))
-		http.Error(w, "Invalid query parameters", http.StatusBadRequest)
+		writeBadRequest(w, r, "Invalid query parameters")
 		return
 	}
 
@@ -243,15 +699,23 @@ func (subscriptionHandler *SubscriptionHandler) deleteSubscription(w http.Respon
 		subscriptionHandler.logger.Warn("Invalid subscription ID format",
 			zap.String("userID", subscriptionIDStr),
 			zap.Error(err))
-		http.Error(w, "Invalid user ID format", http.StatusBadRequest)
+		writeBadRequest(w, r, "Invalid user ID format")
 		return
 	}
 
-	if err := subscriptionHandler.service.DeleteSubscription(r.Context(), subscriptionUUID); err != nil {
+	expectedVersion, ok := parseIfMatch(r)
+	if !ok {
+		subscriptionHandler.logger.Warn("Invalid If-Match header",
+			zap.String("ifMatch", r.Header.Get("If-Match")))
+		writeBadRequest(w, r, "Invalid If-Match header")
+		return
+	}
+
+	if err := subscriptionHandler.service.DeleteSubscription(r.Context(), subscriptionUUID, expectedVersion); err != nil {
 		subscriptionHandler.logger.Error("Failed to delete subscription",
 			zap.String("userID", subscriptionIDStr),
 			zap.Error(err))
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeServiceError(w, r, err)
 		return
 	}
 
@@ -270,15 +734,20 @@ func (subscriptionHandler *SubscriptionHandler) deleteSubscription(w http.Respon
 
 // calculateSubscriptionsCost calculates total cost of subscriptions
 // @Summary Calculate subscriptions cost
-// @Description Calculates total cost of subscriptions for given period with optional filters
+// @Description Calculates total cost of subscriptions for given period with optional filters.
+// @Description The response also contains a breakdown by month, service and user.
+// @Description Free trial months and paused months are not counted.
 // @Tags Subscriptions
 // @Param user-id query string false "User ID filter"
 // @Param service-name query string false "Service name filter"
-// @Param start-date query string true "Start date (format: 01-2006)"
-// @Param end-date query string false "End date (format: 01-2006)"
+// @Param start-date query string true "First day of the report; a month starts on its first day (format: 01-2006, 2006-01-02 or RFC 3339)"
+// @Param end-date query string false "Last day of the report; a month ends on its last day (format: 01-2006, 2006-01-02 or RFC 3339)"
+// @Param include-deleted query bool false "Also count soft-deleted subscriptions"
+// @Param currency query string false "Report currency (ISO 4217); the base currency by default"
 // @Success 200 {object} map[string]interface{}
-// @Failure 400 {string} string "Invalid query parameters"
-// @Failure 500 {string} string "Internal server error"
+// @Failure 400 {object} json_models.Problem "Invalid query parameters"
+// @Failure 422 {object} json_models.Problem "Validation error or missing exchange rate"
+// @Failure 500 {object} json_models.Problem "Internal server error"
 // @Router /subscriptions/calculate-cost [get]
 func (subscriptionHandler *SubscriptionHandler) calculateSubscriptionsCost(w http.ResponseWriter, r *http.Request) {
 	subscriptionHandler.logger.Info("Handling subscriptions cost calculation request")
@@ -286,63 +755,40 @@ func (subscriptionHandler *SubscriptionHandler) calculateSubscriptionsCost(w htt
 	var req json_models.CostRequest
 	if err := utils.QueryParser(r, &req); err != nil {
 		subscriptionHandler.logger.Error("Failed to parse query params", zap.Error(err))
-		http.Error(w, "Invalid query parameters", http.StatusBadRequest)
+		writeBadRequest(w, r, "Invalid query parameters")
 		return
 	}
 
 	if err := subscriptionHandler.validate.Struct(req); err != nil {
 		subscriptionHandler.logger.Warn("Validation failed",
 			zap.Error(err))
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeValidationError(w, r, err)
 		return
 	}
 
-	var userID *uuid.UUID
-	if req.UserID != nil {
-		id, err := uuid.Parse(*req.UserID)
-		if err != nil {
-			subscriptionHandler.logger.Warn("Invalid user ID format",
-				zap.String("userID", *req.UserID),
-				zap.Error(err))
-			http.Error(w, "Invalid user ID format", http.StatusBadRequest)
-			return
-		}
-		userID = &id
-	}
-
-	totalCost, err := subscriptionHandler.service.CalculateSubscriptionsCost(
-		r.Context(),
-		userID,
-		req.ServiceName,
-		req.StartDate,
-		req.EndDate,
-	)
+	report, err := subscriptionHandler.service.CalculateSubscriptionsCost(r.Context(), req)
 	if err != nil {
 		subscriptionHandler.logger.Error("Failed to calculate subscriptions cost",
 			zap.Error(err))
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		writeServiceError(w, r, err)
 		return
 	}
 
+	period := map[string]string{
+		"start": req.StartDate,
+	}
 	if req.EndDate != nil {
-		response := map[string]interface{}{
-			"period": map[string]string{
-				"start": req.StartDate,
-				"end":   *req.EndDate,
-			},
-			"total_cost": totalCost,
-		}
-		w.Header().Set("Content-Type", "application/json")
-		if err := json.NewEncoder(w).Encode(response); err != nil {
-			subscriptionHandler.logger.Error("Failed to encode response",
-				zap.Error(err))
-		}
-		return
+		period["end"] = *req.EndDate
 	}
 	response := map[string]interface{}{
-		"total_cost": totalCost,
-		"period": map[string]string{
-			"start": req.StartDate,
+		"period":     period,
+		"currency":   report.Currency,
+		"total_cost": report.TotalCost,
+		"rates_used": report.RatesUsed,
+		"breakdown": map[string]interface{}{
+			"months":   report.Months,
+			"services": report.Services,
+			"users":    report.Users,
 		},
 	}
 
@@ -352,3 +798,49 @@ func (subscriptionHandler *SubscriptionHandler) calculateSubscriptionsCost(w htt
 			zap.Error(err))
 	}
 }
+
+// upcomingCharges projects the charges of a user
+// @Summary Upcoming charges
+// @Description Lists every charge of the user's subscriptions in the next days, starting today in the user's time zone,
+// @Description in chronological order with a running total. Trial and paused months are skipped.
+// @Tags Subscriptions
+// @Produce json
+// @Param user-id query string true "User ID"
+// @Param days query int false "Projection length in days (1-366), 30 by default"
+// @Param currency query string false "Currency of the amounts (ISO 4217); the base currency by default"
+// @Success 200 {object} json_models.UpcomingCharges
+// @Failure 400 {object} json_models.Problem "Invalid query parameters"
+// @Failure 422 {object} json_models.Problem "Validation error or missing exchange rate"
+// @Failure 500 {object} json_models.Problem "Internal server error"
+// @Router /subscriptions/upcoming-charges [get]
+func (subscriptionHandler *SubscriptionHandler) upcomingCharges(w http.ResponseWriter, r *http.Request) {
+	subscriptionHandler.logger.Info("Handling upcoming charges request")
+
+	var req json_models.UpcomingChargesRequest
+	if err := utils.QueryParser(r, &req); err != nil {
+		subscriptionHandler.logger.Error("Failed to parse query params", zap.Error(err))
+		writeBadRequest(w, r, "Invalid query parameters")
+		return
+	}
+
+	if err := subscriptionHandler.validate.Struct(req); err != nil {
+		subscriptionHandler.logger.Warn("Validation failed",
+			zap.Error(err))
+		writeValidationError(w, r, err)
+		return
+	}
+
+	upcoming, err := subscriptionHandler.service.UpcomingCharges(r.Context(), req)
+	if err != nil {
+		subscriptionHandler.logger.Error("Failed to project upcoming charges",
+			zap.Error(err))
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(upcoming); err != nil {
+		subscriptionHandler.logger.Error("Failed to encode response",
+			zap.Error(err))
+	}
+}
@@ -0,0 +1,610 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"taskTestEffectMobile/internal/handler"
+	"taskTestEffectMobile/internal/models/json_models"
+	"taskTestEffectMobile/internal/models/sql_models"
+	"taskTestEffectMobile/internal/repository"
+	"taskTestEffectMobile/internal/service"
+	"testing"
+	"time"
+)
+
+const (
+	testUserID = "4b7c1f2e-8a3d-4c5e-9f10-2a3b4c5d6e7f"
+	missingID  = "0b1c2d3e-4f50-4a6b-8c7d-9e0f1a2b3c4d"
+)
+
+// newTestRouter wires the subscription and budget routes over the in-memory
+// storage the way main does with STORAGE=memory. Budget evaluation runs until
+// the test ends.
+func newTestRouter(t *testing.T) http.Handler {
+	logger := zap.NewNop()
+	subscriptionRepo := repository.NewInMemorySubscriptionRepository(logger)
+	exchangeRateService := service.NewExchangeRateService(repository.NewInMemoryExchangeRateRepository(logger), "RUB", logger)
+	userSettingsService := service.NewUserSettingsService(repository.NewInMemoryUserSettingsRepository(logger), time.UTC, logger)
+	budgetService := service.NewBudgetService(repository.NewInMemoryBudgetRepository(logger), subscriptionRepo, *exchangeRateService, *userSettingsService, repository.ProrationNone, logger)
+	subscriptionService := service.NewSubscriptionService(subscriptionRepo, *exchangeRateService, *userSettingsService, *budgetService, repository.ProrationNone, logger)
+	idempotencyService := service.NewIdempotencyService(repository.NewInMemoryIdempotencyRepository(logger), time.Hour, logger)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go budgetService.RunEvaluation(ctx, 0)
+
+	mux := http.NewServeMux()
+	handler.NewSubscriptionHandler(*subscriptionService, *idempotencyService, logger).CreateSubscriptionsRoutes(mux)
+	handler.NewBudgetHandler(*budgetService, logger).CreateBudgetRoutes(mux)
+	return handler.RequestMetadata(mux)
+}
+
+// serve runs one request through router; headers are given as name, value pairs.
+func serve(router http.Handler, method, target, body string, headers ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func decode[T any](t *testing.T, recorder *httptest.ResponseRecorder) T {
+	t.Helper()
+	var value T
+	if err := json.Unmarshal(recorder.Body.Bytes(), &value); err != nil {
+		t.Fatalf("failed to decode %q: %v", recorder.Body.String(), err)
+	}
+	return value
+}
+
+func expectStatus(t *testing.T, recorder *httptest.ResponseRecorder, status int) {
+	t.Helper()
+	if recorder.Code != status {
+		t.Fatalf("status = %d, want %d; body %s", recorder.Code, status, recorder.Body.String())
+	}
+}
+
+// expectProblem checks that the response is a problem+json body of the given type.
+func expectProblem(t *testing.T, recorder *httptest.ResponseRecorder, status int, problemType string) json_models.Problem {
+	t.Helper()
+	expectStatus(t, recorder, status)
+	if contentType := recorder.Header().Get("Content-Type"); contentType != "application/problem+json" {
+		t.Errorf("Content-Type = %q, want application/problem+json", contentType)
+	}
+	problem := decode[json_models.Problem](t, recorder)
+	if problem.Type != problemType || problem.Status != status {
+		t.Errorf("problem = %+v, want type %s and status %d", problem, problemType, status)
+	}
+	return problem
+}
+
+func createSubscription(t *testing.T, router http.Handler, serviceName string) string {
+	t.Helper()
+	body := fmt.Sprintf(`{"service_name": %q, "price": 300, "user_id": %q, "start_date": "01-2025"}`, serviceName, testUserID)
+	recorder := serve(router, http.MethodPost, "/api/v1/subscriptions/create-subscription", body)
+	expectStatus(t, recorder, http.StatusCreated)
+	return decode[map[string]string](t, recorder)["id"]
+}
+
+func userSubscriptions(t *testing.T, router http.Handler) []sql_models.Subscription {
+	t.Helper()
+	recorder := serve(router, http.MethodGet, "/api/v1/subscriptions/get-subscription?user-id="+testUserID, "")
+	expectStatus(t, recorder, http.StatusOK)
+	return decode[[]sql_models.Subscription](t, recorder)
+}
+
+// totalCost returns the total_cost of the cost report for query.
+func totalCost(t *testing.T, router http.Handler, query string) float64 {
+	t.Helper()
+	recorder := serve(router, http.MethodGet, "/api/v1/subscriptions/calculate-cost?"+query, "")
+	expectStatus(t, recorder, http.StatusOK)
+	return decode[struct {
+		TotalCost float64 `json:"total_cost"`
+	}](t, recorder).TotalCost
+}
+
+func TestSubscriptionCRUD(t *testing.T) {
+	router := newTestRouter(t)
+	id := createSubscription(t, router, "Yandex Plus")
+
+	subs := userSubscriptions(t, router)
+	if len(subs) != 1 || subs[0].ID != id || subs[0].ServiceName != "Yandex Plus" || subs[0].Price != 300 || subs[0].UserID != testUserID {
+		t.Errorf("created subscriptions = %+v", subs)
+	}
+
+	recorder := serve(router, http.MethodGet, "/api/v1/subscriptions/"+id, "")
+	expectStatus(t, recorder, http.StatusOK)
+	if sub := decode[sql_models.Subscription](t, recorder); sub.ID != id || sub.ServiceName != "Yandex Plus" {
+		t.Errorf("subscription by ID = %+v", sub)
+	}
+
+	body := fmt.Sprintf(`{"service_name": "Kinopoisk", "price": 400, "subscription_id": %q, "start_date": "01-2025"}`, id)
+	recorder = serve(router, http.MethodPut, "/api/v1/subscriptions/update-subscription", body)
+	expectStatus(t, recorder, http.StatusAccepted)
+
+	subs = userSubscriptions(t, router)
+	if len(subs) != 1 || subs[0].ServiceName != "Kinopoisk" || subs[0].Price != 400 {
+		t.Errorf("updated subscriptions = %+v", subs)
+	}
+
+	recorder = serve(router, http.MethodDelete, "/api/v1/subscriptions/delete-subscription?subscription-id="+id, "")
+	expectStatus(t, recorder, http.StatusOK)
+
+	if subs := userSubscriptions(t, router); len(subs) != 0 {
+		t.Errorf("subscriptions after delete = %+v", subs)
+	}
+
+	recorder = serve(router, http.MethodGet, "/api/v1/subscriptions/"+id, "")
+	expectProblem(t, recorder, http.StatusNotFound, "/problems/not-found")
+}
+
+func TestCalculateCost(t *testing.T) {
+	router := newTestRouter(t)
+	createSubscription(t, router, "Yandex Plus")
+	createSubscription(t, router, "Kinopoisk")
+
+	if total := totalCost(t, router, "start-date=01-2025&end-date=03-2025&service-name=Kinopoisk&user-id="+testUserID); total != 900 {
+		t.Errorf("total_cost = %v, want 900", total)
+	}
+}
+
+func TestProblemResponses(t *testing.T) {
+	router := newTestRouter(t)
+	createSubscription(t, router, "Yandex Plus")
+
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		body        string
+		status      int
+		problemType string
+	}{
+		{name: "malformed JSON", method: http.MethodPost, target: "/api/v1/subscriptions/create-subscription", body: `{"service_name":`, status: http.StatusBadRequest, problemType: "/problems/bad-request"},
+		{name: "invalid fields", method: http.MethodPost, target: "/api/v1/subscriptions/create-subscription", body: `{"service_name": "Netflix", "price": -1, "user_id": "nobody", "start_date": "2025"}`, status: http.StatusUnprocessableEntity, problemType: "/problems/validation-error"},
+		{name: "invalid user ID", method: http.MethodGet, target: "/api/v1/subscriptions/get-subscription?user-id=nobody", status: http.StatusBadRequest, problemType: "/problems/bad-request"},
+		{name: "missing subscription ID", method: http.MethodDelete, target: "/api/v1/subscriptions/delete-subscription", status: http.StatusBadRequest, problemType: "/problems/bad-request"},
+		{name: "end before start", method: http.MethodPost, target: "/api/v1/subscriptions/create-subscription", body: fmt.Sprintf(`{"service_name": "Netflix", "price": 300, "user_id": %q, "start_date": "05-2025", "end_date": "01-2025"}`, testUserID), status: http.StatusUnprocessableEntity, problemType: "/problems/invalid-date-range"},
+		{name: "update of a missing subscription", method: http.MethodPut, target: "/api/v1/subscriptions/update-subscription", body: fmt.Sprintf(`{"service_name": "Netflix", "price": 300, "subscription_id": %q, "start_date": "01-2025"}`, missingID), status: http.StatusNotFound, problemType: "/problems/not-found"},
+		{name: "delete of a missing subscription", method: http.MethodDelete, target: "/api/v1/subscriptions/delete-subscription?subscription-id=" + missingID, status: http.StatusNotFound, problemType: "/problems/not-found"},
+		{name: "invalid subscription ID", method: http.MethodGet, target: "/api/v1/subscriptions/not-a-uuid", status: http.StatusBadRequest, problemType: "/problems/bad-request"},
+		{name: "missing subscription", method: http.MethodGet, target: "/api/v1/subscriptions/" + missingID, status: http.StatusNotFound, problemType: "/problems/not-found"},
+		{name: "overlapping subscription", method: http.MethodPost, target: "/api/v1/subscriptions/create-subscription", body: fmt.Sprintf(`{"service_name": "Yandex Plus", "price": 300, "user_id": %q, "start_date": "03-2025"}`, testUserID), status: http.StatusConflict, problemType: "/problems/conflict"},
+		{name: "cost without start date", method: http.MethodGet, target: "/api/v1/subscriptions/calculate-cost?user-id=" + testUserID, status: http.StatusUnprocessableEntity, problemType: "/problems/validation-error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problem := expectProblem(t, serve(router, tt.method, tt.target, tt.body), tt.status, tt.problemType)
+			if problem.Instance != tt.target {
+				t.Errorf("instance = %q, want %q", problem.Instance, tt.target)
+			}
+		})
+	}
+}
+
+func TestNotFoundProblemNamesResource(t *testing.T) {
+	router := newTestRouter(t)
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		title  string
+	}{
+		{name: "subscription", method: http.MethodGet, target: "/api/v1/subscriptions/" + missingID, title: "Subscription not found"},
+		{name: "budget", method: http.MethodDelete, target: "/api/v1/users/" + testUserID + "/budgets/" + missingID, title: "Budget not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problem := expectProblem(t, serve(router, tt.method, tt.target, ""), http.StatusNotFound, "/problems/not-found")
+			if problem.Title != tt.title || strings.Contains(problem.Detail, missingID) {
+				t.Errorf("problem = %+v, want title %q and a fixed detail", problem, tt.title)
+			}
+		})
+	}
+}
+
+func TestValidationProblemListsFields(t *testing.T) {
+	router := newTestRouter(t)
+
+	recorder := serve(router, http.MethodPost, "/api/v1/subscriptions/create-subscription",
+		`{"service_name": "Netflix", "price": -1, "user_id": "nobody", "start_date": "01-2025"}`)
+	problem := expectProblem(t, recorder, http.StatusUnprocessableEntity, "/problems/validation-error")
+
+	fields := make(map[string]string)
+	for _, fieldErr := range problem.Errors {
+		fields[fieldErr.Field] = fieldErr.Rule
+	}
+	if fields["price"] != "gt" || fields["user_id"] != "uuid4" || len(fields) != 2 {
+		t.Errorf("field errors = %+v", problem.Errors)
+	}
+}
+
+func TestListCursorPaging(t *testing.T) {
+	router := newTestRouter(t)
+	created := make(map[string]bool)
+	for i := 0; i < 5; i++ {
+		created[createSubscription(t, router, fmt.Sprintf("Service %d", i))] = true
+	}
+
+	seen := make(map[string]bool)
+	target := "/api/v1/subscriptions?limit=2&sort=created_at&user-id=" + testUserID
+	pages := 0
+	for {
+		recorder := serve(router, http.MethodGet, target, "")
+		expectStatus(t, recorder, http.StatusOK)
+		page := decode[json_models.SubscriptionPage](t, recorder)
+		pages++
+
+		if page.Pagination.Limit != 2 || len(page.Data) > 2 {
+			t.Fatalf("page %d = %+v", pages, page)
+		}
+		for _, sub := range page.Data {
+			if seen[sub.ID] {
+				t.Errorf("subscription %s returned twice", sub.ID)
+			}
+			seen[sub.ID] = true
+		}
+		if !page.Pagination.HasMore {
+			if page.Pagination.NextCursor != nil {
+				t.Errorf("last page has a cursor %q", *page.Pagination.NextCursor)
+			}
+			break
+		}
+		if page.Pagination.NextCursor == nil || pages > 5 {
+			t.Fatalf("page %d has more without a cursor", pages)
+		}
+		target = "/api/v1/subscriptions?limit=2&sort=created_at&user-id=" + testUserID + "&cursor=" + *page.Pagination.NextCursor
+	}
+
+	if pages != 3 || len(seen) != len(created) {
+		t.Errorf("got %d subscriptions in %d pages, want %d in 3", len(seen), pages, len(created))
+	}
+	for id := range created {
+		if !seen[id] {
+			t.Errorf("subscription %s was not listed", id)
+		}
+	}
+}
+
+func TestListFilters(t *testing.T) {
+	router := newTestRouter(t)
+	createSubscription(t, router, "Yandex Plus")
+	body := fmt.Sprintf(`{"service_name": "Netflix", "price": 900, "user_id": %q, "start_date": "03-2025", "end_date": "06-2025"}`, testUserID)
+	expectStatus(t, serve(router, http.MethodPost, "/api/v1/subscriptions/create-subscription", body), http.StatusCreated)
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{query: "service-name=Netflix", want: []string{"Netflix"}},
+		{query: "service-name=netflix", want: []string{"Netflix"}},
+		{query: "service-name=Net"},
+		{query: "sort=price&user-id=" + testUserID, want: []string{"Yandex Plus", "Netflix"}},
+		{query: "min-price=500", want: []string{"Netflix"}},
+		{query: "max-price=500", want: []string{"Yandex Plus"}},
+		{query: "active-at=01-2025", want: []string{"Yandex Plus"}},
+		{query: "active-at=2025-01-15", want: []string{"Yandex Plus"}},
+		{query: "end-from=2025-06-30", want: []string{"Netflix"}},
+		{query: "end-from=2025-07-01"},
+		{query: "end-from=01-2025", want: []string{"Netflix"}},
+		{query: "sort=price&order=desc", want: []string{"Netflix", "Yandex Plus"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			recorder := serve(router, http.MethodGet, "/api/v1/subscriptions?"+tt.query, "")
+			expectStatus(t, recorder, http.StatusOK)
+			page := decode[json_models.SubscriptionPage](t, recorder)
+
+			var got []string
+			for _, sub := range page.Data {
+				got = append(got, sub.ServiceName)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("services = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListCursorErrors(t *testing.T) {
+	router := newTestRouter(t)
+	for i := 0; i < 3; i++ {
+		createSubscription(t, router, fmt.Sprintf("Service %d", i))
+	}
+
+	recorder := serve(router, http.MethodGet, "/api/v1/subscriptions?limit=1&sort=price", "")
+	expectStatus(t, recorder, http.StatusOK)
+	page := decode[json_models.SubscriptionPage](t, recorder)
+	if page.Pagination.NextCursor == nil {
+		t.Fatalf("first page has no cursor: %+v", page)
+	}
+
+	recorder = serve(router, http.MethodGet, "/api/v1/subscriptions?limit=1&sort=start_date&cursor="+*page.Pagination.NextCursor, "")
+	expectProblem(t, recorder, http.StatusUnprocessableEntity, "/problems/validation-error")
+
+	recorder = serve(router, http.MethodGet, "/api/v1/subscriptions?cursor=%21%21", "")
+	expectProblem(t, recorder, http.StatusUnprocessableEntity, "/problems/validation-error")
+}
+
+func TestPatchSubscription(t *testing.T) {
+	router := newTestRouter(t)
+	id := createSubscription(t, router, "Yandex Plus")
+
+	recorder := serve(router, http.MethodPatch, "/api/v1/subscriptions/"+id, `{"price": 400, "end_date": "12-2025"}`)
+	expectStatus(t, recorder, http.StatusOK)
+	sub := decode[sql_models.Subscription](t, recorder)
+	if sub.Price != 400 || sub.EndDate == nil || sub.ServiceName != "Yandex Plus" {
+		t.Errorf("patched subscription = %+v", sub)
+	}
+
+	recorder = serve(router, http.MethodPatch, "/api/v1/subscriptions/"+id, `{"end_date": null}`)
+	expectStatus(t, recorder, http.StatusOK)
+	if sub := decode[sql_models.Subscription](t, recorder); sub.EndDate != nil || sub.Price != 400 {
+		t.Errorf("subscription after clearing end_date = %+v", sub)
+	}
+
+	tests := []struct {
+		name        string
+		body        string
+		status      int
+		problemType string
+	}{
+		{name: "unknown field", body: `{"colour": "red"}`, status: http.StatusBadRequest, problemType: "/problems/bad-request"},
+		{name: "required field set to null", body: `{"price": null}`, status: http.StatusUnprocessableEntity, problemType: "/problems/validation-error"},
+		{name: "end before the stored start", body: `{"end_date": "12-2024"}`, status: http.StatusUnprocessableEntity, problemType: "/problems/invalid-date-range"},
+		{name: "start after the stored end", body: `{"end_date": "06-2025", "start_date": "07-2025"}`, status: http.StatusUnprocessableEntity, problemType: "/problems/invalid-date-range"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expectProblem(t, serve(router, http.MethodPatch, "/api/v1/subscriptions/"+id, tt.body), tt.status, tt.problemType)
+		})
+	}
+
+	expectProblem(t, serve(router, http.MethodPatch, "/api/v1/subscriptions/"+missingID, `{"price": 400}`), http.StatusNotFound, "/problems/not-found")
+}
+
+func TestIfMatch(t *testing.T) {
+	router := newTestRouter(t)
+	id := createSubscription(t, router, "Yandex Plus")
+	patch := `{"price": 400}`
+
+	recorder := serve(router, http.MethodGet, "/api/v1/subscriptions/"+id, "")
+	expectStatus(t, recorder, http.StatusOK)
+	if etag := recorder.Header().Get("ETag"); etag != `"1"` {
+		t.Errorf("ETag = %s, want \"1\"", etag)
+	}
+
+	recorder = serve(router, http.MethodPatch, "/api/v1/subscriptions/"+id, patch, "If-Match", `"2"`)
+	expectProblem(t, recorder, http.StatusPreconditionFailed, "/problems/precondition-failed")
+
+	recorder = serve(router, http.MethodPatch, "/api/v1/subscriptions/"+id, patch, "If-Match", "v1")
+	expectProblem(t, recorder, http.StatusBadRequest, "/problems/bad-request")
+
+	recorder = serve(router, http.MethodPatch, "/api/v1/subscriptions/"+id, patch, "If-Match", `W/"1"`)
+	expectStatus(t, recorder, http.StatusOK)
+	if etag := recorder.Header().Get("ETag"); etag != `"2"` {
+		t.Errorf("ETag = %s, want \"2\"", etag)
+	}
+
+	body := fmt.Sprintf(`{"service_name": "Kinopoisk", "price": 300, "subscription_id": %q, "start_date": "01-2025"}`, id)
+	recorder = serve(router, http.MethodPut, "/api/v1/subscriptions/update-subscription", body, "If-Match", `"1"`)
+	expectProblem(t, recorder, http.StatusPreconditionFailed, "/problems/precondition-failed")
+
+	recorder = serve(router, http.MethodDelete, "/api/v1/subscriptions/delete-subscription?subscription-id="+id, "", "If-Match", `"1"`)
+	expectProblem(t, recorder, http.StatusPreconditionFailed, "/problems/precondition-failed")
+
+	recorder = serve(router, http.MethodPut, "/api/v1/subscriptions/update-subscription", body, "If-Match", "*")
+	expectStatus(t, recorder, http.StatusAccepted)
+	if etag := recorder.Header().Get("ETag"); etag != `"3"` {
+		t.Errorf("ETag = %s, want \"3\"", etag)
+	}
+
+	recorder = serve(router, http.MethodDelete, "/api/v1/subscriptions/delete-subscription?subscription-id="+id, "", "If-Match", `"3"`)
+	expectStatus(t, recorder, http.StatusOK)
+}
+
+func TestCreateOverlappingSubscription(t *testing.T) {
+	router := newTestRouter(t)
+	id := createSubscription(t, router, "Yandex Plus")
+	body := fmt.Sprintf(`{"service_name": "Yandex Plus", "price": 300, "user_id": %q, "start_date": "03-2025"}`, testUserID)
+	forced := fmt.Sprintf(`{"service_name": "Yandex Plus", "price": 300, "user_id": %q, "start_date": "03-2025", "force": true}`, testUserID)
+
+	problem := expectProblem(t, serve(router, http.MethodPost, "/api/v1/subscriptions/create-subscription", body), http.StatusConflict, "/problems/conflict")
+	if problem.ExistingSubscription != "/api/v1/subscriptions/"+id {
+		t.Errorf("existing_subscription = %q, want the first subscription", problem.ExistingSubscription)
+	}
+
+	recorder := serve(router, http.MethodPost, "/api/v1/subscriptions/create-subscription", forced)
+	expectStatus(t, recorder, http.StatusCreated)
+	if subs := userSubscriptions(t, router); len(subs) != 2 {
+		t.Errorf("got %d subscriptions after a forced create, want 2", len(subs))
+	}
+}
+
+func TestSubscriptionHistory(t *testing.T) {
+	router := newTestRouter(t)
+	body := fmt.Sprintf(`{"service_name": "Yandex Plus", "price": 300, "user_id": %q, "start_date": "01-2025"}`, testUserID)
+	recorder := serve(router, http.MethodPost, "/api/v1/subscriptions/create-subscription", body, "X-Actor", "alice", "X-Request-ID", "req-1")
+	expectStatus(t, recorder, http.StatusCreated)
+	if requestID := recorder.Header().Get("X-Request-ID"); requestID != "req-1" {
+		t.Errorf("X-Request-ID = %q, want req-1", requestID)
+	}
+	id := decode[map[string]string](t, recorder)["id"]
+
+	expectStatus(t, serve(router, http.MethodPatch, "/api/v1/subscriptions/"+id, `{"price": 400}`), http.StatusOK)
+	expectStatus(t, serve(router, http.MethodDelete, "/api/v1/subscriptions/delete-subscription?subscription-id="+id, ""), http.StatusOK)
+
+	recorder = serve(router, http.MethodGet, "/api/v1/subscriptions/"+id+"/history", "")
+	expectStatus(t, recorder, http.StatusOK)
+	events := decode[[]sql_models.SubscriptionEvent](t, recorder)
+	if len(events) != 3 {
+		t.Fatalf("got %d events, want 3: %+v", len(events), events)
+	}
+	for i, want := range []string{sql_models.EventCreated, sql_models.EventUpdated, sql_models.EventDeleted} {
+		if events[i].EventType != want {
+			t.Errorf("event %d type = %s, want %s", i, events[i].EventType, want)
+		}
+	}
+	if events[0].Actor != "client:alice" || events[0].RequestID != "req-1" || string(events[0].Before) != "null" {
+		t.Errorf("created event = %+v", events[0])
+	}
+	if events[1].Actor != "anonymous" || events[1].RequestID == "" {
+		t.Errorf("updated event = %+v", events[1])
+	}
+
+	var before, after sql_models.Subscription
+	if err := json.Unmarshal(events[1].Before, &before); err != nil {
+		t.Fatalf("failed to decode before snapshot: %v", err)
+	}
+	if err := json.Unmarshal(events[1].After, &after); err != nil {
+		t.Fatalf("failed to decode after snapshot: %v", err)
+	}
+	if before.Price != 300 || after.Price != 400 {
+		t.Errorf("update snapshots have prices %d and %d, want 300 and 400", before.Price, after.Price)
+	}
+
+	expectProblem(t, serve(router, http.MethodGet, "/api/v1/subscriptions/"+missingID+"/history", ""), http.StatusNotFound, "/problems/not-found")
+}
+
+func TestSoftDeleteAndRestore(t *testing.T) {
+	router := newTestRouter(t)
+	id := createSubscription(t, router, "Yandex Plus")
+	expectStatus(t, serve(router, http.MethodDelete, "/api/v1/subscriptions/delete-subscription?subscription-id="+id, ""), http.StatusOK)
+
+	recorder := serve(router, http.MethodGet, "/api/v1/subscriptions/"+id+"?include-deleted=true", "")
+	expectStatus(t, recorder, http.StatusOK)
+	if sub := decode[sql_models.Subscription](t, recorder); sub.DeletedAt == nil {
+		t.Errorf("deleted subscription has no deleted_at: %+v", sub)
+	}
+
+	recorder = serve(router, http.MethodGet, "/api/v1/subscriptions?include-deleted=true", "")
+	expectStatus(t, recorder, http.StatusOK)
+	if page := decode[json_models.SubscriptionPage](t, recorder); len(page.Data) != 1 {
+		t.Errorf("admin list = %+v", page.Data)
+	}
+	expectProblem(t, serve(router, http.MethodDelete, "/api/v1/subscriptions/delete-subscription?subscription-id="+id, ""), http.StatusNotFound, "/problems/not-found")
+
+	recorder = serve(router, http.MethodPost, "/api/v1/subscriptions/"+id+"/restore", "")
+	expectStatus(t, recorder, http.StatusOK)
+	if sub := decode[sql_models.Subscription](t, recorder); sub.DeletedAt != nil {
+		t.Errorf("restored subscription = %+v", sub)
+	}
+	if subs := userSubscriptions(t, router); len(subs) != 1 {
+		t.Errorf("subscriptions after restore = %+v", subs)
+	}
+	expectProblem(t, serve(router, http.MethodPost, "/api/v1/subscriptions/"+missingID+"/restore", ""), http.StatusNotFound, "/problems/not-found")
+}
+
+func TestStatusTransitions(t *testing.T) {
+	router := newTestRouter(t)
+	id := createSubscription(t, router, "Yandex Plus")
+	statusPath := "/api/v1/subscriptions/" + id
+
+	tests := []struct {
+		action      string
+		status      int
+		wantStatus  string
+		problemType string
+	}{
+		{action: "pause", status: http.StatusOK, wantStatus: sql_models.StatusPaused},
+		{action: "pause", status: http.StatusConflict, problemType: "/problems/invalid-status-transition"},
+		{action: "resume", status: http.StatusOK, wantStatus: sql_models.StatusActive},
+		{action: "cancel", status: http.StatusOK, wantStatus: sql_models.StatusCancelled},
+		{action: "resume", status: http.StatusConflict, problemType: "/problems/invalid-status-transition"},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("%d %s", i, tt.action), func(t *testing.T) {
+			recorder := serve(router, http.MethodPost, statusPath+"/"+tt.action, "")
+			if tt.problemType != "" {
+				expectProblem(t, recorder, tt.status, tt.problemType)
+				return
+			}
+			expectStatus(t, recorder, tt.status)
+			if sub := decode[sql_models.Subscription](t, recorder); sub.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s", sub.Status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestTrialSubscription(t *testing.T) {
+	router := newTestRouter(t)
+	body := fmt.Sprintf(`{"service_name": "Yandex Plus", "price": 300, "user_id": %q, "start_date": "01-2025", "trial_end_date": "02-2025"}`, testUserID)
+	expectStatus(t, serve(router, http.MethodPost, "/api/v1/subscriptions/create-subscription", body), http.StatusCreated)
+
+	if total := totalCost(t, router, "start-date=01-2025&end-date=03-2025"); total != 300 {
+		t.Errorf("total_cost = %v, want 300", total)
+	}
+
+	body = fmt.Sprintf(`{"service_name": "Netflix", "price": 300, "user_id": %q, "start_date": "03-2025", "trial_end_date": "01-2025"}`, testUserID)
+	expectProblem(t, serve(router, http.MethodPost, "/api/v1/subscriptions/create-subscription", body), http.StatusUnprocessableEntity, "/problems/validation-error")
+}
+
+func TestPriceHistory(t *testing.T) {
+	router := newTestRouter(t)
+	id := createSubscription(t, router, "Yandex Plus")
+
+	recorder := serve(router, http.MethodPatch, "/api/v1/subscriptions/"+id, `{"price": 700, "price_effective_from": "03-2025"}`)
+	expectStatus(t, recorder, http.StatusOK)
+
+	recorder = serve(router, http.MethodGet, "/api/v1/subscriptions/"+id+"/prices", "")
+	expectStatus(t, recorder, http.StatusOK)
+	prices := decode[[]sql_models.SubscriptionPrice](t, recorder)
+	if len(prices) != 2 || prices[0].Price != 300 || prices[1].Price != 700 {
+		t.Errorf("prices = %+v", prices)
+	}
+
+	if total := totalCost(t, router, "start-date=01-2025&end-date=04-2025&user-id="+testUserID); total != 2000 {
+		t.Errorf("total_cost = %v, want 2000", total)
+	}
+
+	recorder = serve(router, http.MethodPatch, "/api/v1/subscriptions/"+id, `{"price": 900, "price_effective_from": "01-2099"}`)
+	expectStatus(t, recorder, http.StatusOK)
+	if sub := decode[sql_models.Subscription](t, recorder); sub.Price != 700 {
+		t.Errorf("price after scheduling a future change = %d, want 700", sub.Price)
+	}
+
+	expectProblem(t, serve(router, http.MethodGet, "/api/v1/subscriptions/"+missingID+"/prices", ""), http.StatusNotFound, "/problems/not-found")
+}
+
+func TestDayPrecisionDates(t *testing.T) {
+	router := newTestRouter(t)
+
+	body := fmt.Sprintf(`{"service_name": "Yandex Plus", "price": 300, "user_id": %q, "start_date": "2025-01-15", "end_date": "02-2025"}`, testUserID)
+	recorder := serve(router, http.MethodPost, "/api/v1/subscriptions/create-subscription", body)
+	expectStatus(t, recorder, http.StatusCreated)
+
+	subs := userSubscriptions(t, router)
+	if len(subs) != 1 || !subs[0].StartDate.Equal(time.Date(2025, time.January, 15, 0, 0, 0, 0, time.UTC)) ||
+		subs[0].EndDate == nil || !subs[0].EndDate.Equal(time.Date(2025, time.February, 28, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("subscriptions = %+v", subs)
+	}
+
+	body = fmt.Sprintf(`{"service_name": "Kinopoisk", "price": 300, "user_id": %q, "start_date": "2025-02"}`, testUserID)
+	expectProblem(t, serve(router, http.MethodPost, "/api/v1/subscriptions/create-subscription", body), http.StatusUnprocessableEntity, "/problems/validation-error")
+}
+
+func TestUpcomingCharges(t *testing.T) {
+	router := newTestRouter(t)
+	createSubscription(t, router, "Yandex Plus")
+
+	recorder := serve(router, http.MethodGet, "/api/v1/subscriptions/upcoming-charges?days=60&user-id="+testUserID, "")
+	expectStatus(t, recorder, http.StatusOK)
+	upcoming := decode[json_models.UpcomingCharges](t, recorder)
+	if len(upcoming.Charges) < 1 || upcoming.Charges[0].ServiceName != "Yandex Plus" || upcoming.Total != 300*float64(len(upcoming.Charges)) {
+		t.Errorf("upcoming charges = %+v", upcoming)
+	}
+
+	expectProblem(t, serve(router, http.MethodGet, "/api/v1/subscriptions/upcoming-charges?days=400&user-id="+testUserID, ""), http.StatusUnprocessableEntity, "/problems/validation-error")
+}
@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"net/http"
+	"taskTestEffectMobile/internal/models/json_models"
+	"taskTestEffectMobile/internal/service"
+)
+
+type UserSettingsHandler struct {
+	service  service.UserSettingsService
+	validate *validator.Validate
+	logger   *zap.Logger
+}
+
+func NewUserSettingsHandler(s service.UserSettingsService, logger *zap.Logger) *UserSettingsHandler {
+	return &UserSettingsHandler{
+		service:  s,
+		validate: newValidator(),
+		logger:   logger,
+	}
+}
+
+func (userSettingsHandler *UserSettingsHandler) CreateUserSettingsRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /api/v1/users/{id}/settings", userSettingsHandler.getUserSettings)
+	mux.HandleFunc("PUT /api/v1/users/{id}/settings", userSettingsHandler.updateUserSettings)
+}
+
+// getUserSettings returns the settings of a user
+// @Summary Get user settings
+// @Description Returns the time zone of the user; users without saved settings get the default one
+// @Tags Users
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} sql_models.UserSettings
+// @Failure 400 {object} json_models.Problem "Invalid UUID format"
+// @Failure 500 {object} json_models.Problem "Internal server error"
+// @Router /users/{id}/settings [get]
+func (userSettingsHandler *UserSettingsHandler) getUserSettings(w http.ResponseWriter, r *http.Request) {
+	userID := r.PathValue("id")
+
+	userSettingsHandler.logger.Info("Get user settings request",
+		zap.String("userID", userID))
+
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		userSettingsHandler.logger.Warn("Invalid user ID format",
+			zap.String("userID", userID),
+			zap.Error(err))
+		writeBadRequest(w, r, "Invalid user ID format")
+		return
+	}
+
+	settings, err := userSettingsHandler.service.GetUserSettings(r.Context(), userUUID)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	userSettingsHandler.writeSettings(w, settings)
+}
+
+// updateUserSettings saves the settings of a user
+// @Summary Update user settings
+// @Description Sets the IANA time zone used for the user's subscription statuses and charge dates
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param settings body json_models.UserSettingsInput true "Settings"
+// @Success 200 {object} sql_models.UserSettings
+// @Failure 400 {object} json_models.Problem "Invalid request format"
+// @Failure 422 {object} json_models.Problem "Validation error"
+// @Failure 500 {object} json_models.Problem "Internal server error"
+// @Router /users/{id}/settings [put]
+func (userSettingsHandler *UserSettingsHandler) updateUserSettings(w http.ResponseWriter, r *http.Request) {
+	userID := r.PathValue("id")
+
+	userSettingsHandler.logger.Info("Update user settings request",
+		zap.String("userID", userID))
+
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		userSettingsHandler.logger.Warn("Invalid user ID format",
+			zap.String("userID", userID),
+			zap.Error(err))
+		writeBadRequest(w, r, "Invalid user ID format")
+		return
+	}
+
+	var input json_models.UserSettingsInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		userSettingsHandler.logger.Error("Failed to decode JSON request",
+			zap.Error(err))
+		writeBadRequest(w, r, "Invalid JSON format")
+		return
+	}
+
+	if err := userSettingsHandler.validate.Struct(input); err != nil {
+		userSettingsHandler.logger.Warn("Validation failed",
+			zap.Error(err),
+			zap.Any("settings", input))
+		writeValidationError(w, r, err)
+		return
+	}
+
+	settings, err := userSettingsHandler.service.UpdateUserSettings(r.Context(), userUUID, input)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	userSettingsHandler.writeSettings(w, settings)
+}
+
+func (userSettingsHandler *UserSettingsHandler) writeSettings(w http.ResponseWriter, settings interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(settings); err != nil {
+		userSettingsHandler.logger.Error("Failed to encode response",
+			zap.Error(err))
+	}
+}
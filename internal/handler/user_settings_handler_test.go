@@ -0,0 +1,38 @@
+package handler_test
+
+import (
+	"go.uber.org/zap"
+	"net/http"
+	"taskTestEffectMobile/internal/handler"
+	"taskTestEffectMobile/internal/models/sql_models"
+	"taskTestEffectMobile/internal/repository"
+	"taskTestEffectMobile/internal/service"
+	"testing"
+	"time"
+)
+
+func TestUserSettings(t *testing.T) {
+	logger := zap.NewNop()
+	userSettingsService := service.NewUserSettingsService(repository.NewInMemoryUserSettingsRepository(logger), time.UTC, logger)
+	mux := http.NewServeMux()
+	handler.NewUserSettingsHandler(*userSettingsService, logger).CreateUserSettingsRoutes(mux)
+	target := "/api/v1/users/" + testUserID + "/settings"
+
+	recorder := serve(mux, http.MethodGet, target, "")
+	expectStatus(t, recorder, http.StatusOK)
+	if settings := decode[sql_models.UserSettings](t, recorder); settings.TimeZone != "UTC" || settings.UpdatedAt != nil {
+		t.Errorf("default settings = %+v", settings)
+	}
+
+	recorder = serve(mux, http.MethodPut, target, `{"time_zone": "Europe/Moscow"}`)
+	expectStatus(t, recorder, http.StatusOK)
+
+	recorder = serve(mux, http.MethodGet, target, "")
+	expectStatus(t, recorder, http.StatusOK)
+	if settings := decode[sql_models.UserSettings](t, recorder); settings.TimeZone != "Europe/Moscow" || settings.UpdatedAt == nil {
+		t.Errorf("saved settings = %+v", settings)
+	}
+
+	expectProblem(t, serve(mux, http.MethodPut, target, `{"time_zone": "Mars/Olympus"}`), http.StatusUnprocessableEntity, "/problems/validation-error")
+	expectProblem(t, serve(mux, http.MethodGet, "/api/v1/users/not-a-uuid/settings", ""), http.StatusBadRequest, "/problems/bad-request")
+}
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"reflect"
+	"strings"
+	"taskTestEffectMobile/internal/models/json_models"
+	"taskTestEffectMobile/internal/utils"
+)
+
+// fieldName reports struct fields by the name clients send: the json key for
+// bodies and the schema key for query parameters.
+func fieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "schema"} {
+		name := strings.SplitN(field.Tag.Get(tag), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+func newValidator() *validator.Validate {
+	validate := validator.New()
+	validate.RegisterTagNameFunc(fieldName)
+	_ = validate.RegisterValidation("date", isDate)
+	return validate
+}
+
+// isDate accepts the date formats understood by utils.ParseDate.
+func isDate(fl validator.FieldLevel) bool {
+	_, err := utils.ParseDate(fl.Field().String())
+	return err == nil
+}
+
+func validationMessage(fieldErr validator.FieldError) string {
+	switch fieldErr.Tag() {
+	case "required":
+		return "is required"
+	case "uuid4":
+		return "must be a valid UUID v4"
+	case "date":
+		return "must be a date in MM-YYYY, YYYY-MM-DD or RFC 3339 format"
+	case "datetime":
+		return fmt.Sprintf("must match the %s date format", fieldErr.Param())
+	case "gt":
+		return fmt.Sprintf("must be greater than %s", fieldErr.Param())
+	case "min":
+		return fmt.Sprintf("must be at least %s", fieldErr.Param())
+	case "max":
+		return fmt.Sprintf("must be at most %s", fieldErr.Param())
+	case "timezone":
+		return "must be an IANA time zone such as Europe/Moscow"
+	case "http_url":
+		return "must be an http or https URL"
+	case "iso4217":
+		return "must be an ISO 4217 currency code"
+	case "oneof":
+		return fmt.Sprintf("must be one of: %s", fieldErr.Param())
+	default:
+		return fmt.Sprintf("failed the %q rule", fieldErr.Tag())
+	}
+}
+
+// validatePatch checks the fields present in a merge patch. Required fields
+// may be omitted but not set to null; only end_date can be cleared.
+func validatePatch(validate *validator.Validate, patch json_models.PatchSubscription) []json_models.FieldError {
+	var fieldErrors []json_models.FieldError
+	check := func(field string, set, null bool, value interface{}, rules string) {
+		if !set {
+			return
+		}
+		if null {
+			fieldErrors = append(fieldErrors, json_models.FieldError{Field: field, Rule: "required", Message: "cannot be null"})
+			return
+		}
+		if err := validate.Var(value, rules); err != nil {
+			var validationErrors validator.ValidationErrors
+			if errors.As(err, &validationErrors) {
+				for _, fieldErr := range validationErrors {
+					fieldErrors = append(fieldErrors, json_models.FieldError{Field: field, Rule: fieldErr.Tag(), Message: validationMessage(fieldErr)})
+				}
+			}
+		}
+	}
+
+	check("service_name", patch.ServiceName.Set, patch.ServiceName.Null, patch.ServiceName.Value, "required")
+	check("price", patch.Price.Set, patch.Price.Null, patch.Price.Value, "gt=0")
+	check("start_date", patch.StartDate.Set, patch.StartDate.Null, patch.StartDate.Value, "date")
+	check("end_date", patch.EndDate.Set, false, patch.EndDate.Value, "omitempty,date")
+	check("currency", patch.Currency.Set, patch.Currency.Null, patch.Currency.Value, "iso4217")
+	check("billing_interval", patch.BillingInterval.Set, patch.BillingInterval.Null, patch.BillingInterval.Value, "oneof=week month quarter year")
+	check("billing_interval_count", patch.BillingIntervalCount.Set, patch.BillingIntervalCount.Null, patch.BillingIntervalCount.Value, "min=1")
+	check("billing_anchor_day", patch.BillingAnchorDay.Set, patch.BillingAnchorDay.Null, patch.BillingAnchorDay.Value, "min=1,max=31")
+	check("price_effective_from", patch.PriceEffectiveFrom.Set, patch.PriceEffectiveFrom.Null, patch.PriceEffectiveFrom.Value, "date")
+	if patch.PriceEffectiveFrom.Set && !patch.Price.Set {
+		fieldErrors = append(fieldErrors, json_models.FieldError{Field: "price_effective_from", Rule: "required_with", Message: "requires price"})
+	}
+	return fieldErrors
+}
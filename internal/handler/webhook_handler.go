@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"net/http"
+	"taskTestEffectMobile/internal/models/json_models"
+	"taskTestEffectMobile/internal/service"
+)
+
+type WebhookHandler struct {
+	service  service.WebhookService
+	validate *validator.Validate
+	logger   *zap.Logger
+}
+
+func NewWebhookHandler(s service.WebhookService, logger *zap.Logger) *WebhookHandler {
+	return &WebhookHandler{
+		service:  s,
+		validate: newValidator(),
+		logger:   logger,
+	}
+}
+
+func (webhookHandler *WebhookHandler) CreateWebhookRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("POST /api/v1/webhooks", webhookHandler.registerEndpoint)
+	mux.HandleFunc("GET /api/v1/webhooks", webhookHandler.listEndpoints)
+	mux.HandleFunc("DELETE /api/v1/webhooks/{id}", webhookHandler.deleteEndpoint)
+	mux.HandleFunc("GET /api/v1/webhooks/{id}/deliveries", webhookHandler.listDeliveries)
+	mux.HandleFunc("POST /api/v1/webhooks/{id}/deliveries/{deliveryID}/redeliver", webhookHandler.redeliver)
+}
+
+// registerEndpoint registers a webhook endpoint
+// @Summary Register webhook endpoint
+// @Description Registers a URL that receives subscription events. Every event type is sent when event_types is omitted.
+// @Description The secret signs the deliveries and is returned only in this response; one is generated when omitted
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Param endpoint body json_models.WebhookEndpointInput true "Webhook endpoint"
+// @Success 201 {object} sql_models.WebhookEndpoint
+// @Failure 400 {object} json_models.Problem "Invalid request format"
+// @Failure 422 {object} json_models.Problem "Validation error"
+// @Failure 500 {object} json_models.Problem "Internal server error"
+// @Router /webhooks [post]
+func (webhookHandler *WebhookHandler) registerEndpoint(w http.ResponseWriter, r *http.Request) {
+	var input json_models.WebhookEndpointInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		webhookHandler.logger.Error("Failed to decode JSON request",
+			zap.Error(err))
+		writeBadRequest(w, r, "Invalid JSON format")
+		return
+	}
+
+	if err := webhookHandler.validate.Struct(input); err != nil {
+		webhookHandler.logger.Warn("Validation failed",
+			zap.Error(err),
+			zap.String("url", input.URL))
+		writeValidationError(w, r, err)
+		return
+	}
+
+	endpoint, err := webhookHandler.service.RegisterEndpoint(r.Context(), input)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	webhookHandler.writeJSON(w, http.StatusCreated, endpoint)
+}
+
+// listEndpoints returns the webhook endpoints
+// @Summary List webhook endpoints
+// @Description Returns the registered endpoints without their secrets
+// @Tags Webhooks
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} json_models.Problem "Internal server error"
+// @Router /webhooks [get]
+func (webhookHandler *WebhookHandler) listEndpoints(w http.ResponseWriter, r *http.Request) {
+	endpoints, err := webhookHandler.service.ListEndpoints(r.Context())
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	webhookHandler.writeJSON(w, http.StatusOK, map[string]interface{}{"webhooks": endpoints})
+}
+
+// deleteEndpoint removes a webhook endpoint
+// @Summary Delete webhook endpoint
+// @Description Deletes the endpoint together with its delivery log; pending deliveries are dropped
+// @Tags Webhooks
+// @Param id path string true "Webhook endpoint ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} json_models.Problem "Invalid UUID format"
+// @Failure 404 {object} json_models.Problem "Webhook endpoint not found"
+// @Failure 500 {object} json_models.Problem "Internal server error"
+// @Router /webhooks/{id} [delete]
+func (webhookHandler *WebhookHandler) deleteEndpoint(w http.ResponseWriter, r *http.Request) {
+	endpointUUID, ok := webhookHandler.parseUUID(w, r, "id", "Invalid webhook ID format")
+	if !ok {
+		return
+	}
+
+	if err := webhookHandler.service.DeleteEndpoint(r.Context(), endpointUUID); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listDeliveries returns the delivery log of a webhook endpoint
+// @Summary List webhook deliveries
+// @Description Returns the deliveries of the endpoint with their status and the outcome of the last attempt, newest first
+// @Tags Webhooks
+// @Produce json
+// @Param id path string true "Webhook endpoint ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} json_models.Problem "Invalid UUID format"
+// @Failure 404 {object} json_models.Problem "Webhook endpoint not found"
+// @Failure 500 {object} json_models.Problem "Internal server error"
+// @Router /webhooks/{id}/deliveries [get]
+func (webhookHandler *WebhookHandler) listDeliveries(w http.ResponseWriter, r *http.Request) {
+	endpointUUID, ok := webhookHandler.parseUUID(w, r, "id", "Invalid webhook ID format")
+	if !ok {
+		return
+	}
+
+	deliveries, err := webhookHandler.service.ListDeliveries(r.Context(), endpointUUID)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	webhookHandler.writeJSON(w, http.StatusOK, map[string]interface{}{"deliveries": deliveries})
+}
+
+// redeliver queues a delivery again
+// @Summary Redeliver webhook
+// @Description Queues the delivery again with a fresh set of attempts. The same event ID and payload are sent
+// @Tags Webhooks
+// @Produce json
+// @Param id path string true "Webhook endpoint ID"
+// @Param deliveryID path string true "Delivery ID"
+// @Success 202 {object} sql_models.WebhookDelivery
+// @Failure 400 {object} json_models.Problem "Invalid UUID format"
+// @Failure 404 {object} json_models.Problem "Webhook delivery not found"
+// @Failure 500 {object} json_models.Problem "Internal server error"
+// @Router /webhooks/{id}/deliveries/{deliveryID}/redeliver [post]
+func (webhookHandler *WebhookHandler) redeliver(w http.ResponseWriter, r *http.Request) {
+	endpointUUID, ok := webhookHandler.parseUUID(w, r, "id", "Invalid webhook ID format")
+	if !ok {
+		return
+	}
+	deliveryUUID, ok := webhookHandler.parseUUID(w, r, "deliveryID", "Invalid delivery ID format")
+	if !ok {
+		return
+	}
+
+	delivery, err := webhookHandler.service.Redeliver(r.Context(), endpointUUID, deliveryUUID)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	webhookHandler.writeJSON(w, http.StatusAccepted, delivery)
+}
+
+func (webhookHandler *WebhookHandler) parseUUID(w http.ResponseWriter, r *http.Request, name, message string) (uuid.UUID, bool) {
+	value := r.PathValue(name)
+
+	parsed, err := uuid.Parse(value)
+	if err != nil {
+		webhookHandler.logger.Warn(message,
+			zap.String(name, value),
+			zap.Error(err))
+		writeBadRequest(w, r, message)
+		return uuid.UUID{}, false
+	}
+	return parsed, true
+}
+
+func (webhookHandler *WebhookHandler) writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		webhookHandler.logger.Error("Failed to encode response",
+			zap.Error(err))
+	}
+}
@@ -0,0 +1,73 @@
+package domain_errors
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sentinel errors shared by the repository and service layers.
+// Wrap them with fmt.Errorf("...: %w", err) and check with errors.Is.
+// The wrapped text is for logs only; use WithDetail for a message meant for
+// API clients.
+var (
+	ErrNotFound         = errors.New("not found")
+	ErrConflict         = errors.New("subscription conflict")
+	ErrValidation       = errors.New("validation failed")
+	ErrInvalidDateRange = errors.New("end date is before start date")
+	// ErrPreconditionFailed is returned when the stored version differs from the expected one.
+	ErrPreconditionFailed = errors.New("subscription version mismatch")
+	// ErrInvalidTransition is returned when the subscription status does not allow the requested change.
+	ErrInvalidTransition = errors.New("status transition is not allowed")
+	// ErrMissingExchangeRate is returned when a cost cannot be converted for lack of a rate.
+	ErrMissingExchangeRate = errors.New("exchange rate is missing")
+
+	// ErrIdempotencyKeyReused is returned when a key is sent again with a different request body.
+	ErrIdempotencyKeyReused = errors.New("idempotency key was used with a different request")
+	// ErrIdempotencyInProgress is returned while the first request with the key is still running.
+	ErrIdempotencyInProgress = errors.New("request with this idempotency key is in progress")
+)
+
+// Not-found errors of the individual resources. Each wraps ErrNotFound.
+var (
+	ErrSubscriptionNotFound    = fmt.Errorf("subscription %w", ErrNotFound)
+	ErrUserSettingsNotFound    = fmt.Errorf("user settings %w", ErrNotFound)
+	ErrCalendarFeedNotFound    = fmt.Errorf("calendar feed %w", ErrNotFound)
+	ErrBudgetNotFound          = fmt.Errorf("budget %w", ErrNotFound)
+	ErrWebhookEndpointNotFound = fmt.Errorf("webhook endpoint %w", ErrNotFound)
+	ErrWebhookDeliveryNotFound = fmt.Errorf("webhook delivery %w", ErrNotFound)
+)
+
+// DetailedError attaches a message that is safe to show to API clients to
+// one of the sentinels.
+type DetailedError struct {
+	Err    error
+	Detail string
+}
+
+func (e *DetailedError) Error() string {
+	return e.Err.Error() + ": " + e.Detail
+}
+
+func (e *DetailedError) Unwrap() error {
+	return e.Err
+}
+
+// WithDetail wraps err with a client-facing message. The arguments end up in
+// API responses, so never pass internal errors to it.
+func WithDetail(err error, format string, args ...interface{}) error {
+	return &DetailedError{Err: err, Detail: fmt.Sprintf(format, args...)}
+}
+
+// DuplicateSubscriptionError is returned when the same user already has an
+// active subscription to the service in an overlapping period.
+type DuplicateSubscriptionError struct {
+	ExistingID string
+}
+
+func (e *DuplicateSubscriptionError) Error() string {
+	return "subscription overlaps with existing subscription " + e.ExistingID
+}
+
+func (e *DuplicateSubscriptionError) Unwrap() error {
+	return ErrConflict
+}
@@ -0,0 +1,21 @@
+package json_models
+
+import "taskTestEffectMobile/internal/models/sql_models"
+
+// json_models.BudgetInput model
+// @Description Monthly budget of a user; a budget without service_name covers all subscriptions
+type BudgetInput struct {
+	ServiceName *string `json:"service_name,omitempty" validate:"omitempty,min=1"`
+	Amount      float64 `json:"amount" validate:"required,gt=0"`
+	// Currency of the amount; the base currency when omitted.
+	Currency *string `json:"currency,omitempty" validate:"omitempty,iso4217"`
+}
+
+// json_models.BudgetStatus model
+// @Description Budget with the projected spend of the current month
+type BudgetStatus struct {
+	sql_models.Budget
+	Month   string  `json:"month"`
+	Spent   float64 `json:"spent"`
+	Percent float64 `json:"percent"`
+}
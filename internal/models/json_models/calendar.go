@@ -0,0 +1,15 @@
+package json_models
+
+// json_models.CalendarTokenInput model
+// @Description Options of a new calendar feed
+type CalendarTokenInput struct {
+	// ReminderDaysBefore adds a reminder that many days before each renewal; no reminders when omitted.
+	ReminderDaysBefore *int `json:"reminder_days_before,omitempty" validate:"omitempty,min=0,max=30"`
+}
+
+// json_models.CalendarFeed model
+// @Description Secret URL of a calendar feed; the token is shown only once
+type CalendarFeed struct {
+	Token string `json:"token"`
+	URL   string `json:"url"`
+}
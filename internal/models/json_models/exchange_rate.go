@@ -0,0 +1,18 @@
+package json_models
+
+// json_models.ExchangeRateInput model
+// @Description One row of an exchange-rate upload: one unit of currency costs rate units of the base currency from valid_from on
+type ExchangeRateInput struct {
+	Currency  string  `json:"currency" validate:"required,iso4217"`
+	ValidFrom string  `json:"valid_from" validate:"required,date"`
+	Rate      float64 `json:"rate" validate:"required,gt=0"`
+}
+
+// json_models.RateUsed model
+// @Description Conversion factor applied to the charges of one currency in one month
+type RateUsed struct {
+	Month string  `json:"month"`
+	From  string  `json:"from"`
+	To    string  `json:"to"`
+	Rate  float64 `json:"rate"`
+}
@@ -0,0 +1,25 @@
+package json_models
+
+import "encoding/json"
+
+// Nullable tells apart a JSON field that was omitted, explicitly set to null
+// or set to a value. It is used for JSON Merge Patch (RFC 7396) bodies.
+type Nullable[T any] struct {
+	Set   bool
+	Null  bool
+	Value T
+}
+
+func (n *Nullable[T]) UnmarshalJSON(data []byte) error {
+	n.Set = true
+	if string(data) == "null" {
+		n.Null = true
+		return nil
+	}
+	return json.Unmarshal(data, &n.Value)
+}
+
+// HasValue reports whether the field was sent with a non-null value.
+func (n Nullable[T]) HasValue() bool {
+	return n.Set && !n.Null
+}
@@ -1,15 +1,30 @@
 package json_models
 
-import "time"
+import (
+	"taskTestEffectMobile/internal/models/sql_models"
+	"time"
+)
 
 // json_models.CreateSubscription model
 // @Description Subscription information
 type CreateSubscription struct {
-	ServiceName string  `json:"service_name" validate:"required"`
-	Price       int     `json:"price" validate:"required,gt=0"`
-	UserID      string  `json:"user_id" validate:"required,uuid4"`
-	StartDate   string  `json:"start_date" validate:"required,datetime=01-2006"`
-	EndDate     *string `json:"end_date,omitempty" validate:"omitempty,datetime=01-2006"`
+	ServiceName string `json:"service_name" validate:"required"`
+	Price       int    `json:"price" validate:"required,gt=0"`
+	UserID      string `json:"user_id" validate:"required,uuid4"`
+	// Currency is an ISO 4217 code; the base currency is used when omitted.
+	Currency  string  `json:"currency,omitempty" validate:"omitempty,iso4217"`
+	StartDate string  `json:"start_date" validate:"required,date"`
+	EndDate   *string `json:"end_date,omitempty" validate:"omitempty,date"`
+	// TrialEndDate is the last free month; the subscription starts in the trial status when set.
+	// Trials are billed by month, so a day in the date is ignored.
+	TrialEndDate *string `json:"trial_end_date,omitempty" validate:"omitempty,date"`
+	// BillingInterval is how often Price is charged; monthly when omitted.
+	BillingInterval      string `json:"billing_interval,omitempty" validate:"omitempty,oneof=week month quarter year"`
+	BillingIntervalCount int    `json:"billing_interval_count,omitempty" validate:"omitempty,min=1"`
+	// BillingAnchorDay is the day of month the plan renews on; the start day when omitted.
+	BillingAnchorDay int `json:"billing_anchor_day,omitempty" validate:"omitempty,min=1,max=31"`
+	// Force allows stacking a plan on top of an overlapping subscription to the same service.
+	Force bool `json:"force,omitempty"`
 }
 
 // json_models.PutSubscription model
@@ -18,24 +33,243 @@ type PutSubscription struct {
 	ServiceName    string  `json:"service_name" validate:"required"`
 	Price          int     `json:"price" validate:"gt=0"`
 	SubscriptionID string  `json:"subscription_id" validate:"uuid4"`
-	StartDate      string  `json:"start_date" validate:"datetime=01-2006"`
-	EndDate        *string `json:"end_date,omitempty" validate:"omitempty,datetime=01-2006"`
+	StartDate      string  `json:"start_date" validate:"date"`
+	EndDate        *string `json:"end_date,omitempty" validate:"omitempty,date"`
+	// Omitted currency and billing fields keep their stored values.
+	Currency *string `json:"currency,omitempty" validate:"omitempty,iso4217"`
+	// PriceEffectiveFrom is the first month the price applies to; the current month when omitted.
+	// Prices change by month, so a day in the date is ignored.
+	PriceEffectiveFrom   *string `json:"price_effective_from,omitempty" validate:"omitempty,date"`
+	BillingInterval      *string `json:"billing_interval,omitempty" validate:"omitempty,oneof=week month quarter year"`
+	BillingIntervalCount *int    `json:"billing_interval_count,omitempty" validate:"omitempty,min=1"`
+	BillingAnchorDay     *int    `json:"billing_anchor_day,omitempty" validate:"omitempty,min=1,max=31"`
 }
 
 // json_models.SubscriptionUpdate model
 // @Description Subscription information
 type SubscriptionUpdate struct {
-	ServiceName string
-	Price       int
-	StartDate   *time.Time
-	EndDate     *time.Time
+	ServiceName *string
+	Price       *int
+	// PriceEffectiveFrom is the first month the new Price applies to.
+	PriceEffectiveFrom *time.Time
+	StartDate          *time.Time
+	EndDate            *time.Time
+	ClearEndDate       bool
+	Currency           *string
+	// CurrentMonth is the current month of the owner. The stored price stays
+	// the one in effect then, so a Price effective later only goes to the
+	// price history.
+	CurrentMonth time.Time
+
+	BillingInterval      *string
+	BillingIntervalCount *int
+	BillingAnchorDay     *int
+	// ExpectedVersion turns the update into a compare-and-swap when set.
+	ExpectedVersion *int
+}
+
+// json_models.PatchSubscription model
+// @Description JSON Merge Patch of a subscription: omitted fields are kept, null clears end_date
+type PatchSubscription struct {
+	ServiceName Nullable[string] `json:"service_name" swaggertype:"string"`
+	Price       Nullable[int]    `json:"price" swaggertype:"integer"`
+	// PriceEffectiveFrom is the first month a changed price applies to; the current month when omitted.
+	PriceEffectiveFrom Nullable[string] `json:"price_effective_from" swaggertype:"string"`
+	StartDate          Nullable[string] `json:"start_date" swaggertype:"string"`
+	EndDate            Nullable[string] `json:"end_date" swaggertype:"string"`
+	Currency           Nullable[string] `json:"currency" swaggertype:"string"`
+
+	BillingInterval      Nullable[string] `json:"billing_interval" swaggertype:"string"`
+	BillingIntervalCount Nullable[int]    `json:"billing_interval_count" swaggertype:"integer"`
+	BillingAnchorDay     Nullable[int]    `json:"billing_anchor_day" swaggertype:"integer"`
 }
 
 // json_models.CostRequest model
 // @Description Subscription information
 type CostRequest struct {
-	UserID      *string `schema:"user-id"`
+	UserID         *string `schema:"user-id" validate:"omitempty,uuid4"`
+	ServiceName    *string `schema:"service-name"`
+	StartDate      string  `schema:"start-date" validate:"required,date"`
+	EndDate        *string `schema:"end-date" validate:"omitempty,date"`
+	IncludeDeleted bool    `schema:"include-deleted"`
+	// Currency of the report; the base currency when omitted.
+	Currency *string `schema:"currency" validate:"omitempty,iso4217"`
+}
+
+// json_models.CostFilter model
+// @Description Parsed cost query passed to the repository; StartDate and EndDate are the first and last days of charges, inclusive
+type CostFilter struct {
+	UserID         *string
+	ServiceName    *string
+	StartDate      time.Time
+	EndDate        time.Time
+	IncludeDeleted bool
+	// Proration is the policy for partly covered periods: "none" or "daily".
+	Proration string
+}
+
+// json_models.CostReport model
+// @Description Subscriptions cost for a period with breakdowns
+type CostReport struct {
+	Currency  string        `json:"currency"`
+	TotalCost float64       `json:"total_cost"`
+	Months    []MonthCost   `json:"months"`
+	Services  []ServiceCost `json:"services"`
+	Users     []UserCost    `json:"users"`
+	RatesUsed []RateUsed    `json:"rates_used"`
+}
+
+// json_models.MonthCost model
+// @Description Total cost of a single month
+type MonthCost struct {
+	Month     string  `json:"month"`
+	TotalCost float64 `json:"total_cost"`
+}
+
+// json_models.ServiceCost model
+// @Description Total cost of a single service
+type ServiceCost struct {
+	ServiceName string  `json:"service_name"`
+	TotalCost   float64 `json:"total_cost"`
+}
+
+// json_models.UserCost model
+// @Description Total cost of a single user
+type UserCost struct {
+	UserID    string  `json:"user_id"`
+	TotalCost float64 `json:"total_cost"`
+}
+
+// json_models.UpcomingChargesRequest model
+// @Description Query of the upcoming charges of a user
+type UpcomingChargesRequest struct {
+	UserID string `schema:"user-id" validate:"required,uuid4"`
+	// Days is the length of the projection starting today; 30 when omitted.
+	Days int `schema:"days" validate:"omitempty,min=1,max=366"`
+	// Currency of the amounts; the base currency when omitted.
+	Currency *string `schema:"currency" validate:"omitempty,iso4217"`
+}
+
+// json_models.UpcomingChargesFilter model
+// @Description Parsed upcoming charges query passed to the repository
+type UpcomingChargesFilter struct {
+	// UserID limits the projection to one user; every user is included when empty.
+	UserID string
+	// From and To bound the charge dates; To is exclusive.
+	From      time.Time
+	To        time.Time
+	Proration string
+}
+
+// json_models.UpcomingCharges model
+// @Description Projected charges of a user in chronological order
+type UpcomingCharges struct {
+	UserID    string           `json:"user_id"`
+	From      string           `json:"from"`
+	To        string           `json:"to"`
+	Currency  string           `json:"currency"`
+	Total     float64          `json:"total"`
+	Charges   []UpcomingCharge `json:"charges"`
+	RatesUsed []RateUsed       `json:"rates_used"`
+}
+
+// json_models.UpcomingCharge model
+// @Description One projected charge; Amount and RunningTotal are in the currency of the response
+type UpcomingCharge struct {
+	Date           string  `json:"date"`
+	SubscriptionID string  `json:"subscription_id"`
+	ServiceName    string  `json:"service_name"`
+	Price          float64 `json:"price"`
+	Currency       string  `json:"currency"`
+	Amount         float64 `json:"amount"`
+	RunningTotal   float64 `json:"running_total"`
+}
+
+// json_models.Problem model
+// @Description RFC 7807 problem details
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Errors   []FieldError `json:"errors,omitempty"`
+	// ExistingSubscription points to the subscription a conflicting request collided with.
+	ExistingSubscription string `json:"existing_subscription,omitempty"`
+}
+
+// json_models.FieldError model
+// @Description Validation error of a single field
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// json_models.ListSubscriptionsRequest model
+// @Description Filters, sorting and pagination of the subscriptions list
+type ListSubscriptionsRequest struct {
+	UserID      *string `schema:"user-id" validate:"omitempty,uuid4"`
 	ServiceName *string `schema:"service-name"`
-	StartDate   string  `schema:"start-date" validate:"required,datetime=01-2006"`
-	EndDate     *string `schema:"end-date" validate:"omitempty,datetime=01-2006"`
+	MinPrice    *int    `schema:"min-price" validate:"omitempty,gte=0"`
+	MaxPrice    *int    `schema:"max-price" validate:"omitempty,gte=0"`
+	ActiveAt    *string `schema:"active-at" validate:"omitempty,date"`
+	StartFrom   *string `schema:"start-from" validate:"omitempty,date"`
+	StartTo     *string `schema:"start-to" validate:"omitempty,date"`
+	EndFrom     *string `schema:"end-from" validate:"omitempty,date"`
+	EndTo       *string `schema:"end-to" validate:"omitempty,date"`
+	Sort        string  `schema:"sort" validate:"omitempty,oneof=price start_date created_at"`
+	Order       string  `schema:"order" validate:"omitempty,oneof=asc desc"`
+	Limit       int     `schema:"limit" validate:"omitempty,min=1,max=100"`
+	Cursor      string  `schema:"cursor"`
+	// IncludeDeleted also returns soft-deleted subscriptions (admin view).
+	IncludeDeleted bool `schema:"include-deleted"`
+}
+
+// json_models.SubscriptionListFilter model
+// @Description Parsed subscriptions list query passed to the repository
+type SubscriptionListFilter struct {
+	UserID      *string
+	ServiceName *string
+	MinPrice    *int
+	MaxPrice    *int
+	// ActiveFrom and ActiveTo are set together and keep the subscriptions
+	// active on at least one day between them, inclusive.
+	ActiveFrom *time.Time
+	ActiveTo   *time.Time
+	StartFrom  *time.Time
+	StartTo    *time.Time
+	EndFrom    *time.Time
+	EndTo      *time.Time
+	Sort       string
+	Descending bool
+	Limit      int
+	After      *ListCursor
+
+	IncludeDeleted bool
+}
+
+// json_models.ListCursor model
+// @Description Position of the last returned subscription in the sort order
+type ListCursor struct {
+	Sort  string    `json:"s"`
+	Order string    `json:"o"`
+	ID    string    `json:"id"`
+	Price int       `json:"p,omitempty"`
+	Time  time.Time `json:"t,omitempty"`
+}
+
+// json_models.SubscriptionPage model
+// @Description A page of subscriptions with pagination metadata
+type SubscriptionPage struct {
+	Data       []sql_models.Subscription `json:"data"`
+	Pagination Pagination                `json:"pagination"`
+}
+
+// json_models.Pagination model
+// @Description Cursor pagination metadata
+type Pagination struct {
+	Limit      int     `json:"limit"`
+	HasMore    bool    `json:"has_more"`
+	NextCursor *string `json:"next_cursor"`
 }
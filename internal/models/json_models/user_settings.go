@@ -0,0 +1,7 @@
+package json_models
+
+// json_models.UserSettingsInput model
+// @Description Settings of a user
+type UserSettingsInput struct {
+	TimeZone string `json:"time_zone" validate:"required,timezone"`
+}
@@ -0,0 +1,20 @@
+package json_models
+
+import "time"
+
+// json_models.WebhookEndpointInput model
+// @Description Webhook endpoint registration; a secret is generated when omitted
+type WebhookEndpointInput struct {
+	URL        string   `json:"url" validate:"required,http_url"`
+	EventTypes []string `json:"event_types,omitempty" validate:"omitempty,dive,oneof=subscription.created subscription.updated subscription.deleted subscription.renewing"`
+	Secret     *string  `json:"secret,omitempty" validate:"omitempty,min=16"`
+}
+
+// json_models.WebhookEvent model
+// @Description Body of a webhook delivery
+type WebhookEvent struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
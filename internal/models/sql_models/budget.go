@@ -0,0 +1,34 @@
+package sql_models
+
+import "time"
+
+// sql_models.Budget model
+// @Description Monthly spending limit of a user, for all subscriptions or for one service
+type Budget struct {
+	ID     string `db:"id" json:"id"`
+	UserID string `db:"user_id" json:"user_id"`
+	// ServiceName limits the budget to one service; the budget is overall when empty.
+	ServiceName *string   `db:"service_name" json:"service_name,omitempty"`
+	Amount      float64   `db:"amount" json:"amount"`
+	Currency    string    `db:"currency" json:"currency"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// sql_models.BudgetAlert model
+// @Description Record of the projected spend of a month crossing a budget threshold
+type BudgetAlert struct {
+	ID          string  `db:"id" json:"id"`
+	BudgetID    string  `db:"budget_id" json:"budget_id"`
+	UserID      string  `db:"user_id" json:"user_id"`
+	ServiceName *string `db:"service_name" json:"service_name,omitempty"`
+	// Month is the first day of the month the spend was projected for.
+	Month time.Time `db:"month" json:"month"`
+	// Threshold is the crossed share of the budget in percent.
+	Threshold int `db:"threshold" json:"threshold"`
+	// Spent is the projected spend of the month when the threshold was crossed.
+	Spent     float64   `db:"spent" json:"spent"`
+	Amount    float64   `db:"amount" json:"amount"`
+	Currency  string    `db:"currency" json:"currency"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
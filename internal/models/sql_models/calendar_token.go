@@ -0,0 +1,12 @@
+package sql_models
+
+import "time"
+
+// CalendarToken grants read access to the iCalendar feed of a user.
+type CalendarToken struct {
+	UserID    string `db:"user_id"`
+	TokenHash string `db:"token_hash"`
+	// ReminderDays adds a reminder that many days before each renewal; nil disables reminders.
+	ReminderDays *int      `db:"reminder_days"`
+	CreatedAt    time.Time `db:"created_at"`
+}
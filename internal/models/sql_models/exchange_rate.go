@@ -0,0 +1,11 @@
+package sql_models
+
+import "time"
+
+// sql_models.ExchangeRate model
+// @Description Price of one unit of a currency in the base currency, valid from the given month until the next rate
+type ExchangeRate struct {
+	Currency  string    `db:"currency" json:"currency"`
+	ValidFrom time.Time `db:"valid_from" json:"valid_from"`
+	Rate      float64   `db:"rate" json:"rate"`
+}
@@ -0,0 +1,21 @@
+package sql_models
+
+import "time"
+
+// sql_models.IdempotencyRecord model
+// @Description Stored response of a request sent with an Idempotency-Key header
+type IdempotencyRecord struct {
+	Key          string    `db:"key"`
+	RequestHash  string    `db:"request_hash"`
+	StatusCode   int       `db:"status_code"`
+	ContentType  string    `db:"content_type"`
+	ResponseBody []byte    `db:"response_body"`
+	CreatedAt    time.Time `db:"created_at"`
+	ExpiresAt    time.Time `db:"expires_at"`
+}
+
+// Completed reports whether the response has been stored. A zero status code
+// means the first request is still being processed.
+func (record IdempotencyRecord) Completed() bool {
+	return record.StatusCode != 0
+}
@@ -0,0 +1,20 @@
+package sql_models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// sql_models.OutboxEvent model
+// @Description Subscription change waiting to be published
+type OutboxEvent struct {
+	// ID orders the events; relays publish them in ascending order.
+	ID int64 `db:"id" json:"-"`
+	// EventID identifies the event to consumers and is kept across redeliveries.
+	EventID        string          `db:"event_id" json:"id"`
+	EventType      string          `db:"event_type" json:"type"`
+	SubscriptionID string          `db:"subscription_id" json:"subscription_id"`
+	Payload        json.RawMessage `db:"payload" json:"data" swaggertype:"object"`
+	CreatedAt      time.Time       `db:"created_at" json:"created_at"`
+	PublishedAt    *time.Time      `db:"published_at" json:"-"`
+}
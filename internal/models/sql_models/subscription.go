@@ -1,6 +1,28 @@
 package sql_models
 
-import "time"
+import (
+	"math"
+	"taskTestEffectMobile/internal/utils"
+	"time"
+)
+
+// Subscription statuses. Expired is never stored: it is derived from end_date
+// by StatusAt, as is the switch from trial to active once the trial is over.
+const (
+	StatusTrial     = "trial"
+	StatusActive    = "active"
+	StatusPaused    = "paused"
+	StatusCancelled = "cancelled"
+	StatusExpired   = "expired"
+)
+
+// Billing intervals. A subscription is charged Price every BillingIntervalCount intervals.
+const (
+	IntervalWeek    = "week"
+	IntervalMonth   = "month"
+	IntervalQuarter = "quarter"
+	IntervalYear    = "year"
+)
 
 // sql_models.Subscription model
 // @Description Subscription information
@@ -9,7 +31,108 @@ type Subscription struct {
 	ServiceName string     `db:"service_name"`
 	Price       int        `db:"price"`
 	UserID      string     `db:"user_id"`
+	Currency    string     `db:"currency"`
 	StartDate   time.Time  `db:"start_date"`
 	EndDate     *time.Time `db:"end_date"`
 	CreatedAt   time.Time  `db:"created_at"`
+	Version     int        `db:"version"`
+	DeletedAt   *time.Time `db:"deleted_at"`
+	Status      string     `db:"status"`
+	// TrialEndDate is the last free month of a trial.
+	TrialEndDate *time.Time `db:"trial_end_date"`
+
+	BillingInterval      string `db:"billing_interval"`
+	BillingIntervalCount int    `db:"billing_interval_count"`
+	// BillingAnchorDay is the day of month month-based plans renew on.
+	BillingAnchorDay int `db:"billing_anchor_day"`
+	// MonthlyPrice is Price converted to a monthly equivalent. It is not stored.
+	MonthlyPrice int `db:"-"`
+}
+
+// IntervalCount returns BillingIntervalCount, treating unset values as one.
+func (sub Subscription) IntervalCount() int {
+	if sub.BillingIntervalCount < 1 {
+		return 1
+	}
+	return sub.BillingIntervalCount
+}
+
+// AnchorDay returns BillingAnchorDay, defaulting to the day of the start date.
+func (sub Subscription) AnchorDay() int {
+	if sub.BillingAnchorDay < 1 {
+		return sub.StartDate.Day()
+	}
+	return sub.BillingAnchorDay
+}
+
+// AnchorDate returns the renewal date in the month of t. An anchor day past
+// the end of a shorter month falls on its last day.
+func (sub Subscription) AnchorDate(t time.Time) time.Time {
+	month := utils.MonthStart(t)
+	day := sub.AnchorDay()
+	if lastDay := utils.MonthEnd(month).Day(); day > lastDay {
+		day = lastDay
+	}
+	return month.AddDate(0, 0, day-1)
+}
+
+// IntervalMonths returns the length of a billing period in months, or 0 for
+// weekly billing, which does not align with months.
+func (sub Subscription) IntervalMonths() int {
+	switch sub.BillingInterval {
+	case IntervalWeek:
+		return 0
+	case IntervalQuarter:
+		return 3 * sub.IntervalCount()
+	case IntervalYear:
+		return 12 * sub.IntervalCount()
+	default:
+		return sub.IntervalCount()
+	}
+}
+
+// MonthlyEquivalentPrice spreads Price evenly over the months of one billing
+// period, rounded to the nearest unit. A year is taken as 52 weeks.
+func (sub Subscription) MonthlyEquivalentPrice() int {
+	if months := sub.IntervalMonths(); months > 0 {
+		return int(math.Round(float64(sub.Price) / float64(months)))
+	}
+	return int(math.Round(float64(sub.Price) * 52 / 12 / float64(sub.IntervalCount())))
+}
+
+// StatusAt returns the status of the subscription at now. It expires the day
+// after end_date; trials end at month precision.
+func (sub Subscription) StatusAt(now time.Time) string {
+	month := utils.MonthStart(now)
+	if sub.Status == StatusCancelled {
+		return StatusCancelled
+	}
+	if sub.EndDate != nil && sub.EndDate.Before(utils.DayStart(now)) {
+		return StatusExpired
+	}
+	if sub.Status == StatusTrial && sub.TrialEndDate != nil && sub.TrialEndDate.Before(month) {
+		return StatusActive
+	}
+	return sub.Status
+}
+
+// InTrial reports whether month is a free trial month.
+func (sub Subscription) InTrial(month time.Time) bool {
+	return sub.TrialEndDate != nil && !sub.TrialEndDate.Before(month)
+}
+
+// sql_models.SubscriptionPause model
+// @Description Months in which a subscription was paused and not billed
+type SubscriptionPause struct {
+	SubscriptionID string     `db:"subscription_id"`
+	PausedFrom     time.Time  `db:"paused_from"`
+	PausedUntil    *time.Time `db:"paused_until"`
+}
+
+// Covers reports whether month is inside the pause. An open pause has no end.
+func (pause SubscriptionPause) Covers(month time.Time) bool {
+	if month.Before(pause.PausedFrom) {
+		return false
+	}
+	return pause.PausedUntil == nil || !pause.PausedUntil.Before(month)
 }
@@ -0,0 +1,31 @@
+package sql_models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	EventCreated   = "created"
+	EventUpdated   = "updated"
+	EventDeleted   = "deleted"
+	EventRestored  = "restored"
+	EventPurged    = "purged"
+	EventPaused    = "paused"
+	EventResumed   = "resumed"
+	EventCancelled = "cancelled"
+)
+
+// sql_models.SubscriptionEvent model
+// @Description A single change in the subscription history
+// @Description actor is "client:<X-Actor>" as claimed by the unauthenticated client, "system:<job>" for background jobs or "anonymous"
+type SubscriptionEvent struct {
+	ID             int64           `db:"id" json:"id"`
+	SubscriptionID string          `db:"subscription_id" json:"subscription_id"`
+	EventType      string          `db:"event_type" json:"event_type"`
+	Before         json.RawMessage `db:"before_state" json:"before" swaggertype:"object"`
+	After          json.RawMessage `db:"after_state" json:"after" swaggertype:"object"`
+	Actor          string          `db:"actor" json:"actor"`
+	RequestID      string          `db:"request_id" json:"request_id"`
+	CreatedAt      time.Time       `db:"created_at" json:"created_at"`
+}
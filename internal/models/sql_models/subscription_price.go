@@ -0,0 +1,11 @@
+package sql_models
+
+import "time"
+
+// sql_models.SubscriptionPrice model
+// @Description Price of a subscription from the given month until the next price change
+type SubscriptionPrice struct {
+	SubscriptionID string    `db:"subscription_id" json:"subscription_id"`
+	EffectiveFrom  time.Time `db:"effective_from" json:"effective_from"`
+	Price          int       `db:"price" json:"price"`
+}
@@ -0,0 +1,13 @@
+package sql_models
+
+import "time"
+
+// sql_models.UserSettings model
+// @Description Per-user preferences used when computing statuses and charge dates
+type UserSettings struct {
+	UserID string `db:"user_id" json:"user_id"`
+	// TimeZone is an IANA time zone name such as "Europe/Moscow".
+	TimeZone string `db:"time_zone" json:"time_zone"`
+	// UpdatedAt is empty for users that still have the default settings.
+	UpdatedAt *time.Time `db:"updated_at" json:"updated_at,omitempty"`
+}
@@ -0,0 +1,66 @@
+package sql_models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Event types published through the outbox and sent to webhooks.
+const (
+	WebhookSubscriptionCreated  = "subscription.created"
+	WebhookSubscriptionUpdated  = "subscription.updated"
+	WebhookSubscriptionDeleted  = "subscription.deleted"
+	WebhookSubscriptionRenewing = "subscription.renewing"
+)
+
+// Webhook delivery statuses. A pending delivery is retried until it succeeds
+// or runs out of attempts and fails.
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// sql_models.WebhookEndpoint model
+// @Description URL that receives signed subscription events
+type WebhookEndpoint struct {
+	ID  string `db:"id" json:"id"`
+	URL string `db:"url" json:"url"`
+	// Secret signs the deliveries; it is only returned when the endpoint is registered.
+	Secret string `db:"secret" json:"secret,omitempty"`
+	// EventTypes the endpoint receives; every type when empty.
+	EventTypes []string  `db:"event_types" json:"event_types"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+}
+
+// Accepts reports whether the endpoint is subscribed to the event type.
+func (endpoint WebhookEndpoint) Accepts(eventType string) bool {
+	if len(endpoint.EventTypes) == 0 {
+		return true
+	}
+	for _, accepted := range endpoint.EventTypes {
+		if accepted == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// sql_models.WebhookDelivery model
+// @Description One event sent to one endpoint, with the outcome of the last attempt
+type WebhookDelivery struct {
+	ID         string `db:"id" json:"id"`
+	EndpointID string `db:"endpoint_id" json:"endpoint_id"`
+	// EventID identifies the event; an endpoint receives every event once.
+	EventID   string          `db:"event_id" json:"event_id"`
+	EventType string          `db:"event_type" json:"event_type"`
+	Payload   json.RawMessage `db:"payload" json:"payload" swaggertype:"object"`
+	Status    string          `db:"status" json:"status"`
+	Attempts  int             `db:"attempts" json:"attempts"`
+	// NextAttemptAt is when a pending delivery is sent next.
+	NextAttemptAt  *time.Time `db:"next_attempt_at" json:"next_attempt_at,omitempty"`
+	LastStatusCode *int       `db:"last_status_code" json:"last_status_code,omitempty"`
+	LastError      *string    `db:"last_error" json:"last_error,omitempty"`
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+	DeliveredAt    *time.Time `db:"delivered_at" json:"delivered_at,omitempty"`
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"taskTestEffectMobile/internal/models/sql_models"
+	"taskTestEffectMobile/internal/utils"
+	"time"
+)
+
+const hoursPerDay = 24
+
+// Proration policies for charges whose period is only partly covered by the
+// subscription dates.
+const (
+	// ProrationNone charges every period in full. The partial period before
+	// the first renewal of a plan anchored after its start day is not charged.
+	ProrationNone = "none"
+	// ProrationDaily charges the share of days the subscription covers.
+	ProrationDaily = "daily"
+)
+
+// chargePeriod is the service span paid by one charge; end is exclusive.
+// The charge is made on chargedAt, which is later than start only for the
+// first period of a subscription that starts between two renewals.
+type chargePeriod struct {
+	start, end time.Time
+	chargedAt  time.Time
+	// stub marks the partial period from the start date to the first renewal
+	// of a month-based plan whose anchor day comes after the start day.
+	stub bool
+}
+
+// chargesInMonth returns the periods the subscription is charged for in month.
+// Month-based plans renew on their anchor day every period counted from the
+// start month; a start between two renewals is charged on the start date for
+// the period it falls in. When the anchor day comes after the start day that
+// period is a stub followed by the first renewal in the same month. Weekly
+// plans are charged every 7 * count days counted from the start date. Nothing
+// is charged after end_date.
+// The caller is responsible for keeping month within the subscription dates.
+func chargesInMonth(sub sql_models.Subscription, month time.Time) []chargePeriod {
+	month = utils.MonthStart(month)
+	last := month.AddDate(0, 1, 0)
+	if end := subscriptionEnd(sub); end != nil && end.Before(last) {
+		last = *end
+	}
+
+	var periods []chargePeriod
+	add := func(start, end time.Time, stub bool) {
+		chargedAt := start
+		if sub.StartDate.After(chargedAt) {
+			chargedAt = sub.StartDate
+		}
+		if !chargedAt.Before(month) && chargedAt.Before(last) {
+			periods = append(periods, chargePeriod{start: start, end: end, chargedAt: chargedAt, stub: stub})
+		}
+	}
+
+	if periodMonths := sub.IntervalMonths(); periodMonths > 0 {
+		startMonth := utils.MonthStart(sub.StartDate)
+		renewal := func(k int) time.Time {
+			return sub.AnchorDate(startMonth.AddDate(0, k*periodMonths, 0))
+		}
+		offset := utils.MonthsBetween(startMonth, month) - 1
+		if offset == 0 && renewal(0).After(sub.StartDate) {
+			add(renewal(-1), renewal(0), true)
+		}
+		if offset >= 0 && offset%periodMonths == 0 {
+			k := offset / periodMonths
+			add(renewal(k), renewal(k+1), false)
+		}
+		return periods
+	}
+
+	step := 7 * sub.IntervalCount()
+	first := sub.StartDate
+	if first.Before(month) {
+		daysSinceStart := daysBetween(first, month)
+		first = first.AddDate(0, 0, (daysSinceStart+step-1)/step*step)
+	}
+	for charge := first; charge.Before(last); charge = charge.AddDate(0, 0, step) {
+		add(charge, charge.AddDate(0, 0, step), false)
+	}
+	return periods
+}
+
+// chargeShare returns the part of the period price that is charged. Under the
+// daily policy a period only partly inside the subscription dates is charged
+// for the covered days; otherwise every period is charged in full, except a
+// stub, which is free so the start month is not charged twice.
+func chargeShare(sub sql_models.Subscription, period chargePeriod, proration string) float64 {
+	if proration != ProrationDaily {
+		if period.stub {
+			return 0
+		}
+		return 1
+	}
+	from, to := period.start, period.end
+	if sub.StartDate.After(from) {
+		from = sub.StartDate
+	}
+	if end := subscriptionEnd(sub); end != nil && end.Before(to) {
+		to = *end
+	}
+	if !from.Before(to) {
+		return 0
+	}
+	return float64(daysBetween(from, to)) / float64(daysBetween(period.start, period.end))
+}
+
+// subscriptionEnd returns the exclusive end of the subscription, the day after
+// end_date, or nil for an open-ended one.
+func subscriptionEnd(sub sql_models.Subscription) *time.Time {
+	if sub.EndDate == nil {
+		return nil
+	}
+	end := utils.DayStart(*sub.EndDate).AddDate(0, 0, 1)
+	return &end
+}
+
+// daysBetween returns the number of whole days from "from" to "to".
+func daysBetween(from, to time.Time) int {
+	return int(to.Sub(from).Hours() / hoursPerDay)
+}
@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"math"
+	"taskTestEffectMobile/internal/models/sql_models"
+	"testing"
+	"time"
+)
+
+func TestChargesInMonth(t *testing.T) {
+	endDate := date(2025, time.April, 10)
+
+	tests := []struct {
+		name  string
+		sub   sql_models.Subscription
+		month time.Time
+		want  []chargePeriod
+	}{
+		{
+			name:  "monthly plan",
+			sub:   sql_models.Subscription{StartDate: date(2025, time.March, 1)},
+			month: date(2025, time.April, 1),
+			want:  []chargePeriod{{start: date(2025, time.April, 1), end: date(2025, time.May, 1), chargedAt: date(2025, time.April, 1)}},
+		},
+		{
+			name:  "anchor after the start day adds a stub",
+			sub:   sql_models.Subscription{StartDate: date(2025, time.March, 10), BillingAnchorDay: 15},
+			month: date(2025, time.March, 1),
+			want: []chargePeriod{
+				{start: date(2025, time.February, 15), end: date(2025, time.March, 15), chargedAt: date(2025, time.March, 10), stub: true},
+				{start: date(2025, time.March, 15), end: date(2025, time.April, 15), chargedAt: date(2025, time.March, 15)},
+			},
+		},
+		{
+			name:  "month after a stub has a single renewal",
+			sub:   sql_models.Subscription{StartDate: date(2025, time.March, 10), BillingAnchorDay: 15},
+			month: date(2025, time.April, 1),
+			want: []chargePeriod{
+				{start: date(2025, time.April, 15), end: date(2025, time.May, 15), chargedAt: date(2025, time.April, 15)},
+			},
+		},
+		{
+			name:  "anchor before the start day charges on the start date",
+			sub:   sql_models.Subscription{StartDate: date(2025, time.March, 20), BillingAnchorDay: 15},
+			month: date(2025, time.March, 1),
+			want: []chargePeriod{
+				{start: date(2025, time.March, 15), end: date(2025, time.April, 15), chargedAt: date(2025, time.March, 20)},
+			},
+		},
+		{
+			name:  "anchor past the end of a short month",
+			sub:   sql_models.Subscription{StartDate: date(2025, time.January, 31)},
+			month: date(2025, time.February, 1),
+			want: []chargePeriod{
+				{start: date(2025, time.February, 28), end: date(2025, time.March, 31), chargedAt: date(2025, time.February, 28)},
+			},
+		},
+		{
+			name:  "every second month",
+			sub:   sql_models.Subscription{StartDate: date(2025, time.March, 1), BillingIntervalCount: 2},
+			month: date(2025, time.April, 1),
+		},
+		{
+			name:  "quarterly plan is not charged between renewals",
+			sub:   sql_models.Subscription{StartDate: date(2025, time.January, 1), BillingInterval: sql_models.IntervalQuarter},
+			month: date(2025, time.February, 1),
+		},
+		{
+			name:  "quarterly plan renews every third month",
+			sub:   sql_models.Subscription{StartDate: date(2025, time.January, 1), BillingInterval: sql_models.IntervalQuarter},
+			month: date(2025, time.April, 1),
+			want:  []chargePeriod{{start: date(2025, time.April, 1), end: date(2025, time.July, 1), chargedAt: date(2025, time.April, 1)}},
+		},
+		{
+			name:  "yearly plan renews in its start month",
+			sub:   sql_models.Subscription{StartDate: date(2024, time.March, 1), BillingInterval: sql_models.IntervalYear},
+			month: date(2025, time.March, 1),
+			want:  []chargePeriod{{start: date(2025, time.March, 1), end: date(2026, time.March, 1), chargedAt: date(2025, time.March, 1)}},
+		},
+		{
+			name:  "weekly plan is charged every seven days",
+			sub:   sql_models.Subscription{StartDate: date(2025, time.March, 24), BillingInterval: sql_models.IntervalWeek},
+			month: date(2025, time.April, 1),
+			want: []chargePeriod{
+				{start: date(2025, time.April, 7), end: date(2025, time.April, 14), chargedAt: date(2025, time.April, 7)},
+				{start: date(2025, time.April, 14), end: date(2025, time.April, 21), chargedAt: date(2025, time.April, 14)},
+				{start: date(2025, time.April, 21), end: date(2025, time.April, 28), chargedAt: date(2025, time.April, 21)},
+				{start: date(2025, time.April, 28), end: date(2025, time.May, 5), chargedAt: date(2025, time.April, 28)},
+			},
+		},
+		{
+			name:  "every second week",
+			sub:   sql_models.Subscription{StartDate: date(2025, time.March, 24), BillingInterval: sql_models.IntervalWeek, BillingIntervalCount: 2},
+			month: date(2025, time.April, 1),
+			want: []chargePeriod{
+				{start: date(2025, time.April, 7), end: date(2025, time.April, 21), chargedAt: date(2025, time.April, 7)},
+				{start: date(2025, time.April, 21), end: date(2025, time.May, 5), chargedAt: date(2025, time.April, 21)},
+			},
+		},
+		{
+			name:  "weekly plan stops at the end date",
+			sub:   sql_models.Subscription{StartDate: date(2025, time.March, 24), EndDate: &endDate, BillingInterval: sql_models.IntervalWeek},
+			month: date(2025, time.April, 1),
+			want: []chargePeriod{
+				{start: date(2025, time.April, 7), end: date(2025, time.April, 14), chargedAt: date(2025, time.April, 7)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := chargesInMonth(tt.sub, tt.month)
+			if len(got) != len(tt.want) {
+				t.Fatalf("chargesInMonth() = %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if !got[i].start.Equal(tt.want[i].start) || !got[i].end.Equal(tt.want[i].end) || !got[i].chargedAt.Equal(tt.want[i].chargedAt) ||
+					got[i].stub != tt.want[i].stub {
+					t.Errorf("period %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestChargeShare(t *testing.T) {
+	endDate := date(2025, time.March, 15)
+	beforePeriod := date(2025, time.February, 20)
+	march := chargePeriod{start: date(2025, time.March, 1), end: date(2025, time.April, 1), chargedAt: date(2025, time.March, 1)}
+	stub := chargePeriod{start: date(2025, time.February, 15), end: date(2025, time.March, 15), chargedAt: date(2025, time.March, 10), stub: true}
+
+	tests := []struct {
+		name      string
+		sub       sql_models.Subscription
+		period    chargePeriod
+		proration string
+		want      float64
+	}{
+		{
+			name:      "full period without proration",
+			sub:       sql_models.Subscription{StartDate: date(2025, time.January, 1), EndDate: &endDate},
+			period:    march,
+			proration: ProrationNone,
+			want:      1,
+		},
+		{
+			name:      "stub is free without proration",
+			sub:       sql_models.Subscription{StartDate: date(2025, time.March, 10), BillingAnchorDay: 15},
+			period:    stub,
+			proration: ProrationNone,
+			want:      0,
+		},
+		{
+			name:      "stub is charged for the covered days",
+			sub:       sql_models.Subscription{StartDate: date(2025, time.March, 10), BillingAnchorDay: 15},
+			period:    stub,
+			proration: ProrationDaily,
+			want:      5.0 / 28,
+		},
+		{
+			name:      "covered period is charged in full",
+			sub:       sql_models.Subscription{StartDate: date(2025, time.January, 1)},
+			period:    march,
+			proration: ProrationDaily,
+			want:      1,
+		},
+		{
+			name:      "start date inside the period",
+			sub:       sql_models.Subscription{StartDate: date(2025, time.March, 11)},
+			period:    march,
+			proration: ProrationDaily,
+			want:      21.0 / 31,
+		},
+		{
+			name:      "end date inside the period counts its last day",
+			sub:       sql_models.Subscription{StartDate: date(2025, time.January, 1), EndDate: &endDate},
+			period:    march,
+			proration: ProrationDaily,
+			want:      15.0 / 31,
+		},
+		{
+			name:      "end date before the period",
+			sub:       sql_models.Subscription{StartDate: date(2025, time.January, 1), EndDate: &beforePeriod},
+			period:    march,
+			proration: ProrationDaily,
+			want:      0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := chargeShare(tt.sub, tt.period, tt.proration); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("chargeShare() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
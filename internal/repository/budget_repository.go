@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"go.uber.org/zap"
+	"taskTestEffectMobile/internal/models/domain_errors"
+	"taskTestEffectMobile/internal/models/sql_models"
+)
+
+const budgetColumns = `id, user_id, service_name, amount, currency, created_at, updated_at`
+
+const budgetAlertColumns = `id, budget_id, user_id, service_name, month, threshold, spent, amount, currency, created_at`
+
+type BudgetRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewBudgetRepository(db *sql.DB, logger *zap.Logger) *BudgetRepository {
+	return &BudgetRepository{
+		db:     db,
+		logger: logger.With(zap.String("layer", "repository")),
+	}
+}
+
+// UpsertBudget creates the budget or replaces the amount and currency of the
+// budget the user already has for the same service.
+func (budgetRepository BudgetRepository) UpsertBudget(ctx context.Context, budget sql_models.Budget) (sql_models.Budget, error) {
+	budgetRepository.logger.Debug("Upserting budget",
+		zap.String("userID", budget.UserID),
+		zap.Any("service", budget.ServiceName))
+
+	query := `
+		INSERT INTO budgets (user_id, service_name, amount, currency)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, COALESCE(LOWER(service_name), '')) DO UPDATE
+		SET amount = EXCLUDED.amount, currency = EXCLUDED.currency, updated_at = NOW()
+		RETURNING ` + budgetColumns
+
+	row := budgetRepository.db.QueryRowContext(ctx, query, budget.UserID, budget.ServiceName, budget.Amount, budget.Currency)
+	stored, err := scanBudget(row)
+	if err != nil {
+		budgetRepository.logger.Error("Failed to upsert budget",
+			zap.String("query", query),
+			zap.String("userID", budget.UserID),
+			zap.Error(err))
+		return sql_models.Budget{}, fmt.Errorf("failed to upsert budget: %w", mapDatabaseError(err))
+	}
+	return stored, nil
+}
+
+func (budgetRepository BudgetRepository) ListBudgets(ctx context.Context, userID string) ([]sql_models.Budget, error) {
+	query := `SELECT ` + budgetColumns + ` FROM budgets WHERE user_id = $1 ORDER BY service_name NULLS FIRST, created_at`
+
+	rows, err := budgetRepository.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		budgetRepository.logger.Error("Failed to query budgets",
+			zap.String("query", query),
+			zap.String("userID", userID),
+			zap.Error(err))
+		return nil, fmt.Errorf("database query failed: %w", err)
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			budgetRepository.logger.Error("Failed to close rows",
+				zap.Error(closeErr))
+		}
+	}()
+
+	var budgets []sql_models.Budget
+	for rows.Next() {
+		budget, err := scanBudget(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error with scanning: %w", err)
+		}
+		budgets = append(budgets, budget)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iteration error: %w", err)
+	}
+	return budgets, nil
+}
+
+// ListBudgetUserIDs returns the users that have at least one budget.
+func (budgetRepository BudgetRepository) ListBudgetUserIDs(ctx context.Context) ([]string, error) {
+	query := `SELECT DISTINCT user_id FROM budgets ORDER BY user_id`
+
+	var userIDs []string
+	err := queryRows(ctx, budgetRepository.db, budgetRepository.logger, query, func(rows *sql.Rows) error {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return err
+		}
+		userIDs = append(userIDs, userID)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return userIDs, nil
+}
+
+// DeleteBudget removes a budget of the user together with its alerts.
+func (budgetRepository BudgetRepository) DeleteBudget(ctx context.Context, userID, budgetID string) error {
+	budgetRepository.logger.Debug("Deleting budget",
+		zap.String("userID", userID),
+		zap.String("budgetID", budgetID))
+
+	query := `DELETE FROM budgets WHERE id = $1 AND user_id = $2`
+	result, err := budgetRepository.db.ExecContext(ctx, query, budgetID, userID)
+	if err != nil {
+		budgetRepository.logger.Error("Failed to delete budget",
+			zap.String("query", query),
+			zap.String("budgetID", budgetID),
+			zap.Error(err))
+		return fmt.Errorf("failed to delete budget: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return domain_errors.ErrBudgetNotFound
+	}
+	return nil
+}
+
+// RecordBudgetAlert stores the alert unless the threshold of the budget was
+// already alerted in that month. It reports whether the alert is new.
+func (budgetRepository BudgetRepository) RecordBudgetAlert(ctx context.Context, alert sql_models.BudgetAlert) (bool, error) {
+	query := `
+		INSERT INTO budget_alerts (budget_id, user_id, service_name, month, threshold, spent, amount, currency)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (budget_id, month, threshold) DO NOTHING
+	`
+	result, err := budgetRepository.db.ExecContext(ctx, query,
+		alert.BudgetID, alert.UserID, alert.ServiceName, alert.Month, alert.Threshold, alert.Spent, alert.Amount, alert.Currency)
+	if err != nil {
+		budgetRepository.logger.Error("Failed to record budget alert",
+			zap.String("query", query),
+			zap.String("budgetID", alert.BudgetID),
+			zap.Int("threshold", alert.Threshold),
+			zap.Error(err))
+		return false, fmt.Errorf("failed to record budget alert: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to record budget alert: %w", err)
+	}
+	return rows > 0, nil
+}
+
+// ListBudgetAlerts returns the alerts of the user, newest first.
+func (budgetRepository BudgetRepository) ListBudgetAlerts(ctx context.Context, userID string) ([]sql_models.BudgetAlert, error) {
+	query := `SELECT ` + budgetAlertColumns + ` FROM budget_alerts WHERE user_id = $1 ORDER BY created_at DESC, threshold DESC`
+
+	rows, err := budgetRepository.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		budgetRepository.logger.Error("Failed to query budget alerts",
+			zap.String("query", query),
+			zap.String("userID", userID),
+			zap.Error(err))
+		return nil, fmt.Errorf("database query failed: %w", err)
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			budgetRepository.logger.Error("Failed to close rows",
+				zap.Error(closeErr))
+		}
+	}()
+
+	var alerts []sql_models.BudgetAlert
+	for rows.Next() {
+		var alert sql_models.BudgetAlert
+		var serviceName sql.NullString
+		err := rows.Scan(&alert.ID, &alert.BudgetID, &alert.UserID, &serviceName, &alert.Month,
+			&alert.Threshold, &alert.Spent, &alert.Amount, &alert.Currency, &alert.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error with scanning: %w", err)
+		}
+		if serviceName.Valid {
+			alert.ServiceName = &serviceName.String
+		}
+		alerts = append(alerts, alert)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iteration error: %w", err)
+	}
+	return alerts, nil
+}
+
+func scanBudget(row rowScanner) (sql_models.Budget, error) {
+	var budget sql_models.Budget
+	var serviceName sql.NullString
+	if err := row.Scan(&budget.ID, &budget.UserID, &serviceName, &budget.Amount, &budget.Currency, &budget.CreatedAt, &budget.UpdatedAt); err != nil {
+		return sql_models.Budget{}, err
+	}
+	if serviceName.Valid {
+		budget.ServiceName = &serviceName.String
+	}
+	return budget, nil
+}
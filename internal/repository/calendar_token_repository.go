@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"taskTestEffectMobile/internal/models/domain_errors"
+	"taskTestEffectMobile/internal/models/sql_models"
+)
+
+type CalendarTokenRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewCalendarTokenRepository(db *sql.DB, logger *zap.Logger) *CalendarTokenRepository {
+	return &CalendarTokenRepository{
+		db:     db,
+		logger: logger.With(zap.String("layer", "repository")),
+	}
+}
+
+// SaveCalendarToken stores the token of the user, replacing the previous one.
+func (calendarTokenRepository CalendarTokenRepository) SaveCalendarToken(ctx context.Context, token sql_models.CalendarToken) error {
+	calendarTokenRepository.logger.Debug("Saving calendar token",
+		zap.String("userID", token.UserID))
+
+	query := `
+		INSERT INTO calendar_tokens (user_id, token_hash, reminder_days)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE
+		SET token_hash = EXCLUDED.token_hash, reminder_days = EXCLUDED.reminder_days, created_at = NOW()
+	`
+	if _, err := calendarTokenRepository.db.ExecContext(ctx, query, token.UserID, token.TokenHash, token.ReminderDays); err != nil {
+		calendarTokenRepository.logger.Error("Failed to save calendar token",
+			zap.String("query", query),
+			zap.String("userID", token.UserID),
+			zap.Error(err))
+		return fmt.Errorf("failed to save calendar token: %w", err)
+	}
+	return nil
+}
+
+func (calendarTokenRepository CalendarTokenRepository) GetCalendarToken(ctx context.Context, tokenHash string) (sql_models.CalendarToken, error) {
+	query := `SELECT user_id, token_hash, reminder_days, created_at FROM calendar_tokens WHERE token_hash = $1`
+
+	var token sql_models.CalendarToken
+	var reminderDays sql.NullInt64
+	err := calendarTokenRepository.db.QueryRowContext(ctx, query, tokenHash).Scan(&token.UserID, &token.TokenHash, &reminderDays, &token.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return sql_models.CalendarToken{}, domain_errors.ErrCalendarFeedNotFound
+	}
+	if err != nil {
+		calendarTokenRepository.logger.Error("Failed to get calendar token",
+			zap.String("query", query),
+			zap.Error(err))
+		return sql_models.CalendarToken{}, fmt.Errorf("database query failed: %w", err)
+	}
+	if reminderDays.Valid {
+		days := int(reminderDays.Int64)
+		token.ReminderDays = &days
+	}
+	return token, nil
+}
+
+// DeleteCalendarToken revokes the feed of the user.
+func (calendarTokenRepository CalendarTokenRepository) DeleteCalendarToken(ctx context.Context, userID string) error {
+	calendarTokenRepository.logger.Debug("Deleting calendar token",
+		zap.String("userID", userID))
+
+	query := `DELETE FROM calendar_tokens WHERE user_id = $1`
+	result, err := calendarTokenRepository.db.ExecContext(ctx, query, userID)
+	if err != nil {
+		calendarTokenRepository.logger.Error("Failed to delete calendar token",
+			zap.String("query", query),
+			zap.String("userID", userID),
+			zap.Error(err))
+		return fmt.Errorf("failed to delete calendar token: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return domain_errors.ErrCalendarFeedNotFound
+	}
+	return nil
+}
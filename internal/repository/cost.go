@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"sort"
+	"taskTestEffectMobile/internal/models/json_models"
+	"taskTestEffectMobile/internal/models/sql_models"
+	"taskTestEffectMobile/internal/utils"
+	"time"
+)
+
+// billedMonths returns the first month a subscription is billed inside the
+// [periodStart, periodEnd] window and the number of billed months.
+// Both bounds are inclusive and compared at month precision.
+func billedMonths(sub sql_models.Subscription, periodStart, periodEnd time.Time) (time.Time, int) {
+	from := utils.MonthStart(sub.StartDate)
+	if periodStart.After(from) {
+		from = utils.MonthStart(periodStart)
+	}
+
+	to := utils.MonthStart(periodEnd)
+	if sub.EndDate != nil && sub.EndDate.Before(to) {
+		to = utils.MonthStart(*sub.EndDate)
+	}
+
+	return from, utils.MonthsBetween(from, to)
+}
+
+// calculateCostReport sums the charges of each subscription made inside the
+// window, whose days are both inclusive, and groups the result by month,
+// service and user. A yearly plan
+// is counted in full in the month it renews, a weekly one once per week.
+// Each charge uses the price in effect in its month and is prorated according
+// to the proration policy. Trial months and months covered by pauses are
+// skipped. Every charge is converted into the report currency at the rate
+// valid in its month.
+func calculateCostReport(
+	subscriptions []sql_models.Subscription,
+	history subscriptionHistory,
+	periodStart, periodEnd time.Time,
+	proration string,
+	rates *ExchangeRates,
+) (json_models.CostReport, error) {
+	firstDay, lastDay := utils.DayStart(periodStart), utils.DayStart(periodEnd)
+	periodStart = utils.MonthStart(periodStart)
+	periodEnd = utils.MonthStart(periodEnd)
+
+	monthTotals := make([]float64, utils.MonthsBetween(periodStart, periodEnd))
+	serviceTotals := make(map[string]float64)
+	userTotals := make(map[string]float64)
+
+	report := json_models.CostReport{Currency: rates.Target()}
+	for _, sub := range subscriptions {
+		from, months := billedMonths(sub, periodStart, periodEnd)
+		if months == 0 {
+			continue
+		}
+
+		offset := utils.MonthsBetween(periodStart, from) - 1
+		cost := 0.0
+		for i := 0; i < months; i++ {
+			month := from.AddDate(0, i, 0)
+			if !billable(sub, history.pauses[sub.ID], month) {
+				continue
+			}
+			price := float64(priceAt(sub, history.prices[sub.ID], month))
+			amount := 0.0
+			for _, period := range chargesInMonth(sub, month) {
+				if period.chargedAt.Before(firstDay) || period.chargedAt.After(lastDay) {
+					continue
+				}
+				amount += price * chargeShare(sub, period, proration)
+			}
+			if amount == 0 {
+				continue
+			}
+			charge, err := rates.Convert(amount, sub.Currency, month)
+			if err != nil {
+				return json_models.CostReport{}, err
+			}
+			monthTotals[offset+i] += charge
+			cost += charge
+		}
+		if cost == 0 {
+			continue
+		}
+
+		report.TotalCost += cost
+		serviceTotals[sub.ServiceName] += cost
+		userTotals[sub.UserID] += cost
+	}
+
+	report.Months = make([]json_models.MonthCost, 0, len(monthTotals))
+	for i, total := range monthTotals {
+		report.Months = append(report.Months, json_models.MonthCost{
+			Month:     periodStart.AddDate(0, i, 0).Format(utils.MonthLayout),
+			TotalCost: roundMoney(total),
+		})
+	}
+
+	report.Services = make([]json_models.ServiceCost, 0, len(serviceTotals))
+	for serviceName, total := range serviceTotals {
+		report.Services = append(report.Services, json_models.ServiceCost{ServiceName: serviceName, TotalCost: roundMoney(total)})
+	}
+	sort.Slice(report.Services, func(i, j int) bool {
+		return report.Services[i].ServiceName < report.Services[j].ServiceName
+	})
+
+	report.Users = make([]json_models.UserCost, 0, len(userTotals))
+	for userID, total := range userTotals {
+		report.Users = append(report.Users, json_models.UserCost{UserID: userID, TotalCost: roundMoney(total)})
+	}
+	sort.Slice(report.Users, func(i, j int) bool {
+		return report.Users[i].UserID < report.Users[j].UserID
+	})
+
+	report.TotalCost = roundMoney(report.TotalCost)
+	report.RatesUsed = rates.RatesUsed()
+	return report, nil
+}
@@ -0,0 +1,230 @@
+package repository
+
+import (
+	"errors"
+	"taskTestEffectMobile/internal/models/domain_errors"
+	"taskTestEffectMobile/internal/models/sql_models"
+	"testing"
+	"time"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestCalculateCostReport(t *testing.T) {
+	endDate := date(2025, time.February, 10)
+	lateEnd := date(2026, time.December, 1)
+	trialEnd := date(2025, time.January, 1)
+	pausedUntil := date(2025, time.February, 1)
+	prorationEnd := date(2025, time.February, 14)
+
+	tests := []struct {
+		name      string
+		sub       sql_models.Subscription
+		pauses    []sql_models.SubscriptionPause
+		prices    []sql_models.SubscriptionPrice
+		proration string
+		want      []float64
+	}{
+		{
+			name: "every month of the window",
+			sub:  sql_models.Subscription{Price: 300, StartDate: date(2025, time.January, 1)},
+			want: []float64{300, 300, 300},
+		},
+		{
+			name: "started before the window",
+			sub:  sql_models.Subscription{Price: 300, StartDate: date(2024, time.June, 1)},
+			want: []float64{300, 300, 300},
+		},
+		{
+			name: "runs past the window",
+			sub:  sql_models.Subscription{Price: 300, StartDate: date(2025, time.February, 1), EndDate: &lateEnd},
+			want: []float64{0, 300, 300},
+		},
+		{
+			name: "ends inside the window",
+			sub:  sql_models.Subscription{Price: 300, StartDate: date(2025, time.January, 1), EndDate: &endDate},
+			want: []float64{300, 300, 0},
+		},
+		{
+			name: "starts after the window",
+			sub:  sql_models.Subscription{Price: 300, StartDate: date(2025, time.April, 1)},
+			want: []float64{0, 0, 0},
+		},
+		{
+			name: "quarterly plan",
+			sub:  sql_models.Subscription{Price: 900, StartDate: date(2024, time.December, 1), BillingInterval: sql_models.IntervalQuarter},
+			want: []float64{0, 0, 900},
+		},
+		{
+			name: "weekly plan",
+			sub:  sql_models.Subscription{Price: 100, StartDate: date(2025, time.January, 6), BillingInterval: sql_models.IntervalWeek},
+			want: []float64{400, 400, 500},
+		},
+		{
+			name: "trial months are free",
+			sub:  sql_models.Subscription{Price: 300, StartDate: date(2025, time.January, 1), TrialEndDate: &trialEnd},
+			want: []float64{0, 300, 300},
+		},
+		{
+			name:   "paused months are free",
+			sub:    sql_models.Subscription{Price: 300, StartDate: date(2025, time.January, 1)},
+			pauses: []sql_models.SubscriptionPause{{PausedFrom: date(2025, time.February, 1), PausedUntil: &pausedUntil}},
+			want:   []float64{300, 0, 300},
+		},
+		{
+			name:   "open pause",
+			sub:    sql_models.Subscription{Price: 300, StartDate: date(2025, time.January, 1)},
+			pauses: []sql_models.SubscriptionPause{{PausedFrom: date(2025, time.February, 1)}},
+			want:   []float64{300, 0, 0},
+		},
+		{
+			name: "price history",
+			sub:  sql_models.Subscription{Price: 300, StartDate: date(2025, time.January, 1)},
+			prices: []sql_models.SubscriptionPrice{
+				{EffectiveFrom: date(2025, time.January, 1), Price: 100},
+				{EffectiveFrom: date(2025, time.March, 1), Price: 200},
+			},
+			want: []float64{100, 100, 200},
+		},
+		{
+			name:      "daily proration of a partly covered month",
+			sub:       sql_models.Subscription{Price: 280, StartDate: date(2025, time.January, 1), EndDate: &prorationEnd},
+			proration: ProrationDaily,
+			want:      []float64{280, 140, 0},
+		},
+		{
+			name: "yearly plan is charged in its renewal month",
+			sub:  sql_models.Subscription{Price: 1200, StartDate: date(2024, time.January, 1), BillingInterval: sql_models.IntervalYear},
+			want: []float64{1200, 0, 0},
+		},
+		{
+			name: "stub is not charged twice",
+			sub:  sql_models.Subscription{Price: 310, StartDate: date(2025, time.February, 10), BillingAnchorDay: 15},
+			want: []float64{0, 310, 310},
+		},
+		{
+			name:      "stub is prorated daily",
+			sub:       sql_models.Subscription{Price: 310, StartDate: date(2025, time.February, 10), BillingAnchorDay: 15},
+			proration: ProrationDaily,
+			want:      []float64{0, 50 + 310, 310},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			history := newSubscriptionHistory()
+			history.pauses[tt.sub.ID] = tt.pauses
+			history.prices[tt.sub.ID] = tt.prices
+			proration := tt.proration
+			if proration == "" {
+				proration = ProrationNone
+			}
+			report, err := calculateCostReport([]sql_models.Subscription{tt.sub}, history,
+				date(2025, time.January, 1), date(2025, time.March, 31), proration, NewExchangeRates("RUB", "RUB", nil))
+			if err != nil {
+				t.Fatalf("calculateCostReport() error = %v", err)
+			}
+
+			if len(report.Months) != len(tt.want) {
+				t.Fatalf("got %d months, want %d", len(report.Months), len(tt.want))
+			}
+			total := 0.0
+			for i, month := range report.Months {
+				if month.TotalCost != tt.want[i] {
+					t.Errorf("month %s = %v, want %v", month.Month, month.TotalCost, tt.want[i])
+				}
+				total += tt.want[i]
+			}
+			if report.TotalCost != total {
+				t.Errorf("total = %v, want %v", report.TotalCost, total)
+			}
+		})
+	}
+}
+
+func TestCalculateCostReportGroups(t *testing.T) {
+	subscriptions := []sql_models.Subscription{
+		{ID: "1", ServiceName: "Netflix", UserID: "a", Price: 900, StartDate: date(2025, time.January, 1)},
+		{ID: "2", ServiceName: "Yandex Plus", UserID: "a", Price: 300, StartDate: date(2025, time.February, 1)},
+		{ID: "3", ServiceName: "Yandex Plus", UserID: "b", Price: 400, StartDate: date(2025, time.January, 1)},
+	}
+
+	report, err := calculateCostReport(subscriptions, newSubscriptionHistory(), date(2025, time.January, 1), date(2025, time.February, 28), ProrationNone, NewExchangeRates("RUB", "RUB", nil))
+	if err != nil {
+		t.Fatalf("calculateCostReport() error = %v", err)
+	}
+
+	if report.TotalCost != 2900 {
+		t.Errorf("total = %v, want 2900", report.TotalCost)
+	}
+	if len(report.Months) != 2 || report.Months[0].Month != "01-2025" || report.Months[0].TotalCost != 1300 || report.Months[1].TotalCost != 1600 {
+		t.Errorf("months = %+v", report.Months)
+	}
+	if len(report.Services) != 2 || report.Services[0].ServiceName != "Netflix" || report.Services[0].TotalCost != 1800 || report.Services[1].TotalCost != 1100 {
+		t.Errorf("services = %+v", report.Services)
+	}
+	if len(report.Users) != 2 || report.Users[0].UserID != "a" || report.Users[0].TotalCost != 2100 || report.Users[1].TotalCost != 800 {
+		t.Errorf("users = %+v", report.Users)
+	}
+}
+
+func TestCalculateCostReportDayBounds(t *testing.T) {
+	subscriptions := []sql_models.Subscription{
+		{ID: "1", ServiceName: "Netflix", UserID: "a", Price: 300, StartDate: date(2024, time.December, 15)},
+	}
+
+	report, err := calculateCostReport(subscriptions, newSubscriptionHistory(), date(2025, time.January, 20), date(2025, time.March, 14), ProrationNone, NewExchangeRates("RUB", "RUB", nil))
+	if err != nil {
+		t.Fatalf("calculateCostReport() error = %v", err)
+	}
+
+	if len(report.Months) != 3 || report.Months[0].TotalCost != 0 || report.Months[1].TotalCost != 300 || report.Months[2].TotalCost != 0 {
+		t.Errorf("months = %+v", report.Months)
+	}
+	if report.TotalCost != 300 {
+		t.Errorf("total = %v, want 300", report.TotalCost)
+	}
+}
+
+func TestCalculateCostReportConverts(t *testing.T) {
+	subscriptions := []sql_models.Subscription{
+		{ID: "1", ServiceName: "Netflix", UserID: "a", Price: 10, Currency: "USD", StartDate: date(2025, time.January, 1)},
+		{ID: "2", ServiceName: "Yandex Plus", UserID: "a", Price: 300, Currency: "RUB", StartDate: date(2025, time.January, 1)},
+	}
+	rates := NewExchangeRates("RUB", "EUR", []sql_models.ExchangeRate{
+		{Currency: "USD", ValidFrom: date(2025, time.January, 1), Rate: 90},
+		{Currency: "USD", ValidFrom: date(2025, time.February, 1), Rate: 100},
+		{Currency: "EUR", ValidFrom: date(2024, time.January, 1), Rate: 100},
+	})
+
+	report, err := calculateCostReport(subscriptions, newSubscriptionHistory(), date(2025, time.January, 1), date(2025, time.February, 28), ProrationNone, rates)
+	if err != nil {
+		t.Fatalf("calculateCostReport() error = %v", err)
+	}
+
+	if report.Currency != "EUR" {
+		t.Errorf("currency = %s, want EUR", report.Currency)
+	}
+	if len(report.Months) != 2 || report.Months[0].TotalCost != 12 || report.Months[1].TotalCost != 13 {
+		t.Errorf("months = %+v", report.Months)
+	}
+	if report.TotalCost != 25 {
+		t.Errorf("total = %v, want 25", report.TotalCost)
+	}
+	if len(report.RatesUsed) != 4 {
+		t.Errorf("rates used = %+v", report.RatesUsed)
+	}
+}
+
+func TestCalculateCostReportMissingRate(t *testing.T) {
+	subscriptions := []sql_models.Subscription{
+		{ID: "1", ServiceName: "Netflix", UserID: "a", Price: 10, Currency: "USD", StartDate: date(2025, time.January, 1)},
+	}
+
+	_, err := calculateCostReport(subscriptions, newSubscriptionHistory(), date(2025, time.January, 1), date(2025, time.January, 1), ProrationNone, NewExchangeRates("RUB", "RUB", nil))
+	if !errors.Is(err, domain_errors.ErrMissingExchangeRate) {
+		t.Errorf("error = %v, want %v", err, domain_errors.ErrMissingExchangeRate)
+	}
+}
@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"taskTestEffectMobile/internal/models/domain_errors"
+)
+
+// endAfterStartConstraint is the CHECK that keeps end_date on or after start_date.
+const endAfterStartConstraint = "subscriptions_end_after_start"
+
+// mapDatabaseError translates Postgres constraint violations into domain errors.
+func mapDatabaseError(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+
+	if pqErr.Constraint == endAfterStartConstraint {
+		return fmt.Errorf("%w: %s", domain_errors.ErrInvalidDateRange, pqErr.Message)
+	}
+
+	switch pqErr.Code.Name() {
+	case "unique_violation", "exclusion_violation":
+		return fmt.Errorf("%w: %s", domain_errors.ErrConflict, pqErr.Message)
+	case "check_violation", "not_null_violation":
+		return fmt.Errorf("%w: %s", domain_errors.ErrValidation, pqErr.Message)
+	}
+	return err
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"taskTestEffectMobile/internal/models/sql_models"
+	"taskTestEffectMobile/internal/utils"
+	"time"
+)
+
+// newSubscriptionEvent builds a history entry attributed to the actor and
+// request stored in ctx. A nil snapshot is recorded as JSON null.
+func newSubscriptionEvent(ctx context.Context, eventType, subscriptionID string, before, after *sql_models.Subscription) (sql_models.SubscriptionEvent, error) {
+	event := sql_models.SubscriptionEvent{
+		SubscriptionID: subscriptionID,
+		EventType:      eventType,
+		Actor:          utils.ActorFromContext(ctx),
+		RequestID:      utils.RequestIDFromContext(ctx),
+		CreatedAt:      time.Now(),
+	}
+
+	var err error
+	if event.Before, err = marshalSnapshot(before); err != nil {
+		return sql_models.SubscriptionEvent{}, err
+	}
+	if event.After, err = marshalSnapshot(after); err != nil {
+		return sql_models.SubscriptionEvent{}, err
+	}
+	return event, nil
+}
+
+func marshalSnapshot(sub *sql_models.Subscription) (json.RawMessage, error) {
+	if sub == nil {
+		return nil, nil
+	}
+	raw, err := json.Marshal(sub)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal subscription snapshot: %w", err)
+	}
+	return raw, nil
+}
+
+// newOutboxEvent builds the event published for a history entry. Purges are
+// not published.
+func newOutboxEvent(event sql_models.SubscriptionEvent) (sql_models.OutboxEvent, bool) {
+	var eventType string
+	switch event.EventType {
+	case sql_models.EventPurged:
+		return sql_models.OutboxEvent{}, false
+	case sql_models.EventCreated:
+		eventType = sql_models.WebhookSubscriptionCreated
+	case sql_models.EventDeleted:
+		eventType = sql_models.WebhookSubscriptionDeleted
+	default:
+		eventType = sql_models.WebhookSubscriptionUpdated
+	}
+
+	return sql_models.OutboxEvent{
+		EventID:        uuid.NewString(),
+		EventType:      eventType,
+		SubscriptionID: event.SubscriptionID,
+		Payload:        event.After,
+		CreatedAt:      event.CreatedAt,
+	}, true
+}
+
+func insertSubscriptionEvent(ctx context.Context, tx *sql.Tx, eventType, subscriptionID string, before, after *sql_models.Subscription) error {
+	event, err := newSubscriptionEvent(ctx, eventType, subscriptionID, before, after)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO subscription_events (subscription_id, event_type, before_state, after_state, actor, request_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	_, err = tx.ExecContext(ctx, query,
+		event.SubscriptionID,
+		event.EventType,
+		nullableJSON(event.Before),
+		nullableJSON(event.After),
+		event.Actor,
+		event.RequestID,
+		event.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record subscription event: %w", err)
+	}
+
+	// The outbox row commits or rolls back together with the change.
+	outboxEvent, ok := newOutboxEvent(event)
+	if !ok {
+		return nil
+	}
+	// The shared lock keeps the relay out until the row commits, so events
+	// are published in id order.
+	query = outboxSharedLockQuery
+	if _, err := tx.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to lock outbox: %w", err)
+	}
+	query = `
+		INSERT INTO outbox_events (event_id, event_type, subscription_id, payload, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	_, err = tx.ExecContext(ctx, query,
+		outboxEvent.EventID,
+		outboxEvent.EventType,
+		outboxEvent.SubscriptionID,
+		string(outboxEvent.Payload),
+		outboxEvent.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to write outbox event: %w", err)
+	}
+	return nil
+}
+
+// nullableJSON passes an empty snapshot to Postgres as NULL instead of an empty string.
+func nullableJSON(raw json.RawMessage) interface{} {
+	if raw == nil {
+		return nil
+	}
+	return string(raw)
+}
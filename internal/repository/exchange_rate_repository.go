@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"go.uber.org/zap"
+	"taskTestEffectMobile/internal/models/sql_models"
+)
+
+type ExchangeRateRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewExchangeRateRepository(db *sql.DB, logger *zap.Logger) *ExchangeRateRepository {
+	return &ExchangeRateRepository{
+		db:     db,
+		logger: logger.With(zap.String("layer", "repository")),
+	}
+}
+
+// UpsertExchangeRates stores the rates in one transaction, replacing rates of
+// the same currency and month.
+func (exchangeRateRepository ExchangeRateRepository) UpsertExchangeRates(ctx context.Context, rates []sql_models.ExchangeRate) error {
+	exchangeRateRepository.logger.Debug("Upserting exchange rates",
+		zap.Int("count", len(rates)))
+
+	return runInTx(ctx, exchangeRateRepository.db, exchangeRateRepository.logger, func(tx *sql.Tx) error {
+		query := `
+			INSERT INTO exchange_rates (currency, valid_from, rate)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (currency, valid_from) DO UPDATE
+			SET rate = EXCLUDED.rate, updated_at = NOW()
+		`
+		for _, rate := range rates {
+			if _, err := tx.ExecContext(ctx, query, rate.Currency, rate.ValidFrom, rate.Rate); err != nil {
+				exchangeRateRepository.logger.Error("Failed to upsert exchange rate",
+					zap.String("query", query),
+					zap.String("currency", rate.Currency),
+					zap.Time("validFrom", rate.ValidFrom),
+					zap.Error(err))
+				return fmt.Errorf("failed to upsert exchange rate: %w", mapDatabaseError(err))
+			}
+		}
+		return nil
+	})
+}
+
+func (exchangeRateRepository ExchangeRateRepository) ListExchangeRates(ctx context.Context) ([]sql_models.ExchangeRate, error) {
+	query := `SELECT currency, valid_from, rate FROM exchange_rates ORDER BY currency, valid_from`
+
+	rows, err := exchangeRateRepository.db.QueryContext(ctx, query)
+	if err != nil {
+		exchangeRateRepository.logger.Error("Failed to query exchange rates",
+			zap.String("query", query),
+			zap.Error(err))
+		return nil, fmt.Errorf("database query failed: %w", err)
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			exchangeRateRepository.logger.Error("Failed to close rows",
+				zap.Error(closeErr))
+		}
+	}()
+
+	var rates []sql_models.ExchangeRate
+	for rows.Next() {
+		var rate sql_models.ExchangeRate
+		if err := rows.Scan(&rate.Currency, &rate.ValidFrom, &rate.Rate); err != nil {
+			return nil, fmt.Errorf("error with scanning: %w", err)
+		}
+		rates = append(rates, rate)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iteration error: %w", err)
+	}
+	return rates, nil
+}
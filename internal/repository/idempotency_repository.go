@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"taskTestEffectMobile/internal/models/sql_models"
+	"time"
+)
+
+type IdempotencyRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewIdempotencyRepository(db *sql.DB, logger *zap.Logger) *IdempotencyRepository {
+	return &IdempotencyRepository{
+		db:     db,
+		logger: logger.With(zap.String("layer", "repository")),
+	}
+}
+
+// reserveAttempts bounds the retries of Reserve when the key disappears
+// between the insert and the read.
+const reserveAttempts = 3
+
+// Reserve claims the key for a new request. When the key is already taken by
+// an unexpired record, that record is returned and reserved is false.
+func (idempotencyRepository IdempotencyRepository) Reserve(ctx context.Context, key, requestHash string, expiresAt time.Time) (sql_models.IdempotencyRecord, bool, error) {
+	idempotencyRepository.logger.Debug("Reserving idempotency key",
+		zap.String("key", key))
+
+	for attempt := 1; ; attempt++ {
+		record, reserved, err := idempotencyRepository.reserve(ctx, key, requestHash, expiresAt)
+		// The holder released the key after the insert found it taken; the
+		// next insert can claim it.
+		if errors.Is(err, sql.ErrNoRows) && attempt < reserveAttempts {
+			continue
+		}
+		if err != nil {
+			idempotencyRepository.logger.Error("Failed to reserve idempotency key",
+				zap.String("key", key),
+				zap.Error(err))
+			return sql_models.IdempotencyRecord{}, false, fmt.Errorf("failed to reserve idempotency key: %w", err)
+		}
+		return record, reserved, nil
+	}
+}
+
+func (idempotencyRepository IdempotencyRepository) reserve(ctx context.Context, key, requestHash string, expiresAt time.Time) (sql_models.IdempotencyRecord, bool, error) {
+	query := `
+		INSERT INTO idempotency_keys (key, request_hash, expires_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (key) DO UPDATE
+		SET request_hash = EXCLUDED.request_hash,
+			status_code = 0,
+			content_type = '',
+			response_body = NULL,
+			created_at = NOW(),
+			expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= NOW()
+		RETURNING key
+	`
+
+	var reservedKey string
+	err := idempotencyRepository.db.QueryRowContext(ctx, query, key, requestHash, expiresAt).Scan(&reservedKey)
+	if err == nil {
+		return sql_models.IdempotencyRecord{Key: key, RequestHash: requestHash, ExpiresAt: expiresAt}, true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return sql_models.IdempotencyRecord{}, false, err
+	}
+
+	query = `SELECT key, request_hash, status_code, content_type, response_body, created_at, expires_at FROM idempotency_keys WHERE key = $1`
+
+	var record sql_models.IdempotencyRecord
+	if err := idempotencyRepository.db.QueryRowContext(ctx, query, key).Scan(
+		&record.Key,
+		&record.RequestHash,
+		&record.StatusCode,
+		&record.ContentType,
+		&record.ResponseBody,
+		&record.CreatedAt,
+		&record.ExpiresAt,
+	); err != nil {
+		return sql_models.IdempotencyRecord{}, false, err
+	}
+	return record, false, nil
+}
+
+func (idempotencyRepository IdempotencyRepository) Complete(ctx context.Context, key string, statusCode int, contentType string, body []byte) error {
+	idempotencyRepository.logger.Debug("Storing idempotent response",
+		zap.String("key", key),
+		zap.Int("statusCode", statusCode))
+
+	query := `UPDATE idempotency_keys SET status_code = $1, content_type = $2, response_body = $3 WHERE key = $4`
+
+	if _, err := idempotencyRepository.db.ExecContext(ctx, query, statusCode, contentType, body, key); err != nil {
+		idempotencyRepository.logger.Error("Failed to store idempotent response",
+			zap.String("query", query),
+			zap.String("key", key),
+			zap.Error(err))
+		return fmt.Errorf("failed to store idempotent response: %w", err)
+	}
+	return nil
+}
+
+func (idempotencyRepository IdempotencyRepository) Release(ctx context.Context, key string) error {
+	idempotencyRepository.logger.Debug("Releasing idempotency key",
+		zap.String("key", key))
+
+	query := `DELETE FROM idempotency_keys WHERE key = $1 AND status_code = 0`
+
+	if _, err := idempotencyRepository.db.ExecContext(ctx, query, key); err != nil {
+		idempotencyRepository.logger.Error("Failed to release idempotency key",
+			zap.String("query", query),
+			zap.String("key", key),
+			zap.Error(err))
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpired removes the keys that expired by now.
+func (idempotencyRepository IdempotencyRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	query := `DELETE FROM idempotency_keys WHERE expires_at <= $1`
+
+	result, err := idempotencyRepository.db.ExecContext(ctx, query, now)
+	if err != nil {
+		idempotencyRepository.logger.Error("Failed to delete expired idempotency keys",
+			zap.String("query", query),
+			zap.Error(err))
+		return 0, fmt.Errorf("failed to delete expired idempotency keys: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired idempotency keys: %w", err)
+	}
+	return deleted, nil
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"taskTestEffectMobile/internal/models/json_models"
+	"taskTestEffectMobile/internal/models/sql_models"
+	"time"
+)
+
+const (
+	SortByPrice     = "price"
+	SortByStartDate = "start_date"
+	SortByCreatedAt = "created_at"
+)
+
+// listSortColumns whitelists the columns subscriptions can be ordered by.
+var listSortColumns = map[string]string{
+	SortByPrice:     "price",
+	SortByStartDate: "start_date",
+	SortByCreatedAt: "created_at",
+}
+
+// NewListCursor returns the cursor pointing right after sub in the given order.
+func NewListCursor(sub sql_models.Subscription, sort, order string) json_models.ListCursor {
+	cursor := json_models.ListCursor{Sort: sort, Order: order, ID: sub.ID}
+	switch sort {
+	case SortByPrice:
+		cursor.Price = sub.Price
+	case SortByStartDate:
+		cursor.Time = sub.StartDate
+	default:
+		cursor.Time = sub.CreatedAt
+	}
+	return cursor
+}
+
+// cursorValue returns the sort key stored in the cursor for the given column.
+func cursorValue(cursor json_models.ListCursor, sort string) interface{} {
+	if sort == SortByPrice {
+		return cursor.Price
+	}
+	return cursor.Time
+}
+
+// compareBySort orders two subscriptions by the sort column with the ID as a tiebreaker.
+func compareBySort(a, b sql_models.Subscription, sort string) int {
+	switch sort {
+	case SortByPrice:
+		if a.Price != b.Price {
+			if a.Price < b.Price {
+				return -1
+			}
+			return 1
+		}
+	case SortByStartDate:
+		if c := compareTime(a.StartDate, b.StartDate); c != 0 {
+			return c
+		}
+	default:
+		if c := compareTime(a.CreatedAt, b.CreatedAt); c != 0 {
+			return c
+		}
+	}
+
+	switch {
+	case a.ID < b.ID:
+		return -1
+	case a.ID > b.ID:
+		return 1
+	}
+	return 0
+}
+
+func compareTime(a, b time.Time) int {
+	switch {
+	case a.Before(b):
+		return -1
+	case a.After(b):
+		return 1
+	}
+	return 0
+}
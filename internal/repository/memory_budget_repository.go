@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"sort"
+	"strings"
+	"sync"
+	"taskTestEffectMobile/internal/models/domain_errors"
+	"taskTestEffectMobile/internal/models/sql_models"
+	"time"
+)
+
+// InMemoryBudgetRepository mirrors BudgetRepository in process memory.
+type InMemoryBudgetRepository struct {
+	mu      sync.RWMutex
+	budgets map[string]sql_models.Budget
+	alerts  []sql_models.BudgetAlert
+	logger  *zap.Logger
+}
+
+func NewInMemoryBudgetRepository(logger *zap.Logger) *InMemoryBudgetRepository {
+	return &InMemoryBudgetRepository{
+		budgets: make(map[string]sql_models.Budget),
+		logger:  logger.With(zap.String("layer", "repository"), zap.String("storage", "memory")),
+	}
+}
+
+func (memoryRepository *InMemoryBudgetRepository) UpsertBudget(ctx context.Context, budget sql_models.Budget) (sql_models.Budget, error) {
+	memoryRepository.logger.Debug("Upserting budget",
+		zap.String("userID", budget.UserID),
+		zap.Any("service", budget.ServiceName))
+
+	memoryRepository.mu.Lock()
+	defer memoryRepository.mu.Unlock()
+
+	now := time.Now()
+	for id, stored := range memoryRepository.budgets {
+		if stored.UserID == budget.UserID && sameBudgetScope(stored.ServiceName, budget.ServiceName) {
+			stored.Amount = budget.Amount
+			stored.Currency = budget.Currency
+			stored.UpdatedAt = now
+			memoryRepository.budgets[id] = stored
+			return copyBudget(stored), nil
+		}
+	}
+
+	budget.ID = uuid.NewString()
+	budget.ServiceName = copyString(budget.ServiceName)
+	budget.CreatedAt = now
+	budget.UpdatedAt = now
+	memoryRepository.budgets[budget.ID] = budget
+	return copyBudget(budget), nil
+}
+
+func (memoryRepository *InMemoryBudgetRepository) ListBudgets(ctx context.Context, userID string) ([]sql_models.Budget, error) {
+	memoryRepository.mu.RLock()
+	defer memoryRepository.mu.RUnlock()
+
+	var budgets []sql_models.Budget
+	for _, budget := range memoryRepository.budgets {
+		if budget.UserID == userID {
+			budgets = append(budgets, copyBudget(budget))
+		}
+	}
+	sort.Slice(budgets, func(i, j int) bool {
+		left, right := budgets[i].ServiceName, budgets[j].ServiceName
+		if left == nil || right == nil {
+			return left == nil && right != nil
+		}
+		if *left != *right {
+			return *left < *right
+		}
+		return budgets[i].CreatedAt.Before(budgets[j].CreatedAt)
+	})
+	return budgets, nil
+}
+
+func (memoryRepository *InMemoryBudgetRepository) ListBudgetUserIDs(ctx context.Context) ([]string, error) {
+	memoryRepository.mu.RLock()
+	defer memoryRepository.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	var userIDs []string
+	for _, budget := range memoryRepository.budgets {
+		if !seen[budget.UserID] {
+			seen[budget.UserID] = true
+			userIDs = append(userIDs, budget.UserID)
+		}
+	}
+	sort.Strings(userIDs)
+	return userIDs, nil
+}
+
+func (memoryRepository *InMemoryBudgetRepository) DeleteBudget(ctx context.Context, userID, budgetID string) error {
+	memoryRepository.logger.Debug("Deleting budget",
+		zap.String("userID", userID),
+		zap.String("budgetID", budgetID))
+
+	memoryRepository.mu.Lock()
+	defer memoryRepository.mu.Unlock()
+
+	if budget, ok := memoryRepository.budgets[budgetID]; !ok || budget.UserID != userID {
+		return domain_errors.ErrBudgetNotFound
+	}
+	delete(memoryRepository.budgets, budgetID)
+
+	alerts := memoryRepository.alerts[:0]
+	for _, alert := range memoryRepository.alerts {
+		if alert.BudgetID != budgetID {
+			alerts = append(alerts, alert)
+		}
+	}
+	memoryRepository.alerts = alerts
+	return nil
+}
+
+func (memoryRepository *InMemoryBudgetRepository) RecordBudgetAlert(ctx context.Context, alert sql_models.BudgetAlert) (bool, error) {
+	memoryRepository.mu.Lock()
+	defer memoryRepository.mu.Unlock()
+
+	for _, stored := range memoryRepository.alerts {
+		if stored.BudgetID == alert.BudgetID && stored.Month.Equal(alert.Month) && stored.Threshold == alert.Threshold {
+			return false, nil
+		}
+	}
+	alert.ID = uuid.NewString()
+	alert.ServiceName = copyString(alert.ServiceName)
+	alert.CreatedAt = time.Now()
+	memoryRepository.alerts = append(memoryRepository.alerts, alert)
+	return true, nil
+}
+
+func (memoryRepository *InMemoryBudgetRepository) ListBudgetAlerts(ctx context.Context, userID string) ([]sql_models.BudgetAlert, error) {
+	memoryRepository.mu.RLock()
+	defer memoryRepository.mu.RUnlock()
+
+	var alerts []sql_models.BudgetAlert
+	for i := len(memoryRepository.alerts) - 1; i >= 0; i-- {
+		if alert := memoryRepository.alerts[i]; alert.UserID == userID {
+			alert.ServiceName = copyString(alert.ServiceName)
+			alerts = append(alerts, alert)
+		}
+	}
+	return alerts, nil
+}
+
+// sameBudgetScope reports whether two budgets cover the same subscriptions.
+func sameBudgetScope(left, right *string) bool {
+	if left == nil || right == nil {
+		return left == nil && right == nil
+	}
+	return strings.EqualFold(*left, *right)
+}
+
+func copyBudget(budget sql_models.Budget) sql_models.Budget {
+	budget.ServiceName = copyString(budget.ServiceName)
+	return budget
+}
+
+func copyString(value *string) *string {
+	if value == nil {
+		return nil
+	}
+	copied := *value
+	return &copied
+}
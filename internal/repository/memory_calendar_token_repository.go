@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"sync"
+	"taskTestEffectMobile/internal/models/domain_errors"
+	"taskTestEffectMobile/internal/models/sql_models"
+	"time"
+)
+
+// InMemoryCalendarTokenRepository mirrors CalendarTokenRepository in process memory.
+type InMemoryCalendarTokenRepository struct {
+	mu     sync.RWMutex
+	tokens map[string]sql_models.CalendarToken
+	logger *zap.Logger
+}
+
+func NewInMemoryCalendarTokenRepository(logger *zap.Logger) *InMemoryCalendarTokenRepository {
+	return &InMemoryCalendarTokenRepository{
+		tokens: make(map[string]sql_models.CalendarToken),
+		logger: logger.With(zap.String("layer", "repository"), zap.String("storage", "memory")),
+	}
+}
+
+func (memoryRepository *InMemoryCalendarTokenRepository) SaveCalendarToken(ctx context.Context, token sql_models.CalendarToken) error {
+	memoryRepository.logger.Debug("Saving calendar token",
+		zap.String("userID", token.UserID))
+
+	memoryRepository.mu.Lock()
+	defer memoryRepository.mu.Unlock()
+
+	token.CreatedAt = time.Now()
+	token.ReminderDays = copyInt(token.ReminderDays)
+	memoryRepository.tokens[token.UserID] = token
+	return nil
+}
+
+func (memoryRepository *InMemoryCalendarTokenRepository) GetCalendarToken(ctx context.Context, tokenHash string) (sql_models.CalendarToken, error) {
+	memoryRepository.mu.RLock()
+	defer memoryRepository.mu.RUnlock()
+
+	for _, token := range memoryRepository.tokens {
+		if token.TokenHash == tokenHash {
+			token.ReminderDays = copyInt(token.ReminderDays)
+			return token, nil
+		}
+	}
+	return sql_models.CalendarToken{}, domain_errors.ErrCalendarFeedNotFound
+}
+
+func (memoryRepository *InMemoryCalendarTokenRepository) DeleteCalendarToken(ctx context.Context, userID string) error {
+	memoryRepository.logger.Debug("Deleting calendar token",
+		zap.String("userID", userID))
+
+	memoryRepository.mu.Lock()
+	defer memoryRepository.mu.Unlock()
+
+	if _, ok := memoryRepository.tokens[userID]; !ok {
+		return domain_errors.ErrCalendarFeedNotFound
+	}
+	delete(memoryRepository.tokens, userID)
+	return nil
+}
+
+func copyInt(value *int) *int {
+	if value == nil {
+		return nil
+	}
+	copied := *value
+	return &copied
+}
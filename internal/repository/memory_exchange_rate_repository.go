@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"sort"
+	"sync"
+	"taskTestEffectMobile/internal/models/sql_models"
+	"time"
+)
+
+type exchangeRateKey struct {
+	currency  string
+	validFrom time.Time
+}
+
+// InMemoryExchangeRateRepository mirrors ExchangeRateRepository in process memory.
+type InMemoryExchangeRateRepository struct {
+	mu     sync.RWMutex
+	rates  map[exchangeRateKey]float64
+	logger *zap.Logger
+}
+
+func NewInMemoryExchangeRateRepository(logger *zap.Logger) *InMemoryExchangeRateRepository {
+	return &InMemoryExchangeRateRepository{
+		rates:  make(map[exchangeRateKey]float64),
+		logger: logger.With(zap.String("layer", "repository"), zap.String("storage", "memory")),
+	}
+}
+
+func (memoryRepository *InMemoryExchangeRateRepository) UpsertExchangeRates(ctx context.Context, rates []sql_models.ExchangeRate) error {
+	memoryRepository.logger.Debug("Upserting exchange rates",
+		zap.Int("count", len(rates)))
+
+	memoryRepository.mu.Lock()
+	defer memoryRepository.mu.Unlock()
+
+	for _, rate := range rates {
+		memoryRepository.rates[exchangeRateKey{currency: rate.Currency, validFrom: rate.ValidFrom}] = rate.Rate
+	}
+	return nil
+}
+
+func (memoryRepository *InMemoryExchangeRateRepository) ListExchangeRates(ctx context.Context) ([]sql_models.ExchangeRate, error) {
+	memoryRepository.mu.RLock()
+	defer memoryRepository.mu.RUnlock()
+
+	rates := make([]sql_models.ExchangeRate, 0, len(memoryRepository.rates))
+	for key, rate := range memoryRepository.rates {
+		rates = append(rates, sql_models.ExchangeRate{Currency: key.currency, ValidFrom: key.validFrom, Rate: rate})
+	}
+	sort.Slice(rates, func(i, j int) bool {
+		if rates[i].Currency == rates[j].Currency {
+			return rates[i].ValidFrom.Before(rates[j].ValidFrom)
+		}
+		return rates[i].Currency < rates[j].Currency
+	})
+	return rates, nil
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"sync"
+	"taskTestEffectMobile/internal/models/sql_models"
+	"time"
+)
+
+// InMemoryIdempotencyRepository mirrors IdempotencyRepository in process memory.
+type InMemoryIdempotencyRepository struct {
+	mu      sync.Mutex
+	records map[string]sql_models.IdempotencyRecord
+	logger  *zap.Logger
+}
+
+func NewInMemoryIdempotencyRepository(logger *zap.Logger) *InMemoryIdempotencyRepository {
+	return &InMemoryIdempotencyRepository{
+		records: make(map[string]sql_models.IdempotencyRecord),
+		logger:  logger.With(zap.String("layer", "repository"), zap.String("storage", "memory")),
+	}
+}
+
+func (memoryRepository *InMemoryIdempotencyRepository) Reserve(ctx context.Context, key, requestHash string, expiresAt time.Time) (sql_models.IdempotencyRecord, bool, error) {
+	memoryRepository.logger.Debug("Reserving idempotency key",
+		zap.String("key", key))
+
+	memoryRepository.mu.Lock()
+	defer memoryRepository.mu.Unlock()
+
+	now := time.Now()
+	if record, ok := memoryRepository.records[key]; ok && record.ExpiresAt.After(now) {
+		return record, false, nil
+	}
+
+	record := sql_models.IdempotencyRecord{
+		Key:         key,
+		RequestHash: requestHash,
+		CreatedAt:   now,
+		ExpiresAt:   expiresAt,
+	}
+	memoryRepository.records[key] = record
+	return record, true, nil
+}
+
+func (memoryRepository *InMemoryIdempotencyRepository) Complete(ctx context.Context, key string, statusCode int, contentType string, body []byte) error {
+	memoryRepository.logger.Debug("Storing idempotent response",
+		zap.String("key", key),
+		zap.Int("statusCode", statusCode))
+
+	memoryRepository.mu.Lock()
+	defer memoryRepository.mu.Unlock()
+
+	record, ok := memoryRepository.records[key]
+	if !ok {
+		return nil
+	}
+	record.StatusCode = statusCode
+	record.ContentType = contentType
+	record.ResponseBody = append([]byte(nil), body...)
+	memoryRepository.records[key] = record
+	return nil
+}
+
+func (memoryRepository *InMemoryIdempotencyRepository) Release(ctx context.Context, key string) error {
+	memoryRepository.logger.Debug("Releasing idempotency key",
+		zap.String("key", key))
+
+	memoryRepository.mu.Lock()
+	defer memoryRepository.mu.Unlock()
+
+	if record, ok := memoryRepository.records[key]; ok && !record.Completed() {
+		delete(memoryRepository.records, key)
+	}
+	return nil
+}
+
+func (memoryRepository *InMemoryIdempotencyRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	memoryRepository.mu.Lock()
+	defer memoryRepository.mu.Unlock()
+
+	var deleted int64
+	for key, record := range memoryRepository.records {
+		if !record.ExpiresAt.After(now) {
+			delete(memoryRepository.records, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"testing"
+	"time"
+)
+
+func TestDeleteExpiredIdempotencyKeys(t *testing.T) {
+	ctx := context.Background()
+	repo := NewInMemoryIdempotencyRepository(zap.NewNop())
+	now := time.Now()
+
+	for key, expiresAt := range map[string]time.Time{"expired": now.Add(-time.Minute), "live": now.Add(time.Hour)} {
+		if _, reserved, err := repo.Reserve(ctx, key, "hash", expiresAt); err != nil || !reserved {
+			t.Fatalf("Reserve(%s) = %v, %v", key, reserved, err)
+		}
+	}
+
+	deleted, err := repo.DeleteExpired(ctx, now)
+	if err != nil || deleted != 1 {
+		t.Fatalf("DeleteExpired() = %d, %v; want 1", deleted, err)
+	}
+	if _, reserved, err := repo.Reserve(ctx, "live", "hash", now.Add(time.Hour)); err != nil || reserved {
+		t.Errorf("live key was deleted: reserved = %v, err = %v", reserved, err)
+	}
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"taskTestEffectMobile/internal/models/sql_models"
+	"time"
+)
+
+func (memoryRepository *InMemorySubscriptionRepository) RelayOutboxEvents(ctx context.Context, limit int, publish func(event sql_models.OutboxEvent) error) (int, error) {
+	memoryRepository.relayMu.Lock()
+	defer memoryRepository.relayMu.Unlock()
+
+	// Events are published without the store lock, so a slow publisher does
+	// not block subscription changes. relayMu keeps them in order.
+	memoryRepository.mu.RLock()
+	var events []sql_models.OutboxEvent
+	for _, event := range memoryRepository.outbox {
+		if event.PublishedAt == nil {
+			events = append(events, event)
+			if len(events) == limit {
+				break
+			}
+		}
+	}
+	memoryRepository.mu.RUnlock()
+
+	published := make(map[int64]bool)
+	var publishErr error
+	for _, event := range events {
+		if publishErr = publish(event); publishErr != nil {
+			break
+		}
+		published[event.ID] = true
+	}
+
+	memoryRepository.mu.Lock()
+	defer memoryRepository.mu.Unlock()
+
+	now := time.Now()
+	for i := range memoryRepository.outbox {
+		if published[memoryRepository.outbox[i].ID] {
+			memoryRepository.outbox[i].PublishedAt = &now
+		}
+	}
+	return len(published), publishErr
+}
+
+func (memoryRepository *InMemorySubscriptionRepository) DeletePublishedOutboxEvents(ctx context.Context, publishedBefore time.Time) (int64, error) {
+	memoryRepository.logger.Debug("Deleting published outbox events",
+		zap.Time("publishedBefore", publishedBefore))
+
+	memoryRepository.mu.Lock()
+	defer memoryRepository.mu.Unlock()
+
+	kept := memoryRepository.outbox[:0]
+	for _, event := range memoryRepository.outbox {
+		if event.PublishedAt == nil || !event.PublishedAt.Before(publishedBefore) {
+			kept = append(kept, event)
+		}
+	}
+	deleted := int64(len(memoryRepository.outbox) - len(kept))
+	memoryRepository.outbox = kept
+	return deleted, nil
+}
@@ -0,0 +1,607 @@
+package repository
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"sort"
+	"strings"
+	"sync"
+	"taskTestEffectMobile/internal/models/domain_errors"
+	"taskTestEffectMobile/internal/models/json_models"
+	"taskTestEffectMobile/internal/models/sql_models"
+	"taskTestEffectMobile/internal/utils"
+	"time"
+)
+
+// InMemorySubscriptionRepository keeps subscriptions in process memory.
+// It mirrors SubscriptionRepository and is meant for tests and local demos.
+type InMemorySubscriptionRepository struct {
+	mu            sync.RWMutex
+	subscriptions map[string]sql_models.Subscription
+	pauses        map[string][]sql_models.SubscriptionPause
+	prices        map[string][]sql_models.SubscriptionPrice
+	events        []sql_models.SubscriptionEvent
+	outbox        []sql_models.OutboxEvent
+	outboxSeq     int64
+	// relayMu lets one relay at a time publish the outbox.
+	relayMu sync.Mutex
+	logger  *zap.Logger
+}
+
+func NewInMemorySubscriptionRepository(logger *zap.Logger) *InMemorySubscriptionRepository {
+	return &InMemorySubscriptionRepository{
+		subscriptions: make(map[string]sql_models.Subscription),
+		pauses:        make(map[string][]sql_models.SubscriptionPause),
+		prices:        make(map[string][]sql_models.SubscriptionPrice),
+		logger:        logger.With(zap.String("layer", "repository"), zap.String("storage", "memory")),
+	}
+}
+
+func (memoryRepository *InMemorySubscriptionRepository) InsertSubscription(ctx context.Context, sub sql_models.Subscription, allowOverlap bool) (string, error) {
+	memoryRepository.logger.Debug("Inserting new subscription",
+		zap.String("userID", sub.UserID),
+		zap.String("service", sub.ServiceName),
+		zap.Bool("allowOverlap", allowOverlap))
+
+	memoryRepository.mu.Lock()
+	defer memoryRepository.mu.Unlock()
+
+	if !allowOverlap {
+		if err := memoryRepository.checkOverlap(sub); err != nil {
+			return "", err
+		}
+	}
+
+	if sub.EndDate != nil && sub.EndDate.Before(sub.StartDate) {
+		return "", domain_errors.ErrInvalidDateRange
+	}
+
+	sub.ID = uuid.New().String()
+	sub.EndDate = copyTime(sub.EndDate)
+	sub.TrialEndDate = copyTime(sub.TrialEndDate)
+	sub.CreatedAt = time.Now()
+	sub.Version = 1
+	if err := memoryRepository.recordEvent(ctx, sql_models.EventCreated, sub.ID, nil, &sub); err != nil {
+		return "", err
+	}
+	memoryRepository.subscriptions[sub.ID] = sub
+	memoryRepository.prices[sub.ID] = []sql_models.SubscriptionPrice{
+		{SubscriptionID: sub.ID, EffectiveFrom: utils.MonthStart(sub.StartDate), Price: sub.Price},
+	}
+
+	memoryRepository.logger.Info("Subscription created successfully",
+		zap.String("subscriptionID", sub.ID))
+	return sub.ID, nil
+}
+
+// checkOverlap mirrors SubscriptionRepository.checkOverlap. The caller must hold the lock.
+func (memoryRepository *InMemorySubscriptionRepository) checkOverlap(sub sql_models.Subscription) error {
+	var existing *sql_models.Subscription
+	for _, stored := range memoryRepository.subscriptions {
+		if stored.ID == sub.ID || stored.DeletedAt != nil {
+			continue
+		}
+		if stored.UserID != sub.UserID || !strings.EqualFold(stored.ServiceName, sub.ServiceName) {
+			continue
+		}
+		if !periodsOverlap(stored.StartDate, stored.EndDate, sub.StartDate, sub.EndDate) {
+			continue
+		}
+		if existing == nil || stored.StartDate.Before(existing.StartDate) {
+			candidate := stored
+			existing = &candidate
+		}
+	}
+	if existing != nil {
+		memoryRepository.logger.Warn("Overlapping subscription exists",
+			zap.String("userID", sub.UserID),
+			zap.String("service", sub.ServiceName),
+			zap.String("existingID", existing.ID))
+		return &domain_errors.DuplicateSubscriptionError{ExistingID: existing.ID}
+	}
+	return nil
+}
+
+func (memoryRepository *InMemorySubscriptionRepository) GetSubscriptions(ctx context.Context, userID uuid.UUID) ([]sql_models.Subscription, error) {
+	memoryRepository.logger.Debug("Getting user subscriptions",
+		zap.String("userID", userID.String()))
+
+	memoryRepository.mu.RLock()
+	defer memoryRepository.mu.RUnlock()
+
+	var subscriptions []sql_models.Subscription
+	for _, sub := range memoryRepository.subscriptions {
+		if sub.UserID == userID.String() && sub.DeletedAt == nil {
+			subscriptions = append(subscriptions, cloneSubscription(sub))
+		}
+	}
+	sortByCreatedAt(subscriptions)
+
+	memoryRepository.logger.Debug("Retrieved subscriptions count",
+		zap.String("userID", userID.String()),
+		zap.Int("count", len(subscriptions)))
+	return subscriptions, nil
+}
+
+func (memoryRepository *InMemorySubscriptionRepository) GetSubscription(ctx context.Context, subscriptionUUID uuid.UUID, includeDeleted bool) (sql_models.Subscription, error) {
+	memoryRepository.logger.Debug("Getting subscription",
+		zap.String("subscriptionID", subscriptionUUID.String()),
+		zap.Bool("includeDeleted", includeDeleted))
+
+	memoryRepository.mu.RLock()
+	defer memoryRepository.mu.RUnlock()
+
+	sub, ok := memoryRepository.subscriptions[subscriptionUUID.String()]
+	if !ok || (sub.DeletedAt != nil && !includeDeleted) {
+		memoryRepository.logger.Warn("Subscription not found",
+			zap.String("subscriptionID", subscriptionUUID.String()))
+		return sql_models.Subscription{}, domain_errors.ErrSubscriptionNotFound
+	}
+	return cloneSubscription(sub), nil
+}
+
+func (memoryRepository *InMemorySubscriptionRepository) ListSubscriptions(ctx context.Context, filter json_models.SubscriptionListFilter) ([]sql_models.Subscription, error) {
+	memoryRepository.logger.Debug("Listing subscriptions",
+		zap.Any("filter", filter))
+
+	memoryRepository.mu.RLock()
+	var subscriptions []sql_models.Subscription
+	for _, sub := range memoryRepository.subscriptions {
+		if matchesListFilter(sub, filter) {
+			subscriptions = append(subscriptions, cloneSubscription(sub))
+		}
+	}
+	memoryRepository.mu.RUnlock()
+
+	sort.Slice(subscriptions, func(i, j int) bool {
+		c := compareBySort(subscriptions[i], subscriptions[j], filter.Sort)
+		if filter.Descending {
+			return c > 0
+		}
+		return c < 0
+	})
+
+	if len(subscriptions) > filter.Limit {
+		subscriptions = subscriptions[:filter.Limit]
+	}
+
+	memoryRepository.logger.Debug("Listed subscriptions count",
+		zap.Int("count", len(subscriptions)))
+	return subscriptions, nil
+}
+
+func (memoryRepository *InMemorySubscriptionRepository) UpdateSubscription(ctx context.Context, subscriptionID string, data json_models.SubscriptionUpdate) (sql_models.Subscription, error) {
+	memoryRepository.logger.Debug("Updating subscription",
+		zap.String("SubscriptionID", subscriptionID),
+		zap.Any("updateData", data))
+
+	memoryRepository.mu.Lock()
+	defer memoryRepository.mu.Unlock()
+
+	before, ok := memoryRepository.subscriptions[subscriptionID]
+	if !ok || before.DeletedAt != nil {
+		memoryRepository.logger.Warn("Subscription not found for update",
+			zap.String("subscriptionID", subscriptionID))
+		return sql_models.Subscription{}, domain_errors.ErrSubscriptionNotFound
+	}
+	sub := cloneSubscription(before)
+	if data.ExpectedVersion != nil && *data.ExpectedVersion != sub.Version {
+		memoryRepository.logger.Warn("Subscription version mismatch",
+			zap.String("subscriptionID", subscriptionID),
+			zap.Int("version", sub.Version),
+			zap.Int("expectedVersion", *data.ExpectedVersion))
+		return sql_models.Subscription{}, domain_errors.ErrPreconditionFailed
+	}
+
+	if err := checkUpdatedDates(before, data); err != nil {
+		memoryRepository.logger.Warn("Invalid dates after update",
+			zap.String("subscriptionID", subscriptionID),
+			zap.Error(err))
+		return sql_models.Subscription{}, err
+	}
+
+	if data.ServiceName != nil {
+		sub.ServiceName = *data.ServiceName
+	}
+	if data.Price != nil {
+		sub.Price = *data.Price
+	}
+	if data.StartDate != nil {
+		sub.StartDate = *data.StartDate
+	}
+	if data.Currency != nil {
+		sub.Currency = *data.Currency
+	}
+	if data.BillingInterval != nil {
+		sub.BillingInterval = *data.BillingInterval
+	}
+	if data.BillingIntervalCount != nil {
+		sub.BillingIntervalCount = *data.BillingIntervalCount
+	}
+	if data.BillingAnchorDay != nil {
+		sub.BillingAnchorDay = *data.BillingAnchorDay
+	}
+	if data.ClearEndDate {
+		sub.EndDate = nil
+	} else if data.EndDate != nil {
+		sub.EndDate = copyTime(data.EndDate)
+	}
+	prices, pricesChanged := memoryRepository.prices[subscriptionID], false
+	if data.Price != nil && data.PriceEffectiveFrom != nil {
+		current := append([]sql_models.SubscriptionPrice(nil), prices...)
+		prices, pricesChanged = applyPriceChange(subscriptionID, current, *data.Price, *data.PriceEffectiveFrom)
+		sub.Price = currentPrice(before, prices, data.CurrentMonth)
+	}
+	sub.Version++
+	if err := memoryRepository.recordEvent(ctx, sql_models.EventUpdated, subscriptionID, &before, &sub); err != nil {
+		return sql_models.Subscription{}, err
+	}
+	memoryRepository.subscriptions[subscriptionID] = sub
+	if pricesChanged {
+		memoryRepository.prices[subscriptionID] = prices
+	}
+
+	memoryRepository.logger.Info("Subscription updated successfully",
+		zap.String("subscriptionID", subscriptionID))
+	return cloneSubscription(sub), nil
+}
+
+func (memoryRepository *InMemorySubscriptionRepository) DeleteSubscription(ctx context.Context, subscriptionUUID uuid.UUID, expectedVersion *int) (sql_models.Subscription, error) {
+	memoryRepository.logger.Debug("Attempting to delete subscription",
+		zap.String("userID", subscriptionUUID.String()))
+
+	memoryRepository.mu.Lock()
+	defer memoryRepository.mu.Unlock()
+
+	before, ok := memoryRepository.subscriptions[subscriptionUUID.String()]
+	if !ok || before.DeletedAt != nil {
+		memoryRepository.logger.Warn("Subscription not found for deletion",
+			zap.String("userID", subscriptionUUID.String()))
+		return sql_models.Subscription{}, domain_errors.ErrSubscriptionNotFound
+	}
+	if expectedVersion != nil && *expectedVersion != before.Version {
+		memoryRepository.logger.Warn("Subscription version mismatch",
+			zap.String("userID", subscriptionUUID.String()),
+			zap.Int("version", before.Version),
+			zap.Int("expectedVersion", *expectedVersion))
+		return sql_models.Subscription{}, domain_errors.ErrPreconditionFailed
+	}
+	sub := cloneSubscription(before)
+	deletedAt := time.Now()
+	sub.DeletedAt = &deletedAt
+	sub.Version++
+	if err := memoryRepository.recordEvent(ctx, sql_models.EventDeleted, sub.ID, &before, &sub); err != nil {
+		return sql_models.Subscription{}, err
+	}
+	memoryRepository.subscriptions[sub.ID] = sub
+
+	memoryRepository.logger.Info("Subscription deleted successfully",
+		zap.String("userID", subscriptionUUID.String()))
+	return cloneSubscription(sub), nil
+}
+
+func (memoryRepository *InMemorySubscriptionRepository) TransitionSubscription(ctx context.Context, subscriptionUUID uuid.UUID, target string, now time.Time, expectedVersion *int) (sql_models.Subscription, error) {
+	memoryRepository.logger.Debug("Changing subscription status",
+		zap.String("subscriptionID", subscriptionUUID.String()),
+		zap.String("status", target))
+
+	memoryRepository.mu.Lock()
+	defer memoryRepository.mu.Unlock()
+
+	before, ok := memoryRepository.subscriptions[subscriptionUUID.String()]
+	if !ok || before.DeletedAt != nil {
+		memoryRepository.logger.Warn("Subscription not found for status change",
+			zap.String("subscriptionID", subscriptionUUID.String()))
+		return sql_models.Subscription{}, domain_errors.ErrSubscriptionNotFound
+	}
+	if expectedVersion != nil && *expectedVersion != before.Version {
+		memoryRepository.logger.Warn("Subscription version mismatch",
+			zap.String("subscriptionID", subscriptionUUID.String()),
+			zap.Int("version", before.Version),
+			zap.Int("expectedVersion", *expectedVersion))
+		return sql_models.Subscription{}, domain_errors.ErrPreconditionFailed
+	}
+
+	plan, err := planTransition(before, target, now)
+	if err != nil {
+		memoryRepository.logger.Warn("Status transition not allowed",
+			zap.String("subscriptionID", subscriptionUUID.String()),
+			zap.String("status", before.StatusAt(now)),
+			zap.String("target", target))
+		return sql_models.Subscription{}, err
+	}
+
+	sub := plan.after
+	sub.Version++
+	if err := memoryRepository.recordEvent(ctx, plan.eventType, sub.ID, &before, &sub); err != nil {
+		return sql_models.Subscription{}, err
+	}
+
+	pauses := memoryRepository.pauses[sub.ID]
+	if plan.pauseUntil != nil {
+		kept := pauses[:0]
+		for _, pause := range pauses {
+			if pause.PausedUntil == nil {
+				pause.PausedUntil = copyTime(plan.pauseUntil)
+			}
+			// A pause resumed in the month after it was requested never took effect.
+			if !pause.PausedUntil.Before(pause.PausedFrom) {
+				kept = append(kept, pause)
+			}
+		}
+		pauses = kept
+	}
+	if plan.pauseFrom != nil {
+		pauses = append(pauses, sql_models.SubscriptionPause{SubscriptionID: sub.ID, PausedFrom: *plan.pauseFrom})
+	}
+	memoryRepository.pauses[sub.ID] = pauses
+	memoryRepository.subscriptions[sub.ID] = sub
+
+	memoryRepository.logger.Info("Subscription status changed",
+		zap.String("subscriptionID", subscriptionUUID.String()),
+		zap.String("status", sub.Status))
+	return cloneSubscription(sub), nil
+}
+
+func (memoryRepository *InMemorySubscriptionRepository) RestoreSubscription(ctx context.Context, subscriptionUUID uuid.UUID) (sql_models.Subscription, error) {
+	memoryRepository.logger.Debug("Restoring subscription",
+		zap.String("subscriptionID", subscriptionUUID.String()))
+
+	memoryRepository.mu.Lock()
+	defer memoryRepository.mu.Unlock()
+
+	before, ok := memoryRepository.subscriptions[subscriptionUUID.String()]
+	if !ok {
+		memoryRepository.logger.Warn("Subscription not found for restore",
+			zap.String("subscriptionID", subscriptionUUID.String()))
+		return sql_models.Subscription{}, domain_errors.ErrSubscriptionNotFound
+	}
+	if before.DeletedAt == nil {
+		return cloneSubscription(before), nil
+	}
+	if err := memoryRepository.checkOverlap(before); err != nil {
+		return sql_models.Subscription{}, err
+	}
+
+	sub := cloneSubscription(before)
+	sub.DeletedAt = nil
+	sub.Version++
+	if err := memoryRepository.recordEvent(ctx, sql_models.EventRestored, sub.ID, &before, &sub); err != nil {
+		return sql_models.Subscription{}, err
+	}
+	memoryRepository.subscriptions[sub.ID] = sub
+
+	memoryRepository.logger.Info("Subscription restored successfully",
+		zap.String("subscriptionID", subscriptionUUID.String()))
+	return cloneSubscription(sub), nil
+}
+
+func (memoryRepository *InMemorySubscriptionRepository) PurgeDeletedSubscriptions(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	memoryRepository.logger.Debug("Purging deleted subscriptions",
+		zap.Time("deletedBefore", deletedBefore))
+
+	memoryRepository.mu.Lock()
+	defer memoryRepository.mu.Unlock()
+
+	var purged int64
+	for id, sub := range memoryRepository.subscriptions {
+		if sub.DeletedAt == nil || !sub.DeletedAt.Before(deletedBefore) {
+			continue
+		}
+		if err := memoryRepository.recordEvent(ctx, sql_models.EventPurged, id, &sub, nil); err != nil {
+			return purged, err
+		}
+		delete(memoryRepository.subscriptions, id)
+		delete(memoryRepository.pauses, id)
+		delete(memoryRepository.prices, id)
+		purged++
+	}
+
+	if purged > 0 {
+		memoryRepository.logger.Info("Deleted subscriptions purged",
+			zap.Int64("count", purged))
+	}
+	return purged, nil
+}
+
+func (memoryRepository *InMemorySubscriptionRepository) GetUpcomingCharges(ctx context.Context, filter json_models.UpcomingChargesFilter, rates *ExchangeRates) (json_models.UpcomingCharges, error) {
+	memoryRepository.logger.Debug("Projecting upcoming charges",
+		zap.Any("filter", filter))
+
+	memoryRepository.mu.RLock()
+	var subscriptions []sql_models.Subscription
+	history := newSubscriptionHistory()
+	for _, sub := range memoryRepository.subscriptions {
+		if (filter.UserID != "" && sub.UserID != filter.UserID) || sub.DeletedAt != nil || !sub.StartDate.Before(filter.To) {
+			continue
+		}
+		if sub.EndDate != nil && sub.EndDate.Before(filter.From) {
+			continue
+		}
+		subscriptions = append(subscriptions, cloneSubscription(sub))
+		history.pauses[sub.ID] = append([]sql_models.SubscriptionPause(nil), memoryRepository.pauses[sub.ID]...)
+		history.prices[sub.ID] = append([]sql_models.SubscriptionPrice(nil), memoryRepository.prices[sub.ID]...)
+	}
+	memoryRepository.mu.RUnlock()
+
+	return buildUpcomingCharges(subscriptions, history, filter, rates)
+}
+
+func (memoryRepository *InMemorySubscriptionRepository) GetSubscriptionPrices(ctx context.Context, subscriptionUUID uuid.UUID) ([]sql_models.SubscriptionPrice, error) {
+	memoryRepository.logger.Debug("Getting subscription prices",
+		zap.String("subscriptionID", subscriptionUUID.String()))
+
+	memoryRepository.mu.RLock()
+	defer memoryRepository.mu.RUnlock()
+
+	sub, ok := memoryRepository.subscriptions[subscriptionUUID.String()]
+	if !ok || sub.DeletedAt != nil {
+		return nil, domain_errors.ErrSubscriptionNotFound
+	}
+	return append([]sql_models.SubscriptionPrice{}, memoryRepository.prices[sub.ID]...), nil
+}
+
+func (memoryRepository *InMemorySubscriptionRepository) GetSubscriptionHistory(ctx context.Context, subscriptionUUID uuid.UUID) ([]sql_models.SubscriptionEvent, error) {
+	memoryRepository.logger.Debug("Getting subscription history",
+		zap.String("subscriptionID", subscriptionUUID.String()))
+
+	memoryRepository.mu.RLock()
+	defer memoryRepository.mu.RUnlock()
+
+	events := []sql_models.SubscriptionEvent{}
+	for _, event := range memoryRepository.events {
+		if event.SubscriptionID == subscriptionUUID.String() {
+			events = append(events, event)
+		}
+	}
+
+	if _, ok := memoryRepository.subscriptions[subscriptionUUID.String()]; !ok && len(events) == 0 {
+		return nil, domain_errors.ErrSubscriptionNotFound
+	}
+	return events, nil
+}
+
+// recordEvent appends a history entry and its outbox event. The caller must
+// hold the write lock.
+func (memoryRepository *InMemorySubscriptionRepository) recordEvent(ctx context.Context, eventType, subscriptionID string, before, after *sql_models.Subscription) error {
+	event, err := newSubscriptionEvent(ctx, eventType, subscriptionID, before, after)
+	if err != nil {
+		return err
+	}
+	event.ID = int64(len(memoryRepository.events) + 1)
+	memoryRepository.events = append(memoryRepository.events, event)
+
+	if outboxEvent, ok := newOutboxEvent(event); ok {
+		memoryRepository.outboxSeq++
+		outboxEvent.ID = memoryRepository.outboxSeq
+		memoryRepository.outbox = append(memoryRepository.outbox, outboxEvent)
+	}
+	return nil
+}
+
+func (memoryRepository *InMemorySubscriptionRepository) GetSubscriptionsCost(ctx context.Context, filter json_models.CostFilter, rates *ExchangeRates) (json_models.CostReport, error) {
+	memoryRepository.logger.Debug("Calculating subscriptions cost",
+		zap.Any("filter", filter))
+
+	periodEnd := filter.EndDate
+	windowStart := utils.MonthStart(filter.StartDate)
+	windowEnd := utils.MonthStart(periodEnd).AddDate(0, 1, 0)
+
+	memoryRepository.mu.RLock()
+	var subscriptions []sql_models.Subscription
+	history := newSubscriptionHistory()
+	for _, sub := range memoryRepository.subscriptions {
+		if !sub.StartDate.Before(windowEnd) {
+			continue
+		}
+		if sub.EndDate != nil && sub.EndDate.Before(windowStart) {
+			continue
+		}
+		if sub.DeletedAt != nil && !filter.IncludeDeleted {
+			continue
+		}
+		if filter.UserID != nil && sub.UserID != *filter.UserID {
+			continue
+		}
+		if filter.ServiceName != nil && !strings.EqualFold(sub.ServiceName, *filter.ServiceName) {
+			continue
+		}
+		subscriptions = append(subscriptions, cloneSubscription(sub))
+		history.pauses[sub.ID] = append([]sql_models.SubscriptionPause(nil), memoryRepository.pauses[sub.ID]...)
+		history.prices[sub.ID] = append([]sql_models.SubscriptionPrice(nil), memoryRepository.prices[sub.ID]...)
+	}
+	memoryRepository.mu.RUnlock()
+
+	report, err := calculateCostReport(subscriptions, history, filter.StartDate, periodEnd, filter.Proration, rates)
+	if err != nil {
+		memoryRepository.logger.Warn("Failed to convert subscriptions cost",
+			zap.String("currency", rates.Target()),
+			zap.Error(err))
+		return json_models.CostReport{}, err
+	}
+
+	memoryRepository.logger.Debug("Subscriptions cost calculated",
+		zap.Int("subscriptionsCount", len(subscriptions)),
+		zap.Float64("totalCost", report.TotalCost))
+	return report, nil
+}
+
+// periodsOverlap reports whether two month ranges share at least one month.
+// A nil end means the range is open-ended.
+func periodsOverlap(aStart time.Time, aEnd *time.Time, bStart time.Time, bEnd *time.Time) bool {
+	if aEnd != nil && aEnd.Before(bStart) {
+		return false
+	}
+	if bEnd != nil && bEnd.Before(aStart) {
+		return false
+	}
+	return true
+}
+
+func matchesListFilter(sub sql_models.Subscription, filter json_models.SubscriptionListFilter) bool {
+	if sub.DeletedAt != nil && !filter.IncludeDeleted {
+		return false
+	}
+	if filter.UserID != nil && sub.UserID != *filter.UserID {
+		return false
+	}
+	if filter.ServiceName != nil && !strings.EqualFold(sub.ServiceName, *filter.ServiceName) {
+		return false
+	}
+	if filter.MinPrice != nil && sub.Price < *filter.MinPrice {
+		return false
+	}
+	if filter.MaxPrice != nil && sub.Price > *filter.MaxPrice {
+		return false
+	}
+	if filter.ActiveFrom != nil && filter.ActiveTo != nil {
+		if sub.StartDate.After(*filter.ActiveTo) || (sub.EndDate != nil && sub.EndDate.Before(*filter.ActiveFrom)) {
+			return false
+		}
+	}
+	if filter.StartFrom != nil && sub.StartDate.Before(*filter.StartFrom) {
+		return false
+	}
+	if filter.StartTo != nil && sub.StartDate.After(*filter.StartTo) {
+		return false
+	}
+	if filter.EndFrom != nil && (sub.EndDate == nil || sub.EndDate.Before(*filter.EndFrom)) {
+		return false
+	}
+	if filter.EndTo != nil && (sub.EndDate == nil || sub.EndDate.After(*filter.EndTo)) {
+		return false
+	}
+	if filter.After != nil {
+		boundary := sql_models.Subscription{ID: filter.After.ID, Price: filter.After.Price, StartDate: filter.After.Time, CreatedAt: filter.After.Time}
+		c := compareBySort(sub, boundary, filter.Sort)
+		if (filter.Descending && c >= 0) || (!filter.Descending && c <= 0) {
+			return false
+		}
+	}
+	return true
+}
+
+func cloneSubscription(sub sql_models.Subscription) sql_models.Subscription {
+	sub.EndDate = copyTime(sub.EndDate)
+	sub.DeletedAt = copyTime(sub.DeletedAt)
+	sub.TrialEndDate = copyTime(sub.TrialEndDate)
+	return sub
+}
+
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	value := *t
+	return &value
+}
+
+func sortByCreatedAt(subscriptions []sql_models.Subscription) {
+	sort.Slice(subscriptions, func(i, j int) bool {
+		if subscriptions[i].CreatedAt.Equal(subscriptions[j].CreatedAt) {
+			return subscriptions[i].ID < subscriptions[j].ID
+		}
+		return subscriptions[i].CreatedAt.Before(subscriptions[j].CreatedAt)
+	})
+}
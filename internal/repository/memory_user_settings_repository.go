@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"sync"
+	"taskTestEffectMobile/internal/models/domain_errors"
+	"taskTestEffectMobile/internal/models/sql_models"
+	"time"
+)
+
+// InMemoryUserSettingsRepository mirrors UserSettingsRepository in process memory.
+type InMemoryUserSettingsRepository struct {
+	mu       sync.RWMutex
+	settings map[string]sql_models.UserSettings
+	logger   *zap.Logger
+}
+
+func NewInMemoryUserSettingsRepository(logger *zap.Logger) *InMemoryUserSettingsRepository {
+	return &InMemoryUserSettingsRepository{
+		settings: make(map[string]sql_models.UserSettings),
+		logger:   logger.With(zap.String("layer", "repository"), zap.String("storage", "memory")),
+	}
+}
+
+func (memoryRepository *InMemoryUserSettingsRepository) GetUserSettings(ctx context.Context, userID string) (sql_models.UserSettings, error) {
+	memoryRepository.mu.RLock()
+	defer memoryRepository.mu.RUnlock()
+
+	settings, ok := memoryRepository.settings[userID]
+	if !ok {
+		return sql_models.UserSettings{}, domain_errors.ErrUserSettingsNotFound
+	}
+	return settings, nil
+}
+
+func (memoryRepository *InMemoryUserSettingsRepository) UpsertUserSettings(ctx context.Context, settings sql_models.UserSettings) (sql_models.UserSettings, error) {
+	memoryRepository.logger.Debug("Upserting user settings",
+		zap.String("userID", settings.UserID))
+
+	memoryRepository.mu.Lock()
+	defer memoryRepository.mu.Unlock()
+
+	updatedAt := time.Now()
+	settings.UpdatedAt = &updatedAt
+	memoryRepository.settings[settings.UserID] = settings
+	return settings, nil
+}
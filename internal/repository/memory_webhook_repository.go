@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"sort"
+	"sync"
+	"taskTestEffectMobile/internal/models/domain_errors"
+	"taskTestEffectMobile/internal/models/sql_models"
+	"time"
+)
+
+// InMemoryWebhookRepository mirrors WebhookRepository in process memory.
+type InMemoryWebhookRepository struct {
+	mu         sync.Mutex
+	endpoints  map[string]sql_models.WebhookEndpoint
+	deliveries map[string]sql_models.WebhookDelivery
+	logger     *zap.Logger
+}
+
+func NewInMemoryWebhookRepository(logger *zap.Logger) *InMemoryWebhookRepository {
+	return &InMemoryWebhookRepository{
+		endpoints:  make(map[string]sql_models.WebhookEndpoint),
+		deliveries: make(map[string]sql_models.WebhookDelivery),
+		logger:     logger.With(zap.String("layer", "repository"), zap.String("storage", "memory")),
+	}
+}
+
+func (memoryRepository *InMemoryWebhookRepository) CreateWebhookEndpoint(ctx context.Context, endpoint sql_models.WebhookEndpoint) (sql_models.WebhookEndpoint, error) {
+	memoryRepository.logger.Debug("Creating webhook endpoint",
+		zap.String("url", endpoint.URL))
+
+	memoryRepository.mu.Lock()
+	defer memoryRepository.mu.Unlock()
+
+	endpoint.ID = uuid.NewString()
+	endpoint.EventTypes = append([]string{}, endpoint.EventTypes...)
+	endpoint.CreatedAt = time.Now()
+	memoryRepository.endpoints[endpoint.ID] = endpoint
+	return copyWebhookEndpoint(endpoint), nil
+}
+
+func (memoryRepository *InMemoryWebhookRepository) ListWebhookEndpoints(ctx context.Context) ([]sql_models.WebhookEndpoint, error) {
+	memoryRepository.mu.Lock()
+	defer memoryRepository.mu.Unlock()
+
+	var endpoints []sql_models.WebhookEndpoint
+	for _, endpoint := range memoryRepository.endpoints {
+		endpoints = append(endpoints, copyWebhookEndpoint(endpoint))
+	}
+	sort.Slice(endpoints, func(i, j int) bool {
+		return endpoints[i].CreatedAt.Before(endpoints[j].CreatedAt)
+	})
+	return endpoints, nil
+}
+
+func (memoryRepository *InMemoryWebhookRepository) GetWebhookEndpoint(ctx context.Context, endpointID string) (sql_models.WebhookEndpoint, error) {
+	memoryRepository.mu.Lock()
+	defer memoryRepository.mu.Unlock()
+
+	endpoint, ok := memoryRepository.endpoints[endpointID]
+	if !ok {
+		return sql_models.WebhookEndpoint{}, domain_errors.ErrWebhookEndpointNotFound
+	}
+	return copyWebhookEndpoint(endpoint), nil
+}
+
+func (memoryRepository *InMemoryWebhookRepository) DeleteWebhookEndpoint(ctx context.Context, endpointID string) error {
+	memoryRepository.logger.Debug("Deleting webhook endpoint",
+		zap.String("endpointID", endpointID))
+
+	memoryRepository.mu.Lock()
+	defer memoryRepository.mu.Unlock()
+
+	if _, ok := memoryRepository.endpoints[endpointID]; !ok {
+		return domain_errors.ErrWebhookEndpointNotFound
+	}
+	delete(memoryRepository.endpoints, endpointID)
+	for id, delivery := range memoryRepository.deliveries {
+		if delivery.EndpointID == endpointID {
+			delete(memoryRepository.deliveries, id)
+		}
+	}
+	return nil
+}
+
+func (memoryRepository *InMemoryWebhookRepository) EnqueueWebhookDeliveries(ctx context.Context, deliveries []sql_models.WebhookDelivery) error {
+	memoryRepository.logger.Debug("Enqueuing webhook deliveries",
+		zap.Int("count", len(deliveries)))
+
+	memoryRepository.mu.Lock()
+	defer memoryRepository.mu.Unlock()
+
+	now := time.Now()
+	for _, delivery := range deliveries {
+		if memoryRepository.hasDelivery(delivery.EndpointID, delivery.EventID) {
+			continue
+		}
+		delivery.ID = uuid.NewString()
+		delivery.Status = sql_models.DeliveryPending
+		delivery.Payload = append([]byte(nil), delivery.Payload...)
+		delivery.CreatedAt = now
+		memoryRepository.deliveries[delivery.ID] = copyWebhookDelivery(delivery)
+	}
+	return nil
+}
+
+func (memoryRepository *InMemoryWebhookRepository) hasDelivery(endpointID, eventID string) bool {
+	for _, delivery := range memoryRepository.deliveries {
+		if delivery.EndpointID == endpointID && delivery.EventID == eventID {
+			return true
+		}
+	}
+	return false
+}
+
+func (memoryRepository *InMemoryWebhookRepository) ClaimWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]sql_models.WebhookDelivery, error) {
+	memoryRepository.mu.Lock()
+	defer memoryRepository.mu.Unlock()
+
+	var due []sql_models.WebhookDelivery
+	for _, delivery := range memoryRepository.deliveries {
+		if delivery.Status == sql_models.DeliveryPending && delivery.NextAttemptAt != nil && !delivery.NextAttemptAt.After(now) {
+			due = append(due, delivery)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].NextAttemptAt.Before(*due[j].NextAttemptAt)
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	leaseEnd := now.Add(lease)
+	claimed := make([]sql_models.WebhookDelivery, 0, len(due))
+	for _, delivery := range due {
+		delivery.NextAttemptAt = &leaseEnd
+		memoryRepository.deliveries[delivery.ID] = delivery
+		claimed = append(claimed, copyWebhookDelivery(delivery))
+	}
+	return claimed, nil
+}
+
+func (memoryRepository *InMemoryWebhookRepository) UpdateWebhookDelivery(ctx context.Context, delivery sql_models.WebhookDelivery) error {
+	memoryRepository.mu.Lock()
+	defer memoryRepository.mu.Unlock()
+
+	stored, ok := memoryRepository.deliveries[delivery.ID]
+	if !ok {
+		return domain_errors.ErrWebhookDeliveryNotFound
+	}
+	stored.Status = delivery.Status
+	stored.Attempts = delivery.Attempts
+	stored.NextAttemptAt = delivery.NextAttemptAt
+	stored.LastStatusCode = delivery.LastStatusCode
+	stored.LastError = delivery.LastError
+	stored.DeliveredAt = delivery.DeliveredAt
+	memoryRepository.deliveries[delivery.ID] = copyWebhookDelivery(stored)
+	return nil
+}
+
+func (memoryRepository *InMemoryWebhookRepository) ListWebhookDeliveries(ctx context.Context, endpointID string) ([]sql_models.WebhookDelivery, error) {
+	memoryRepository.mu.Lock()
+	defer memoryRepository.mu.Unlock()
+
+	var deliveries []sql_models.WebhookDelivery
+	for _, delivery := range memoryRepository.deliveries {
+		if delivery.EndpointID == endpointID {
+			deliveries = append(deliveries, copyWebhookDelivery(delivery))
+		}
+	}
+	sort.Slice(deliveries, func(i, j int) bool {
+		return deliveries[i].CreatedAt.After(deliveries[j].CreatedAt)
+	})
+	return deliveries, nil
+}
+
+func (memoryRepository *InMemoryWebhookRepository) GetWebhookDelivery(ctx context.Context, endpointID, deliveryID string) (sql_models.WebhookDelivery, error) {
+	memoryRepository.mu.Lock()
+	defer memoryRepository.mu.Unlock()
+
+	delivery, ok := memoryRepository.deliveries[deliveryID]
+	if !ok || delivery.EndpointID != endpointID {
+		return sql_models.WebhookDelivery{}, domain_errors.ErrWebhookDeliveryNotFound
+	}
+	return copyWebhookDelivery(delivery), nil
+}
+
+func copyWebhookEndpoint(endpoint sql_models.WebhookEndpoint) sql_models.WebhookEndpoint {
+	endpoint.EventTypes = append([]string{}, endpoint.EventTypes...)
+	return endpoint
+}
+
+// copyWebhookDelivery detaches the pointer fields so callers cannot change
+// the stored delivery.
+func copyWebhookDelivery(delivery sql_models.WebhookDelivery) sql_models.WebhookDelivery {
+	delivery.NextAttemptAt = copyTime(delivery.NextAttemptAt)
+	delivery.DeliveredAt = copyTime(delivery.DeliveredAt)
+	delivery.LastStatusCode = copyInt(delivery.LastStatusCode)
+	delivery.LastError = copyString(delivery.LastError)
+	return delivery
+}
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+	"taskTestEffectMobile/internal/models/sql_models"
+	"time"
+)
+
+const outboxEventColumns = `id, event_id, event_type, subscription_id, payload, created_at, published_at`
+
+// The outbox lock orders the events: writers hold it shared while they
+// insert events and the relay holds it exclusively.
+const (
+	outboxLockQuery       = `SELECT pg_advisory_xact_lock(hashtext('outbox_events'))`
+	outboxSharedLockQuery = `SELECT pg_advisory_xact_lock_shared(hashtext('outbox_events'))`
+)
+
+// RelayOutboxEvents passes up to limit unpublished events to publish in
+// ascending id order and marks the ones it accepted as published. It stops at
+// the first error, which is returned after the accepted events are saved, so
+// the failed event is retried first on the next call.
+//
+// Events are delivered in id order across all instances. Only one relay runs
+// at a time, and it waits for the transactions still writing events, so no
+// event with a smaller id can commit after a larger one was published. The
+// writers wait for the relay in turn, so publish must be quick: the webhook
+// publisher only queues deliveries and the HTTP calls happen in the dispatcher.
+func (subscriptionRepository SubscriptionRepository) RelayOutboxEvents(ctx context.Context, limit int, publish func(event sql_models.OutboxEvent) error) (int, error) {
+	var published []int64
+	var publishErr error
+
+	err := runInTx(ctx, subscriptionRepository.db, subscriptionRepository.logger, func(tx *sql.Tx) error {
+		query := outboxLockQuery
+		if _, err := tx.ExecContext(ctx, query); err != nil {
+			subscriptionRepository.logger.Error("Failed to lock outbox",
+				zap.String("query", query),
+				zap.Error(err))
+			return fmt.Errorf("failed to lock outbox: %w", err)
+		}
+
+		query = `
+			SELECT ` + outboxEventColumns + `
+			FROM outbox_events
+			WHERE published_at IS NULL
+			ORDER BY id
+			LIMIT $1
+		`
+		rows, err := tx.QueryContext(ctx, query, limit)
+		if err != nil {
+			subscriptionRepository.logger.Error("Failed to load outbox events",
+				zap.String("query", query),
+				zap.Error(err))
+			return fmt.Errorf("failed to load outbox events: %w", err)
+		}
+		var events []sql_models.OutboxEvent
+		for rows.Next() {
+			event, err := scanOutboxEvent(rows)
+			if err != nil {
+				_ = rows.Close()
+				return fmt.Errorf("failed to scan outbox event: %w", err)
+			}
+			events = append(events, event)
+		}
+		if err := rows.Close(); err != nil {
+			return fmt.Errorf("failed to load outbox events: %w", err)
+		}
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("failed to load outbox events: %w", err)
+		}
+
+		for _, event := range events {
+			if publishErr = publish(event); publishErr != nil {
+				break
+			}
+			published = append(published, event.ID)
+		}
+		if len(published) == 0 {
+			return nil
+		}
+
+		query = `UPDATE outbox_events SET published_at = NOW() WHERE id = ANY($1)`
+		if _, err := tx.ExecContext(ctx, query, pq.Array(published)); err != nil {
+			subscriptionRepository.logger.Error("Failed to mark outbox events published",
+				zap.String("query", query),
+				zap.Error(err))
+			return fmt.Errorf("failed to mark outbox events published: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(published), publishErr
+}
+
+// DeletePublishedOutboxEvents removes the events published before the given time.
+func (subscriptionRepository SubscriptionRepository) DeletePublishedOutboxEvents(ctx context.Context, publishedBefore time.Time) (int64, error) {
+	query := `DELETE FROM outbox_events WHERE published_at < $1`
+	result, err := subscriptionRepository.db.ExecContext(ctx, query, publishedBefore)
+	if err != nil {
+		subscriptionRepository.logger.Error("Failed to delete published outbox events",
+			zap.String("query", query),
+			zap.Error(err))
+		return 0, fmt.Errorf("failed to delete published outbox events: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete published outbox events: %w", err)
+	}
+	return deleted, nil
+}
+
+func scanOutboxEvent(row rowScanner) (sql_models.OutboxEvent, error) {
+	var event sql_models.OutboxEvent
+	var payload []byte
+	var publishedAt sql.NullTime
+
+	if err := row.Scan(
+		&event.ID,
+		&event.EventID,
+		&event.EventType,
+		&event.SubscriptionID,
+		&payload,
+		&event.CreatedAt,
+		&publishedAt,
+	); err != nil {
+		return sql_models.OutboxEvent{}, err
+	}
+
+	event.Payload = payload
+	if publishedAt.Valid {
+		event.PublishedAt = &publishedAt.Time
+	}
+	return event, nil
+}
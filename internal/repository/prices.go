@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"sort"
+	"taskTestEffectMobile/internal/models/sql_models"
+	"taskTestEffectMobile/internal/utils"
+	"time"
+)
+
+// subscriptionHistory holds the effective-dated records of subscriptions
+// that the cost report needs, keyed by subscription ID.
+type subscriptionHistory struct {
+	pauses map[string][]sql_models.SubscriptionPause
+	prices map[string][]sql_models.SubscriptionPrice
+}
+
+func newSubscriptionHistory() subscriptionHistory {
+	return subscriptionHistory{
+		pauses: make(map[string][]sql_models.SubscriptionPause),
+		prices: make(map[string][]sql_models.SubscriptionPrice),
+	}
+}
+
+// priceAt returns the price in effect in month. Prices must be sorted by
+// EffectiveFrom. Months before the first record use the first price, and a
+// subscription without records falls back to its stored price.
+func priceAt(sub sql_models.Subscription, prices []sql_models.SubscriptionPrice, month time.Time) int {
+	if len(prices) == 0 {
+		return sub.Price
+	}
+	i := sort.Search(len(prices), func(i int) bool {
+		return prices[i].EffectiveFrom.After(month)
+	})
+	if i == 0 {
+		return prices[0].Price
+	}
+	return prices[i-1].Price
+}
+
+// currentPrice returns the price to store on the subscription row: the one in
+// effect in month, so a change scheduled for a later month leaves it as is.
+// Without a month the latest price is used.
+func currentPrice(sub sql_models.Subscription, prices []sql_models.SubscriptionPrice, month time.Time) int {
+	if month.IsZero() && len(prices) > 0 {
+		return prices[len(prices)-1].Price
+	}
+	return priceAt(sub, prices, utils.MonthStart(month))
+}
+
+// applyPriceChange sets price from the month of from onward: later records
+// are dropped and a record for that month is added or replaced. It reports
+// whether the history changed.
+func applyPriceChange(subscriptionID string, prices []sql_models.SubscriptionPrice, price int, from time.Time) ([]sql_models.SubscriptionPrice, bool) {
+	from = utils.MonthStart(from)
+
+	kept := make([]sql_models.SubscriptionPrice, 0, len(prices)+1)
+	for _, record := range prices {
+		if record.EffectiveFrom.Before(from) {
+			kept = append(kept, record)
+		}
+	}
+	if len(kept) == len(prices) && len(prices) > 0 && prices[len(prices)-1].Price == price {
+		return prices, false
+	}
+
+	kept = append(kept, sql_models.SubscriptionPrice{SubscriptionID: subscriptionID, EffectiveFrom: from, Price: price})
+	return kept, true
+}
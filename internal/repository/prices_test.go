@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"taskTestEffectMobile/internal/models/sql_models"
+	"testing"
+	"time"
+)
+
+func TestPriceAt(t *testing.T) {
+	sub := sql_models.Subscription{Price: 300}
+	prices := []sql_models.SubscriptionPrice{
+		{EffectiveFrom: date(2025, time.January, 1), Price: 100},
+		{EffectiveFrom: date(2025, time.April, 1), Price: 200},
+	}
+
+	tests := []struct {
+		name   string
+		prices []sql_models.SubscriptionPrice
+		month  time.Time
+		want   int
+	}{
+		{name: "no records use the stored price", month: date(2025, time.March, 1), want: 300},
+		{name: "month before the first record", prices: prices, month: date(2024, time.December, 1), want: 100},
+		{name: "month of the first record", prices: prices, month: date(2025, time.January, 1), want: 100},
+		{name: "month between records", prices: prices, month: date(2025, time.March, 1), want: 100},
+		{name: "month of a change", prices: prices, month: date(2025, time.April, 1), want: 200},
+		{name: "month after the last record", prices: prices, month: date(2026, time.January, 1), want: 200},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := priceAt(sub, tt.prices, tt.month); got != tt.want {
+				t.Errorf("priceAt() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCurrentPrice(t *testing.T) {
+	sub := sql_models.Subscription{Price: 300}
+	prices := []sql_models.SubscriptionPrice{
+		{EffectiveFrom: date(2025, time.January, 1), Price: 100},
+		{EffectiveFrom: date(2027, time.January, 1), Price: 200},
+	}
+
+	tests := []struct {
+		name   string
+		prices []sql_models.SubscriptionPrice
+		month  time.Time
+		want   int
+	}{
+		{name: "future change keeps the current price", prices: prices, month: date(2025, time.June, 15), want: 100},
+		{name: "change takes effect in its month", prices: prices, month: date(2027, time.January, 20), want: 200},
+		{name: "no month uses the latest price", prices: prices, want: 200},
+		{name: "no records use the stored price", month: date(2025, time.June, 1), want: 300},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := currentPrice(sub, tt.prices, tt.month); got != tt.want {
+				t.Errorf("currentPrice() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
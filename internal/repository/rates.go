@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"math"
+	"sort"
+	"taskTestEffectMobile/internal/models/domain_errors"
+	"taskTestEffectMobile/internal/models/json_models"
+	"taskTestEffectMobile/internal/models/sql_models"
+	"taskTestEffectMobile/internal/utils"
+	"time"
+)
+
+type rateKey struct {
+	month    time.Time
+	currency string
+}
+
+// ExchangeRates converts amounts into the report currency using the rate in
+// effect in each month and remembers every rate it applied.
+// Rates are quoted in the base currency, whose own rate is always 1.
+type ExchangeRates struct {
+	base   string
+	target string
+	rates  map[string][]sql_models.ExchangeRate
+	used   map[rateKey]float64
+}
+
+func NewExchangeRates(base, target string, rates []sql_models.ExchangeRate) *ExchangeRates {
+	byCurrency := make(map[string][]sql_models.ExchangeRate)
+	for _, rate := range rates {
+		byCurrency[rate.Currency] = append(byCurrency[rate.Currency], rate)
+	}
+	for _, list := range byCurrency {
+		sort.Slice(list, func(i, j int) bool {
+			return list[i].ValidFrom.Before(list[j].ValidFrom)
+		})
+	}
+
+	return &ExchangeRates{
+		base:   base,
+		target: target,
+		rates:  byCurrency,
+		used:   make(map[rateKey]float64),
+	}
+}
+
+// Target returns the currency amounts are converted into.
+func (exchangeRates *ExchangeRates) Target() string {
+	return exchangeRates.target
+}
+
+// Convert turns amount in currency into the target currency at the rates
+// valid in month. An empty currency is treated as the base currency.
+func (exchangeRates *ExchangeRates) Convert(amount float64, currency string, month time.Time) (float64, error) {
+	if currency == "" {
+		currency = exchangeRates.base
+	}
+	if currency == exchangeRates.target {
+		return amount, nil
+	}
+
+	month = utils.MonthStart(month)
+	key := rateKey{month: month, currency: currency}
+	if factor, ok := exchangeRates.used[key]; ok {
+		return amount * factor, nil
+	}
+
+	from, err := exchangeRates.rateAt(currency, month)
+	if err != nil {
+		return 0, err
+	}
+	to, err := exchangeRates.rateAt(exchangeRates.target, month)
+	if err != nil {
+		return 0, err
+	}
+
+	factor := from / to
+	exchangeRates.used[key] = factor
+	return amount * factor, nil
+}
+
+// RatesUsed lists the conversion factors applied so far, ordered by month and currency.
+func (exchangeRates *ExchangeRates) RatesUsed() []json_models.RateUsed {
+	keys := make([]rateKey, 0, len(exchangeRates.used))
+	for key := range exchangeRates.used {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].month.Equal(keys[j].month) {
+			return keys[i].currency < keys[j].currency
+		}
+		return keys[i].month.Before(keys[j].month)
+	})
+
+	used := make([]json_models.RateUsed, 0, len(keys))
+	for _, key := range keys {
+		used = append(used, json_models.RateUsed{
+			Month: key.month.Format(utils.MonthLayout),
+			From:  key.currency,
+			To:    exchangeRates.target,
+			Rate:  exchangeRates.used[key],
+		})
+	}
+	return used
+}
+
+// rateAt returns the latest rate of currency that became valid in or before month.
+func (exchangeRates *ExchangeRates) rateAt(currency string, month time.Time) (float64, error) {
+	if currency == exchangeRates.base {
+		return 1, nil
+	}
+
+	list := exchangeRates.rates[currency]
+	i := sort.Search(len(list), func(i int) bool {
+		return list[i].ValidFrom.After(month)
+	})
+	if i == 0 {
+		return 0, domain_errors.WithDetail(domain_errors.ErrMissingExchangeRate, "no %s rate valid in %s", currency, month.Format(utils.MonthLayout))
+	}
+	return list[i-1].Rate, nil
+}
+
+// roundMoney rounds an amount to two decimal places.
+func roundMoney(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
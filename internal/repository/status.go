@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"taskTestEffectMobile/internal/models/domain_errors"
+	"taskTestEffectMobile/internal/models/json_models"
+	"taskTestEffectMobile/internal/models/sql_models"
+	"taskTestEffectMobile/internal/utils"
+	"time"
+)
+
+// transitionPlan describes how a status change is stored. Pauses are kept in
+// month ranges so past periods keep excluding the months that were paused.
+type transitionPlan struct {
+	after sql_models.Subscription
+	// pauseFrom opens a pause starting with this month.
+	pauseFrom *time.Time
+	// pauseUntil closes the open pause with this month as its last one.
+	pauseUntil *time.Time
+	eventType  string
+}
+
+// planTransition checks that the subscription may move to target in the month
+// of now and returns the resulting state. Allowed moves are trial -> active,
+// active <-> paused and any status except cancelled -> cancelled.
+// A pause takes effect from the next month; the current month is already paid.
+func planTransition(sub sql_models.Subscription, target string, now time.Time) (transitionPlan, error) {
+	month := utils.MonthStart(now)
+	current := sub.StatusAt(now)
+	plan := transitionPlan{after: cloneSubscription(sub)}
+	plan.after.Status = target
+
+	switch {
+	case target == sql_models.StatusPaused && current == sql_models.StatusActive:
+		from := month.AddDate(0, 1, 0)
+		plan.pauseFrom = &from
+		plan.eventType = sql_models.EventPaused
+	case target == sql_models.StatusActive && current == sql_models.StatusPaused:
+		until := month.AddDate(0, -1, 0)
+		plan.pauseUntil = &until
+		plan.eventType = sql_models.EventResumed
+	case target == sql_models.StatusActive && current == sql_models.StatusTrial:
+		// Ending a trial early makes the current month the first paid one.
+		plan.after.TrialEndDate = nil
+		if trialEnd := month.AddDate(0, -1, 0); !trialEnd.Before(utils.MonthStart(sub.StartDate)) {
+			plan.after.TrialEndDate = &trialEnd
+		}
+		plan.eventType = sql_models.EventResumed
+	case target == sql_models.StatusCancelled && current != sql_models.StatusCancelled:
+		if sub.StartDate.After(month) {
+			return transitionPlan{}, domain_errors.WithDetail(domain_errors.ErrInvalidTransition, "subscription has not started yet, delete it instead")
+		}
+		if monthEnd := utils.MonthEnd(month); sub.EndDate == nil || sub.EndDate.After(monthEnd) {
+			plan.after.EndDate = &monthEnd
+		}
+		if current == sql_models.StatusPaused {
+			plan.pauseUntil = &month
+		}
+		plan.eventType = sql_models.EventCancelled
+	default:
+		return transitionPlan{}, domain_errors.WithDetail(domain_errors.ErrInvalidTransition, "cannot change status from %s to %s", current, target)
+	}
+	return plan, nil
+}
+
+// billable reports whether the subscription is charged for month: trial
+// months and paused months are free.
+func billable(sub sql_models.Subscription, pauses []sql_models.SubscriptionPause, month time.Time) bool {
+	if sub.InTrial(month) {
+		return false
+	}
+	for _, pause := range pauses {
+		if pause.Covers(month) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkUpdatedDates validates the dates the subscription will have once update
+// is applied on top of the locked row: the end must not precede the start, and
+// a trial must still end within the subscription.
+func checkUpdatedDates(before sql_models.Subscription, update json_models.SubscriptionUpdate) error {
+	startDate, endDate := before.StartDate, before.EndDate
+	if update.StartDate != nil {
+		startDate = *update.StartDate
+	}
+	if update.ClearEndDate {
+		endDate = nil
+	} else if update.EndDate != nil {
+		endDate = update.EndDate
+	}
+
+	if endDate != nil && endDate.Before(startDate) {
+		return domain_errors.WithDetail(domain_errors.ErrInvalidDateRange, "end date %s is before start date %s", endDate.Format(utils.DayLayout), startDate.Format(utils.DayLayout))
+	}
+	if trialEnd := before.TrialEndDate; trialEnd != nil {
+		if trialEnd.Before(utils.MonthStart(startDate)) || (endDate != nil && trialEnd.After(*endDate)) {
+			return domain_errors.WithDetail(domain_errors.ErrValidation, "trial end date must be between start and end dates")
+		}
+	}
+	return nil
+}
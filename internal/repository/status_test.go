@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"errors"
+	"taskTestEffectMobile/internal/models/domain_errors"
+	"taskTestEffectMobile/internal/models/json_models"
+	"taskTestEffectMobile/internal/models/sql_models"
+	"testing"
+	"time"
+)
+
+func TestCheckUpdatedDates(t *testing.T) {
+	storedEnd := date(2025, time.June, 30)
+	trialEnd := date(2025, time.March, 31)
+	earlyEnd := date(2024, time.December, 31)
+	beforeTrialEnd := date(2025, time.February, 28)
+	lateStart := date(2025, time.April, 1)
+	afterEnd := date(2025, time.August, 1)
+	stored := sql_models.Subscription{StartDate: date(2025, time.January, 1), EndDate: &storedEnd}
+	trial := sql_models.Subscription{StartDate: date(2025, time.January, 1), TrialEndDate: &trialEnd}
+
+	tests := []struct {
+		name    string
+		before  sql_models.Subscription
+		update  json_models.SubscriptionUpdate
+		wantErr error
+	}{
+		{name: "unchanged dates", before: stored},
+		{name: "cleared end date", before: stored, update: json_models.SubscriptionUpdate{ClearEndDate: true}},
+		{name: "end before the stored start", before: stored, update: json_models.SubscriptionUpdate{EndDate: &earlyEnd}, wantErr: domain_errors.ErrInvalidDateRange},
+		{name: "start after the stored end", before: stored, update: json_models.SubscriptionUpdate{StartDate: &afterEnd}, wantErr: domain_errors.ErrInvalidDateRange},
+		{name: "end before the trial end", before: trial, update: json_models.SubscriptionUpdate{EndDate: &beforeTrialEnd}, wantErr: domain_errors.ErrValidation},
+		{name: "start after the trial end", before: trial, update: json_models.SubscriptionUpdate{StartDate: &lateStart}, wantErr: domain_errors.ErrValidation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkUpdatedDates(tt.before, tt.update)
+			if (tt.wantErr == nil && err != nil) || (tt.wantErr != nil && !errors.Is(err, tt.wantErr)) {
+				t.Errorf("checkUpdatedDates() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
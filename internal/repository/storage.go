@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"taskTestEffectMobile/internal/models/json_models"
+	"taskTestEffectMobile/internal/models/sql_models"
+	"time"
+)
+
+// SubscriptionStorage is implemented by every subscription store the service can work with.
+type SubscriptionStorage interface {
+	InsertSubscription(ctx context.Context, sub sql_models.Subscription, allowOverlap bool) (string, error)
+	GetSubscription(ctx context.Context, subscriptionUUID uuid.UUID, includeDeleted bool) (sql_models.Subscription, error)
+	GetSubscriptions(ctx context.Context, userID uuid.UUID) ([]sql_models.Subscription, error)
+	ListSubscriptions(ctx context.Context, filter json_models.SubscriptionListFilter) ([]sql_models.Subscription, error)
+	UpdateSubscription(ctx context.Context, subscriptionID string, data json_models.SubscriptionUpdate) (sql_models.Subscription, error)
+	DeleteSubscription(ctx context.Context, subscriptionUUID uuid.UUID, expectedVersion *int) (sql_models.Subscription, error)
+	TransitionSubscription(ctx context.Context, subscriptionUUID uuid.UUID, target string, now time.Time, expectedVersion *int) (sql_models.Subscription, error)
+	RestoreSubscription(ctx context.Context, subscriptionUUID uuid.UUID) (sql_models.Subscription, error)
+	PurgeDeletedSubscriptions(ctx context.Context, deletedBefore time.Time) (int64, error)
+	GetUpcomingCharges(ctx context.Context, filter json_models.UpcomingChargesFilter, rates *ExchangeRates) (json_models.UpcomingCharges, error)
+	GetSubscriptionPrices(ctx context.Context, subscriptionUUID uuid.UUID) ([]sql_models.SubscriptionPrice, error)
+	GetSubscriptionHistory(ctx context.Context, subscriptionUUID uuid.UUID) ([]sql_models.SubscriptionEvent, error)
+	GetSubscriptionsCost(ctx context.Context, filter json_models.CostFilter, rates *ExchangeRates) (json_models.CostReport, error)
+}
+
+// ExchangeRateStorage keeps currency rates quoted in the base currency.
+type ExchangeRateStorage interface {
+	UpsertExchangeRates(ctx context.Context, rates []sql_models.ExchangeRate) error
+	ListExchangeRates(ctx context.Context) ([]sql_models.ExchangeRate, error)
+}
+
+// UserSettingsStorage keeps per-user preferences such as the time zone.
+type UserSettingsStorage interface {
+	GetUserSettings(ctx context.Context, userID string) (sql_models.UserSettings, error)
+	UpsertUserSettings(ctx context.Context, settings sql_models.UserSettings) (sql_models.UserSettings, error)
+}
+
+// CalendarTokenStorage keeps the secret tokens of the users' calendar feeds.
+type CalendarTokenStorage interface {
+	SaveCalendarToken(ctx context.Context, token sql_models.CalendarToken) error
+	GetCalendarToken(ctx context.Context, tokenHash string) (sql_models.CalendarToken, error)
+	DeleteCalendarToken(ctx context.Context, userID string) error
+}
+
+// BudgetStorage keeps the monthly budgets of the users and the alerts raised
+// when their spend crosses a threshold.
+type BudgetStorage interface {
+	UpsertBudget(ctx context.Context, budget sql_models.Budget) (sql_models.Budget, error)
+	ListBudgets(ctx context.Context, userID string) ([]sql_models.Budget, error)
+	ListBudgetUserIDs(ctx context.Context) ([]string, error)
+	DeleteBudget(ctx context.Context, userID, budgetID string) error
+	RecordBudgetAlert(ctx context.Context, alert sql_models.BudgetAlert) (bool, error)
+	ListBudgetAlerts(ctx context.Context, userID string) ([]sql_models.BudgetAlert, error)
+}
+
+// WebhookStorage keeps the registered webhook endpoints and the queue and log
+// of deliveries to them.
+type WebhookStorage interface {
+	CreateWebhookEndpoint(ctx context.Context, endpoint sql_models.WebhookEndpoint) (sql_models.WebhookEndpoint, error)
+	ListWebhookEndpoints(ctx context.Context) ([]sql_models.WebhookEndpoint, error)
+	GetWebhookEndpoint(ctx context.Context, endpointID string) (sql_models.WebhookEndpoint, error)
+	DeleteWebhookEndpoint(ctx context.Context, endpointID string) error
+	EnqueueWebhookDeliveries(ctx context.Context, deliveries []sql_models.WebhookDelivery) error
+	ClaimWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]sql_models.WebhookDelivery, error)
+	UpdateWebhookDelivery(ctx context.Context, delivery sql_models.WebhookDelivery) error
+	ListWebhookDeliveries(ctx context.Context, endpointID string) ([]sql_models.WebhookDelivery, error)
+	GetWebhookDelivery(ctx context.Context, endpointID, deliveryID string) (sql_models.WebhookDelivery, error)
+}
+
+// IdempotencyStorage keeps responses of requests sent with an Idempotency-Key.
+type IdempotencyStorage interface {
+	Reserve(ctx context.Context, key, requestHash string, expiresAt time.Time) (sql_models.IdempotencyRecord, bool, error)
+	Complete(ctx context.Context, key string, statusCode int, contentType string, body []byte) error
+	Release(ctx context.Context, key string) error
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// OutboxStorage reads the outbox the subscription storage writes together
+// with every change. It is implemented by the subscription repositories.
+type OutboxStorage interface {
+	RelayOutboxEvents(ctx context.Context, limit int, publish func(event sql_models.OutboxEvent) error) (int, error)
+	DeletePublishedOutboxEvents(ctx context.Context, publishedBefore time.Time) (int64, error)
+}
+
+var (
+	_ SubscriptionStorage  = SubscriptionRepository{}
+	_ SubscriptionStorage  = (*InMemorySubscriptionRepository)(nil)
+	_ IdempotencyStorage   = IdempotencyRepository{}
+	_ IdempotencyStorage   = (*InMemoryIdempotencyRepository)(nil)
+	_ ExchangeRateStorage  = ExchangeRateRepository{}
+	_ ExchangeRateStorage  = (*InMemoryExchangeRateRepository)(nil)
+	_ UserSettingsStorage  = UserSettingsRepository{}
+	_ UserSettingsStorage  = (*InMemoryUserSettingsRepository)(nil)
+	_ CalendarTokenStorage = CalendarTokenRepository{}
+	_ CalendarTokenStorage = (*InMemoryCalendarTokenRepository)(nil)
+	_ BudgetStorage        = BudgetRepository{}
+	_ BudgetStorage        = (*InMemoryBudgetRepository)(nil)
+	_ WebhookStorage       = WebhookRepository{}
+	_ WebhookStorage       = (*InMemoryWebhookRepository)(nil)
+	_ OutboxStorage        = SubscriptionRepository{}
+	_ OutboxStorage        = (*InMemorySubscriptionRepository)(nil)
+)
@@ -3,14 +3,21 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"go.uber.org/zap"
+	"taskTestEffectMobile/internal/models/domain_errors"
 	"taskTestEffectMobile/internal/models/json_models"
 	"taskTestEffectMobile/internal/models/sql_models"
+	"taskTestEffectMobile/internal/utils"
 	"time"
 )
 
+// subscriptionColumns is the column list read by scanSubscription.
+const subscriptionColumns = "id, service_name, price, user_id, start_date, end_date, created_at, version, deleted_at, status, trial_end_date, billing_interval, billing_interval_count, currency, billing_anchor_day"
+
 type SubscriptionRepository struct {
 	db     *sql.DB
 	logger *zap.Logger
@@ -23,35 +30,102 @@ func NewSubscriptionRepository(db *sql.DB, logger *zap.Logger) *SubscriptionRepo
 	}
 }
 
-func (subscriptionRepository SubscriptionRepository) InsertSubscription(ctx context.Context, serviceName string, price int, userID string, startDate time.Time, endTime *time.Time) (string, error) {
+// InsertSubscription stores a new subscription. Unless allowOverlap is set it
+// fails with a DuplicateSubscriptionError when the user already has the same
+// service in an overlapping period.
+func (subscriptionRepository SubscriptionRepository) InsertSubscription(ctx context.Context, sub sql_models.Subscription, allowOverlap bool) (string, error) {
 	subscriptionRepository.logger.Debug("Inserting new subscription",
-		zap.String("userID", userID),
-		zap.String("service", serviceName))
+		zap.String("userID", sub.UserID),
+		zap.String("service", sub.ServiceName),
+		zap.Bool("allowOverlap", allowOverlap))
+
+	sub.ID = uuid.New().String()
+	sub.CreatedAt = time.Now()
+	sub.Version = 1
+
+	err := runInTx(ctx, subscriptionRepository.db, subscriptionRepository.logger, func(tx *sql.Tx) error {
+		if !allowOverlap {
+			if err := subscriptionRepository.checkOverlap(ctx, tx, sub); err != nil {
+				return err
+			}
+		}
+
+		query := `INSERT INTO subscriptions (id, service_name, price, user_id, start_date, end_date, created_at, version, status, trial_end_date, billing_interval, billing_interval_count, currency, billing_anchor_day) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`
+
+		_, err := tx.ExecContext(ctx, query, sub.ID, sub.ServiceName, sub.Price, sub.UserID, sub.StartDate, sub.EndDate, sub.CreatedAt, sub.Version, sub.Status, sub.TrialEndDate, sub.BillingInterval, sub.BillingIntervalCount, sub.Currency, sub.BillingAnchorDay)
+		if err != nil {
+			subscriptionRepository.logger.Error("Failed to insert subscription",
+				zap.String("query", query),
+				zap.String("userID", sub.UserID),
+				zap.String("service", sub.ServiceName),
+				zap.Error(err))
+			return fmt.Errorf("failed to insert subscription: %w", mapDatabaseError(err))
+		}
 
-	id := uuid.New().String()
-	query := `INSERT INTO subscriptions (id, service_name, price, user_id, start_date, end_date, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`
+		initialPrice := []sql_models.SubscriptionPrice{{SubscriptionID: sub.ID, EffectiveFrom: utils.MonthStart(sub.StartDate), Price: sub.Price}}
+		if err := replacePrices(ctx, tx, sub.ID, initialPrice); err != nil {
+			return err
+		}
 
-	_, err := subscriptionRepository.db.ExecContext(ctx, query, id, serviceName, price, userID, startDate, endTime, time.Now())
+		return insertSubscriptionEvent(ctx, tx, sql_models.EventCreated, sub.ID, nil, &sub)
+	})
 	if err != nil {
-		subscriptionRepository.logger.Error("Failed to insert subscription",
-			zap.String("query", query),
-			zap.String("userID", userID),
-			zap.String("service", serviceName),
-			zap.Error(err))
-		return "", fmt.Errorf("failed to insert subscription: %w", err)
+		return "", err
 	}
 
 	subscriptionRepository.logger.Info("Subscription created successfully",
-		zap.String("subscriptionID", id))
-	return id, nil
+		zap.String("subscriptionID", sub.ID))
+	return sub.ID, nil
+}
+
+// checkOverlap fails with a DuplicateSubscriptionError when the user already
+// has the same service in a period overlapping sub.
+func (subscriptionRepository SubscriptionRepository) checkOverlap(ctx context.Context, tx *sql.Tx, sub sql_models.Subscription) error {
+	// Serialise inserts of the same user and service so two concurrent
+	// requests cannot both pass the overlap check.
+	lockQuery := `SELECT pg_advisory_xact_lock(hashtext($1 || '|' || LOWER($2)))`
+	if _, err := tx.ExecContext(ctx, lockQuery, sub.UserID, sub.ServiceName); err != nil {
+		subscriptionRepository.logger.Error("Failed to lock user service",
+			zap.String("query", lockQuery),
+			zap.Error(err))
+		return fmt.Errorf("failed to check overlapping subscriptions: %w", err)
+	}
+
+	overlapQuery := `
+		SELECT id FROM subscriptions
+		WHERE user_id = $1
+		AND LOWER(service_name) = LOWER($2)
+		AND ($3::date IS NULL OR start_date <= $3)
+		AND (end_date IS NULL OR end_date >= $4)
+		AND id <> $5
+		AND deleted_at IS NULL
+		ORDER BY start_date
+		LIMIT 1
+	`
+	var existingID string
+	err := tx.QueryRowContext(ctx, overlapQuery, sub.UserID, sub.ServiceName, sub.EndDate, sub.StartDate, sub.ID).Scan(&existingID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		subscriptionRepository.logger.Error("Failed to check overlapping subscriptions",
+			zap.String("query", overlapQuery),
+			zap.Error(err))
+		return fmt.Errorf("failed to check overlapping subscriptions: %w", err)
+	}
+
+	subscriptionRepository.logger.Warn("Overlapping subscription exists",
+		zap.String("userID", sub.UserID),
+		zap.String("service", sub.ServiceName),
+		zap.String("existingID", existingID))
+	return &domain_errors.DuplicateSubscriptionError{ExistingID: existingID}
 }
 
 func (subscriptionRepository SubscriptionRepository) GetSubscriptions(ctx context.Context, userID uuid.UUID) ([]sql_models.Subscription, error) {
 	subscriptionRepository.logger.Debug("Getting user subscriptions",
 		zap.String("userID", userID.String()))
 
-	var subscriptions []sql_models.Subscription
-	query := `SELECT id, service_name, price, user_id, start_date, end_date, created_at FROM subscriptions WHERE user_id = $1`
+	query := `SELECT ` + subscriptionColumns + ` FROM subscriptions WHERE user_id = $1 AND deleted_at IS NULL`
 
 	rows, err := subscriptionRepository.db.QueryContext(ctx, query, userID)
 	if err != nil {
@@ -69,168 +143,836 @@ func (subscriptionRepository SubscriptionRepository) GetSubscriptions(ctx contex
 		}
 	}()
 
-	for rows.Next() {
-		var sub sql_models.Subscription
-		var endDate sql.NullTime
+	subscriptions, err := scanSubscriptions(rows)
+	if err != nil {
+		subscriptionRepository.logger.Error("Failed to read subscriptions",
+			zap.String("userID", userID.String()),
+			zap.Error(err))
+		return nil, err
+	}
 
-		if err := rows.Scan(
-			&sub.ID,
-			&sub.ServiceName,
-			&sub.Price,
-			&sub.UserID,
-			&sub.StartDate,
-			&endDate,
-			&sub.CreatedAt,
-		); err != nil {
-			subscriptionRepository.logger.Error("Failed to scan subscription row",
-				zap.String("userID", userID.String()),
-				zap.Error(err))
-			return nil, fmt.Errorf("error with scanning: %w", err)
-		}
+	subscriptionRepository.logger.Debug("Retrieved subscriptions count",
+		zap.String("userID", userID.String()),
+		zap.Int("count", len(subscriptions)))
+	return subscriptions, nil
+}
+
+// GetSubscription returns the subscription with the given ID. Soft-deleted
+// subscriptions are reported as not found unless includeDeleted is set.
+func (subscriptionRepository SubscriptionRepository) GetSubscription(ctx context.Context, subscriptionUUID uuid.UUID, includeDeleted bool) (sql_models.Subscription, error) {
+	subscriptionRepository.logger.Debug("Getting subscription",
+		zap.String("subscriptionID", subscriptionUUID.String()),
+		zap.Bool("includeDeleted", includeDeleted))
 
-		if endDate.Valid {
-			sub.EndDate = &endDate.Time
+	query := `SELECT ` + subscriptionColumns + ` FROM subscriptions WHERE id = $1 AND ($2 OR deleted_at IS NULL)`
+
+	sub, err := scanSubscription(subscriptionRepository.db.QueryRowContext(ctx, query, subscriptionUUID, includeDeleted))
+	if errors.Is(err, sql.ErrNoRows) {
+		subscriptionRepository.logger.Warn("Subscription not found",
+			zap.String("subscriptionID", subscriptionUUID.String()))
+		return sql_models.Subscription{}, domain_errors.ErrSubscriptionNotFound
+	}
+	if err != nil {
+		subscriptionRepository.logger.Error("Failed to query subscription",
+			zap.String("query", query),
+			zap.String("subscriptionID", subscriptionUUID.String()),
+			zap.Error(err))
+		return sql_models.Subscription{}, fmt.Errorf("database query failed: %w", err)
+	}
+
+	return sub, nil
+}
+
+func (subscriptionRepository SubscriptionRepository) ListSubscriptions(ctx context.Context, filter json_models.SubscriptionListFilter) ([]sql_models.Subscription, error) {
+	subscriptionRepository.logger.Debug("Listing subscriptions",
+		zap.Any("filter", filter))
+
+	query := `SELECT ` + subscriptionColumns + ` FROM subscriptions WHERE 1 = 1`
+	var args []interface{}
+	addCondition := func(condition string, values ...interface{}) {
+		placeholders := make([]interface{}, len(values))
+		for i, value := range values {
+			args = append(args, value)
+			placeholders[i] = len(args)
 		}
-		subscriptions = append(subscriptions, sub)
+		query += " AND " + fmt.Sprintf(condition, placeholders...)
 	}
 
-	if err := rows.Err(); err != nil {
-		subscriptionRepository.logger.Error("Row iteration error",
-			zap.String("userID", userID.String()),
+	if !filter.IncludeDeleted {
+		addCondition("deleted_at IS NULL")
+	}
+	if filter.UserID != nil {
+		addCondition("user_id = $%d", *filter.UserID)
+	}
+	if filter.ServiceName != nil {
+		addCondition("LOWER(service_name) = LOWER($%d)", *filter.ServiceName)
+	}
+	if filter.MinPrice != nil {
+		addCondition("price >= $%d", *filter.MinPrice)
+	}
+	if filter.MaxPrice != nil {
+		addCondition("price <= $%d", *filter.MaxPrice)
+	}
+	if filter.ActiveFrom != nil && filter.ActiveTo != nil {
+		addCondition("start_date <= $%d AND (end_date IS NULL OR end_date >= $%d)", *filter.ActiveTo, *filter.ActiveFrom)
+	}
+	if filter.StartFrom != nil {
+		addCondition("start_date >= $%d", *filter.StartFrom)
+	}
+	if filter.StartTo != nil {
+		addCondition("start_date <= $%d", *filter.StartTo)
+	}
+	if filter.EndFrom != nil {
+		addCondition("end_date >= $%d", *filter.EndFrom)
+	}
+	if filter.EndTo != nil {
+		addCondition("end_date <= $%d", *filter.EndTo)
+	}
+
+	column := listSortColumns[filter.Sort]
+	direction, comparison := "ASC", ">"
+	if filter.Descending {
+		direction, comparison = "DESC", "<"
+	}
+	if filter.After != nil {
+		addCondition("("+column+", id) "+comparison+" ($%d, $%d)", cursorValue(*filter.After, filter.Sort), filter.After.ID)
+	}
+
+	query += fmt.Sprintf(" ORDER BY %s %s, id %s LIMIT %d", column, direction, direction, filter.Limit)
+
+	rows, err := subscriptionRepository.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		subscriptionRepository.logger.Error("Failed to list subscriptions",
+			zap.String("query", query),
+			zap.Any("args", args),
 			zap.Error(err))
-		return nil, fmt.Errorf("iteration error: %w", err)
+		return nil, fmt.Errorf("database query failed: %w", err)
 	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			subscriptionRepository.logger.Error("Failed to close rows",
+				zap.Error(closeErr))
+		}
+	}()
 
-	subscriptionRepository.logger.Debug("Retrieved subscriptions count",
-		zap.String("userID", userID.String()),
+	subscriptions, err := scanSubscriptions(rows)
+	if err != nil {
+		subscriptionRepository.logger.Error("Failed to read subscriptions list",
+			zap.Any("args", args),
+			zap.Error(err))
+		return nil, err
+	}
+
+	subscriptionRepository.logger.Debug("Listed subscriptions count",
 		zap.Int("count", len(subscriptions)))
 	return subscriptions, nil
 }
 
-func (subscriptionRepository SubscriptionRepository) UpdateSubscription(ctx context.Context, subscriptionID string, data json_models.SubscriptionUpdate) error {
+func (subscriptionRepository SubscriptionRepository) UpdateSubscription(ctx context.Context, subscriptionID string, data json_models.SubscriptionUpdate) (sql_models.Subscription, error) {
 	subscriptionRepository.logger.Debug("Updating subscription",
 		zap.String("SubscriptionID", subscriptionID),
 		zap.Any("updateData", data))
 
-	query := `
-		UPDATE subscriptions
-		SET 
-			service_name = COALESCE($1, service_name),
-			price = COALESCE($2, price),
-			start_date = COALESCE($3, start_date),
-			end_date = COALESCE($4, end_date)
-		WHERE id = $5
-	`
+	var sub sql_models.Subscription
+	err := runInTx(ctx, subscriptionRepository.db, subscriptionRepository.logger, func(tx *sql.Tx) error {
+		before, err := subscriptionRepository.lockSubscription(ctx, tx, subscriptionID, data.ExpectedVersion)
+		if err != nil {
+			return err
+		}
+		if err := checkUpdatedDates(before, data); err != nil {
+			subscriptionRepository.logger.Warn("Invalid dates after update",
+				zap.String("subscriptionID", subscriptionID),
+				zap.Error(err))
+			return err
+		}
+
+		price := data.Price
+		if data.Price != nil && data.PriceEffectiveFrom != nil {
+			prices, err := loadPrices(ctx, tx, subscriptionID)
+			if err != nil {
+				return err
+			}
+			prices, changed := applyPriceChange(subscriptionID, prices, *data.Price, *data.PriceEffectiveFrom)
+			if changed {
+				if err := replacePrices(ctx, tx, subscriptionID, prices); err != nil {
+					return err
+				}
+			}
+			current := currentPrice(before, prices, data.CurrentMonth)
+			price = &current
+		}
 
-	_, err := subscriptionRepository.db.ExecContext(ctx, query,
-		data.ServiceName,
-		data.Price,
-		data.StartDate,
-		data.EndDate,
-		subscriptionID,
-	)
+		query := `
+			UPDATE subscriptions
+			SET 
+				service_name = COALESCE($1, service_name),
+				price = COALESCE($2, price),
+				start_date = COALESCE($3, start_date),
+				end_date = CASE WHEN $4::boolean THEN NULL ELSE COALESCE($5, end_date) END,
+				billing_interval = COALESCE($8, billing_interval),
+				billing_interval_count = COALESCE($9, billing_interval_count),
+				currency = COALESCE($10, currency),
+				billing_anchor_day = COALESCE($11, billing_anchor_day),
+				version = version + 1
+			WHERE id = $6
+			AND version = $7
+			RETURNING ` + subscriptionColumns
+
+		sub, err = scanSubscription(tx.QueryRowContext(ctx, query,
+			data.ServiceName,
+			price,
+			data.StartDate,
+			data.ClearEndDate,
+			data.EndDate,
+			subscriptionID,
+			before.Version,
+			data.BillingInterval,
+			data.BillingIntervalCount,
+			data.Currency,
+			data.BillingAnchorDay,
+		))
+		if err != nil {
+			subscriptionRepository.logger.Error("Failed to update subscription",
+				zap.String("query", query),
+				zap.String("subscriptionID", subscriptionID),
+				zap.Error(err))
+			return fmt.Errorf("failed to update subscription: %w", mapDatabaseError(err))
+		}
 
+		return insertSubscriptionEvent(ctx, tx, sql_models.EventUpdated, subscriptionID, &before, &sub)
+	})
 	if err != nil {
-		subscriptionRepository.logger.Error("Failed to update subscription",
-			zap.String("query", query),
-			zap.String("subscriptionID", subscriptionID),
-			zap.Error(err))
-		return fmt.Errorf("failed to update subscription: %w", err)
+		return sql_models.Subscription{}, err
 	}
 
 	subscriptionRepository.logger.Info("Subscription updated successfully",
 		zap.String("subscriptionID", subscriptionID))
-	return nil
+	return sub, nil
 }
 
-func (subscriptionRepository SubscriptionRepository) DeleteSubscription(ctx context.Context, subscriptionUUID uuid.UUID) error {
+// DeleteSubscription soft-deletes the subscription by stamping deleted_at.
+// The row is removed for good by PurgeDeletedSubscriptions.
+func (subscriptionRepository SubscriptionRepository) DeleteSubscription(ctx context.Context, subscriptionUUID uuid.UUID, expectedVersion *int) (sql_models.Subscription, error) {
 	subscriptionRepository.logger.Debug("Attempting to delete subscription",
 		zap.String("userID", subscriptionUUID.String()))
 
-	query := `DELETE FROM subscriptions 
-        WHERE id = $1`
+	var after sql_models.Subscription
+	err := runInTx(ctx, subscriptionRepository.db, subscriptionRepository.logger, func(tx *sql.Tx) error {
+		before, err := subscriptionRepository.lockSubscription(ctx, tx, subscriptionUUID.String(), expectedVersion)
+		if err != nil {
+			return err
+		}
+
+		query := `UPDATE subscriptions
+			SET deleted_at = NOW(), version = version + 1
+			WHERE id = $1
+			RETURNING ` + subscriptionColumns
+
+		after, err = scanSubscription(tx.QueryRowContext(ctx, query, subscriptionUUID))
+		if err != nil {
+			subscriptionRepository.logger.Error("Database error when deleting subscription",
+				zap.String("query", query),
+				zap.String("userID", subscriptionUUID.String()),
+				zap.Error(err))
+			return fmt.Errorf("database error when deleting subscription: %w", err)
+		}
+
+		return insertSubscriptionEvent(ctx, tx, sql_models.EventDeleted, subscriptionUUID.String(), &before, &after)
+	})
+	if err != nil {
+		return sql_models.Subscription{}, err
+	}
+
+	subscriptionRepository.logger.Info("Subscription deleted successfully",
+		zap.String("userID", subscriptionUUID.String()))
+	return after, nil
+}
+
+// TransitionSubscription moves the subscription to the target status as of now.
+// Pausing and resuming also open and close a pause range used by cost reports.
+func (subscriptionRepository SubscriptionRepository) TransitionSubscription(ctx context.Context, subscriptionUUID uuid.UUID, target string, now time.Time, expectedVersion *int) (sql_models.Subscription, error) {
+	subscriptionRepository.logger.Debug("Changing subscription status",
+		zap.String("subscriptionID", subscriptionUUID.String()),
+		zap.String("status", target))
+
+	var sub sql_models.Subscription
+	err := runInTx(ctx, subscriptionRepository.db, subscriptionRepository.logger, func(tx *sql.Tx) error {
+		before, err := subscriptionRepository.lockSubscription(ctx, tx, subscriptionUUID.String(), expectedVersion)
+		if err != nil {
+			return err
+		}
+
+		plan, err := planTransition(before, target, now)
+		if err != nil {
+			subscriptionRepository.logger.Warn("Status transition not allowed",
+				zap.String("subscriptionID", subscriptionUUID.String()),
+				zap.String("status", before.StatusAt(now)),
+				zap.String("target", target))
+			return err
+		}
+
+		if plan.pauseUntil != nil {
+			closeQuery := `UPDATE subscription_pauses SET paused_until = $2 WHERE subscription_id = $1 AND paused_until IS NULL`
+			if _, err := tx.ExecContext(ctx, closeQuery, subscriptionUUID, *plan.pauseUntil); err != nil {
+				subscriptionRepository.logger.Error("Failed to close subscription pause",
+					zap.String("query", closeQuery),
+					zap.Error(err))
+				return fmt.Errorf("failed to close subscription pause: %w", err)
+			}
+			// A pause resumed in the month after it was requested never took effect.
+			cleanupQuery := `DELETE FROM subscription_pauses WHERE subscription_id = $1 AND paused_until < paused_from`
+			if _, err := tx.ExecContext(ctx, cleanupQuery, subscriptionUUID); err != nil {
+				subscriptionRepository.logger.Error("Failed to remove empty subscription pause",
+					zap.String("query", cleanupQuery),
+					zap.Error(err))
+				return fmt.Errorf("failed to close subscription pause: %w", err)
+			}
+		}
+		if plan.pauseFrom != nil {
+			pauseQuery := `INSERT INTO subscription_pauses (subscription_id, paused_from) VALUES ($1, $2)`
+			if _, err := tx.ExecContext(ctx, pauseQuery, subscriptionUUID, *plan.pauseFrom); err != nil {
+				subscriptionRepository.logger.Error("Failed to open subscription pause",
+					zap.String("query", pauseQuery),
+					zap.Error(err))
+				return fmt.Errorf("failed to open subscription pause: %w", err)
+			}
+		}
+
+		query := `UPDATE subscriptions
+			SET status = $1, end_date = $2, trial_end_date = $3, version = version + 1
+			WHERE id = $4
+			RETURNING ` + subscriptionColumns
+
+		sub, err = scanSubscription(tx.QueryRowContext(ctx, query, plan.after.Status, plan.after.EndDate, plan.after.TrialEndDate, subscriptionUUID))
+		if err != nil {
+			subscriptionRepository.logger.Error("Failed to change subscription status",
+				zap.String("query", query),
+				zap.String("subscriptionID", subscriptionUUID.String()),
+				zap.Error(err))
+			return fmt.Errorf("failed to change subscription status: %w", mapDatabaseError(err))
+		}
 
-	result, err := subscriptionRepository.db.ExecContext(ctx, query, subscriptionUUID)
+		return insertSubscriptionEvent(ctx, tx, plan.eventType, subscriptionUUID.String(), &before, &sub)
+	})
+	if err != nil {
+		return sql_models.Subscription{}, err
+	}
+
+	subscriptionRepository.logger.Info("Subscription status changed",
+		zap.String("subscriptionID", subscriptionUUID.String()),
+		zap.String("status", sub.Status))
+	return sub, nil
+}
+
+// RestoreSubscription clears deleted_at of a soft-deleted subscription.
+// Restoring a subscription that is not deleted returns it unchanged.
+func (subscriptionRepository SubscriptionRepository) RestoreSubscription(ctx context.Context, subscriptionUUID uuid.UUID) (sql_models.Subscription, error) {
+	subscriptionRepository.logger.Debug("Restoring subscription",
+		zap.String("subscriptionID", subscriptionUUID.String()))
+
+	var sub sql_models.Subscription
+	err := runInTx(ctx, subscriptionRepository.db, subscriptionRepository.logger, func(tx *sql.Tx) error {
+		query := `SELECT ` + subscriptionColumns + ` FROM subscriptions WHERE id = $1 FOR UPDATE`
+
+		before, err := scanSubscription(tx.QueryRowContext(ctx, query, subscriptionUUID))
+		if errors.Is(err, sql.ErrNoRows) {
+			subscriptionRepository.logger.Warn("Subscription not found for restore",
+				zap.String("subscriptionID", subscriptionUUID.String()))
+			return domain_errors.ErrSubscriptionNotFound
+		}
+		if err != nil {
+			subscriptionRepository.logger.Error("Failed to lock subscription",
+				zap.String("query", query),
+				zap.String("subscriptionID", subscriptionUUID.String()),
+				zap.Error(err))
+			return fmt.Errorf("database query failed: %w", err)
+		}
+		if before.DeletedAt == nil {
+			sub = before
+			return nil
+		}
+
+		if err := subscriptionRepository.checkOverlap(ctx, tx, before); err != nil {
+			return err
+		}
+
+		restoreQuery := `UPDATE subscriptions
+			SET deleted_at = NULL, version = version + 1
+			WHERE id = $1
+			RETURNING ` + subscriptionColumns
+
+		sub, err = scanSubscription(tx.QueryRowContext(ctx, restoreQuery, subscriptionUUID))
+		if err != nil {
+			subscriptionRepository.logger.Error("Failed to restore subscription",
+				zap.String("query", restoreQuery),
+				zap.String("subscriptionID", subscriptionUUID.String()),
+				zap.Error(err))
+			return fmt.Errorf("failed to restore subscription: %w", err)
+		}
+
+		return insertSubscriptionEvent(ctx, tx, sql_models.EventRestored, subscriptionUUID.String(), &before, &sub)
+	})
+	if err != nil {
+		return sql_models.Subscription{}, err
+	}
+
+	subscriptionRepository.logger.Info("Subscription restored successfully",
+		zap.String("subscriptionID", subscriptionUUID.String()))
+	return sub, nil
+}
+
+// PurgeDeletedSubscriptions permanently removes subscriptions soft-deleted
+// before deletedBefore and returns how many rows were removed.
+func (subscriptionRepository SubscriptionRepository) PurgeDeletedSubscriptions(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	subscriptionRepository.logger.Debug("Purging deleted subscriptions",
+		zap.Time("deletedBefore", deletedBefore))
+
+	var purged int64
+	err := runInTx(ctx, subscriptionRepository.db, subscriptionRepository.logger, func(tx *sql.Tx) error {
+		query := `DELETE FROM subscriptions
+			WHERE deleted_at IS NOT NULL
+			AND deleted_at < $1
+			RETURNING ` + subscriptionColumns
+
+		rows, err := tx.QueryContext(ctx, query, deletedBefore)
+		if err != nil {
+			subscriptionRepository.logger.Error("Failed to purge deleted subscriptions",
+				zap.String("query", query),
+				zap.Error(err))
+			return fmt.Errorf("failed to purge deleted subscriptions: %w", err)
+		}
+		subscriptions, err := scanSubscriptions(rows)
+		if closeErr := rows.Close(); closeErr != nil {
+			subscriptionRepository.logger.Error("Failed to close rows",
+				zap.Error(closeErr))
+		}
+		if err != nil {
+			return fmt.Errorf("failed to purge deleted subscriptions: %w", err)
+		}
+
+		for i := range subscriptions {
+			if err := insertSubscriptionEvent(ctx, tx, sql_models.EventPurged, subscriptions[i].ID, &subscriptions[i], nil); err != nil {
+				return err
+			}
+		}
+		purged = int64(len(subscriptions))
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if purged > 0 {
+		subscriptionRepository.logger.Info("Deleted subscriptions purged",
+			zap.Int64("count", purged))
+	}
+	return purged, nil
+}
+
+// GetUpcomingCharges projects the charges of the user's live subscriptions
+// dated inside the filter window.
+func (subscriptionRepository SubscriptionRepository) GetUpcomingCharges(ctx context.Context, filter json_models.UpcomingChargesFilter, rates *ExchangeRates) (json_models.UpcomingCharges, error) {
+	subscriptionRepository.logger.Debug("Projecting upcoming charges",
+		zap.Any("filter", filter))
+
+	query := `
+		SELECT ` + subscriptionColumns + `
+		FROM subscriptions
+		WHERE deleted_at IS NULL
+		AND start_date < $2
+		AND (end_date IS NULL OR end_date >= $1)
+	`
+	args := []interface{}{filter.From, filter.To}
+	if filter.UserID != "" {
+		query += " AND user_id = $3"
+		args = append(args, filter.UserID)
+	}
+	rows, err := subscriptionRepository.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		subscriptionRepository.logger.Error("Database error when deleting subscription",
+		subscriptionRepository.logger.Error("Failed to query upcoming charges",
 			zap.String("query", query),
-			zap.String("userID", subscriptionUUID.String()),
+			zap.String("userID", filter.UserID),
 			zap.Error(err))
-		return fmt.Errorf("database error when deleting subscription: %w", err)
+		return json_models.UpcomingCharges{}, fmt.Errorf("failed to project upcoming charges: %w", err)
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			subscriptionRepository.logger.Error("Failed to close rows",
+				zap.Error(closeErr))
+		}
+	}()
+
+	subscriptions, err := scanSubscriptions(rows)
+	if err != nil {
+		return json_models.UpcomingCharges{}, fmt.Errorf("failed to project upcoming charges: %w", err)
+	}
+
+	history, err := subscriptionRepository.getHistory(ctx, subscriptions)
+	if err != nil {
+		return json_models.UpcomingCharges{}, fmt.Errorf("failed to project upcoming charges: %w", err)
+	}
+
+	return buildUpcomingCharges(subscriptions, history, filter, rates)
+}
+
+// GetSubscriptionPrices returns the price records of the subscription, oldest first.
+func (subscriptionRepository SubscriptionRepository) GetSubscriptionPrices(ctx context.Context, subscriptionUUID uuid.UUID) ([]sql_models.SubscriptionPrice, error) {
+	subscriptionRepository.logger.Debug("Getting subscription prices",
+		zap.String("subscriptionID", subscriptionUUID.String()))
+
+	if _, err := subscriptionRepository.GetSubscription(ctx, subscriptionUUID, false); err != nil {
+		return nil, err
+	}
+
+	query := `SELECT subscription_id, effective_from, price FROM subscription_prices WHERE subscription_id = $1 ORDER BY effective_from`
+	prices := []sql_models.SubscriptionPrice{}
+	err := queryRows(ctx, subscriptionRepository.db, subscriptionRepository.logger, query, func(rows *sql.Rows) error {
+		var price sql_models.SubscriptionPrice
+		if err := rows.Scan(&price.SubscriptionID, &price.EffectiveFrom, &price.Price); err != nil {
+			return err
+		}
+		prices = append(prices, price)
+		return nil
+	}, subscriptionUUID)
+	if err != nil {
+		return nil, err
 	}
+	return prices, nil
+}
+
+func (subscriptionRepository SubscriptionRepository) GetSubscriptionHistory(ctx context.Context, subscriptionUUID uuid.UUID) ([]sql_models.SubscriptionEvent, error) {
+	subscriptionRepository.logger.Debug("Getting subscription history",
+		zap.String("subscriptionID", subscriptionUUID.String()))
+
+	query := `
+		SELECT id, subscription_id, event_type, before_state, after_state, actor, request_id, created_at
+		FROM subscription_events
+		WHERE subscription_id = $1
+		ORDER BY id
+	`
 
-	rowsAffected, err := result.RowsAffected()
+	rows, err := subscriptionRepository.db.QueryContext(ctx, query, subscriptionUUID)
 	if err != nil {
-		subscriptionRepository.logger.Error("Failed to get rows affected count",
-			zap.String("userID", subscriptionUUID.String()),
+		subscriptionRepository.logger.Error("Failed to query subscription history",
+			zap.String("query", query),
+			zap.String("subscriptionID", subscriptionUUID.String()),
 			zap.Error(err))
-		return fmt.Errorf("failed to verify deletion: %w", err)
+		return nil, fmt.Errorf("database query failed: %w", err)
 	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			subscriptionRepository.logger.Error("Failed to close rows",
+				zap.Error(closeErr))
+		}
+	}()
 
-	if rowsAffected == 0 {
-		subscriptionRepository.logger.Warn("Subscription not found for deletion",
-			zap.String("userID", subscriptionUUID.String()))
-		return fmt.Errorf("subscription does not exist")
+	events := []sql_models.SubscriptionEvent{}
+	for rows.Next() {
+		var event sql_models.SubscriptionEvent
+		var before, after []byte
+		if err := rows.Scan(
+			&event.ID,
+			&event.SubscriptionID,
+			&event.EventType,
+			&before,
+			&after,
+			&event.Actor,
+			&event.RequestID,
+			&event.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("error with scanning: %w", err)
+		}
+		event.Before, event.After = before, after
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iteration error: %w", err)
 	}
 
-	subscriptionRepository.logger.Info("Subscription deleted successfully",
-		zap.String("userID", subscriptionUUID.String()),
-		zap.Int64("rowsAffected", rowsAffected))
-	return nil
+	if len(events) == 0 {
+		// A subscription without events still has an (empty) history; only a
+		// missing row is reported as not found.
+		var exists bool
+		existsQuery := `SELECT EXISTS (SELECT 1 FROM subscriptions WHERE id = $1)`
+		if err := subscriptionRepository.db.QueryRowContext(ctx, existsQuery, subscriptionUUID).Scan(&exists); err != nil {
+			subscriptionRepository.logger.Error("Failed to check subscription existence",
+				zap.String("query", existsQuery),
+				zap.String("subscriptionID", subscriptionUUID.String()),
+				zap.Error(err))
+			return nil, fmt.Errorf("database query failed: %w", err)
+		}
+		if !exists {
+			return nil, domain_errors.ErrSubscriptionNotFound
+		}
+	}
+	return events, nil
 }
 
-func (subscriptionRepository SubscriptionRepository) GetSubscriptionsCost(
-	ctx context.Context,
-	userID *uuid.UUID,
-	serviceName *string,
-	startDate time.Time,
-	endDate *time.Time,
-) (int, error) {
+// lockSubscription reads the subscription with a row lock for the rest of the
+// transaction and checks the expected version when one is given.
+func (subscriptionRepository SubscriptionRepository) lockSubscription(ctx context.Context, tx *sql.Tx, subscriptionID string, expectedVersion *int) (sql_models.Subscription, error) {
+	query := `SELECT ` + subscriptionColumns + ` FROM subscriptions WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`
+
+	sub, err := scanSubscription(tx.QueryRowContext(ctx, query, subscriptionID))
+	if errors.Is(err, sql.ErrNoRows) {
+		subscriptionRepository.logger.Warn("Subscription not found",
+			zap.String("subscriptionID", subscriptionID))
+		return sql_models.Subscription{}, domain_errors.ErrSubscriptionNotFound
+	}
+	if err != nil {
+		subscriptionRepository.logger.Error("Failed to lock subscription",
+			zap.String("query", query),
+			zap.String("subscriptionID", subscriptionID),
+			zap.Error(err))
+		return sql_models.Subscription{}, fmt.Errorf("database query failed: %w", err)
+	}
+
+	if expectedVersion != nil && *expectedVersion != sub.Version {
+		subscriptionRepository.logger.Warn("Subscription version mismatch",
+			zap.String("subscriptionID", subscriptionID),
+			zap.Int("version", sub.Version),
+			zap.Int("expectedVersion", *expectedVersion))
+		return sql_models.Subscription{}, domain_errors.ErrPreconditionFailed
+	}
+	return sub, nil
+}
+
+func (subscriptionRepository SubscriptionRepository) GetSubscriptionsCost(ctx context.Context, filter json_models.CostFilter, rates *ExchangeRates) (json_models.CostReport, error) {
 	subscriptionRepository.logger.Debug("Calculating subscriptions cost",
-		zap.Any("userID", userID),
-		zap.Any("serviceName", serviceName),
-		zap.Time("startDate", startDate),
-		zap.Any("endDate", endDate))
+		zap.Any("filter", filter))
+
+	periodEnd := filter.EndDate
 
 	query := `
-        SELECT COALESCE(SUM(price), 0) 
-        FROM subscriptions 
-        WHERE start_date >= $1
-        AND (end_date IS NULL OR end_date <= $2)
+        SELECT ` + subscriptionColumns + `
+        FROM subscriptions
+        WHERE start_date < $2
+        AND (end_date IS NULL OR end_date >= $1)
     `
-	args := []interface{}{startDate}
-
-	checkDate := time.Now()
-	if endDate != nil {
-		checkDate = *endDate
-	}
-	args = append(args, checkDate)
+	args := []interface{}{utils.MonthStart(filter.StartDate), utils.MonthStart(periodEnd).AddDate(0, 1, 0)}
 
 	argPos := 3
 
-	if userID != nil {
+	if !filter.IncludeDeleted {
+		query += " AND deleted_at IS NULL"
+	}
+
+	if filter.UserID != nil {
 		query += fmt.Sprintf(" AND user_id = $%d", argPos)
-		args = append(args, *userID)
+		args = append(args, *filter.UserID)
 		argPos++
 	}
 
-	if serviceName != nil {
-		query += fmt.Sprintf(" AND service_name ILIKE $%d", argPos)
-		args = append(args, *serviceName)
+	if filter.ServiceName != nil {
+		query += fmt.Sprintf(" AND LOWER(service_name) = LOWER($%d)", argPos)
+		args = append(args, *filter.ServiceName)
 		argPos++
 	}
 
-	var totalCost int
-	err := subscriptionRepository.db.QueryRowContext(ctx, query, args...).Scan(&totalCost)
+	rows, err := subscriptionRepository.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		subscriptionRepository.logger.Error("Failed to calculate subscriptions cost",
 			zap.String("query", query),
 			zap.Any("args", args),
 			zap.Error(err))
-		return 0, fmt.Errorf("failed to calculate subscriptions cost: %w", err)
+		return json_models.CostReport{}, fmt.Errorf("failed to calculate subscriptions cost: %w", err)
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			subscriptionRepository.logger.Error("Failed to close rows",
+				zap.Error(closeErr))
+		}
+	}()
+
+	subscriptions, err := scanSubscriptions(rows)
+	if err != nil {
+		subscriptionRepository.logger.Error("Failed to read subscriptions for cost calculation",
+			zap.Any("args", args),
+			zap.Error(err))
+		return json_models.CostReport{}, fmt.Errorf("failed to calculate subscriptions cost: %w", err)
+	}
+
+	history, err := subscriptionRepository.getHistory(ctx, subscriptions)
+	if err != nil {
+		return json_models.CostReport{}, fmt.Errorf("failed to calculate subscriptions cost: %w", err)
+	}
+
+	report, err := calculateCostReport(subscriptions, history, filter.StartDate, periodEnd, filter.Proration, rates)
+	if err != nil {
+		subscriptionRepository.logger.Warn("Failed to convert subscriptions cost",
+			zap.String("currency", rates.Target()),
+			zap.Error(err))
+		return json_models.CostReport{}, err
 	}
 
 	subscriptionRepository.logger.Debug("Subscriptions cost calculated",
-		zap.Int("totalCost", totalCost))
-	return totalCost, nil
+		zap.Int("subscriptionsCount", len(subscriptions)),
+		zap.Float64("totalCost", report.TotalCost))
+	return report, nil
+}
+
+// getHistory loads the pause ranges and price records of the given subscriptions.
+func (subscriptionRepository SubscriptionRepository) getHistory(ctx context.Context, subscriptions []sql_models.Subscription) (subscriptionHistory, error) {
+	history := newSubscriptionHistory()
+	if len(subscriptions) == 0 {
+		return history, nil
+	}
+
+	ids := make([]string, len(subscriptions))
+	for i, sub := range subscriptions {
+		ids[i] = sub.ID
+	}
+
+	pausesQuery := `SELECT subscription_id, paused_from, paused_until FROM subscription_pauses WHERE subscription_id = ANY($1::uuid[])`
+	err := queryRows(ctx, subscriptionRepository.db, subscriptionRepository.logger, pausesQuery, func(rows *sql.Rows) error {
+		var pause sql_models.SubscriptionPause
+		var pausedUntil sql.NullTime
+		if err := rows.Scan(&pause.SubscriptionID, &pause.PausedFrom, &pausedUntil); err != nil {
+			return err
+		}
+		if pausedUntil.Valid {
+			pause.PausedUntil = &pausedUntil.Time
+		}
+		history.pauses[pause.SubscriptionID] = append(history.pauses[pause.SubscriptionID], pause)
+		return nil
+	}, pq.Array(ids))
+	if err != nil {
+		return subscriptionHistory{}, err
+	}
+
+	pricesQuery := `SELECT subscription_id, effective_from, price FROM subscription_prices WHERE subscription_id = ANY($1::uuid[]) ORDER BY effective_from`
+	err = queryRows(ctx, subscriptionRepository.db, subscriptionRepository.logger, pricesQuery, func(rows *sql.Rows) error {
+		var price sql_models.SubscriptionPrice
+		if err := rows.Scan(&price.SubscriptionID, &price.EffectiveFrom, &price.Price); err != nil {
+			return err
+		}
+		history.prices[price.SubscriptionID] = append(history.prices[price.SubscriptionID], price)
+		return nil
+	}, pq.Array(ids))
+	if err != nil {
+		return subscriptionHistory{}, err
+	}
+	return history, nil
+}
+
+// queryRows runs query and calls scan for every returned row.
+func queryRows(ctx context.Context, db *sql.DB, logger *zap.Logger, query string, scan func(rows *sql.Rows) error, args ...interface{}) error {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		logger.Error("Failed to run query",
+			zap.String("query", query),
+			zap.Error(err))
+		return fmt.Errorf("database query failed: %w", err)
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			logger.Error("Failed to close rows",
+				zap.Error(closeErr))
+		}
+	}()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return fmt.Errorf("error with scanning: %w", err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iteration error: %w", err)
+	}
+	return nil
+}
+
+// loadPrices reads the price records of one subscription inside tx.
+func loadPrices(ctx context.Context, tx *sql.Tx, subscriptionID string) ([]sql_models.SubscriptionPrice, error) {
+	query := `SELECT subscription_id, effective_from, price FROM subscription_prices WHERE subscription_id = $1 ORDER BY effective_from`
+
+	rows, err := tx.QueryContext(ctx, query, subscriptionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load subscription prices: %w", err)
+	}
+	defer rows.Close()
+
+	var prices []sql_models.SubscriptionPrice
+	for rows.Next() {
+		var price sql_models.SubscriptionPrice
+		if err := rows.Scan(&price.SubscriptionID, &price.EffectiveFrom, &price.Price); err != nil {
+			return nil, fmt.Errorf("error with scanning: %w", err)
+		}
+		prices = append(prices, price)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iteration error: %w", err)
+	}
+	return prices, nil
+}
+
+// replacePrices stores prices as the complete price history of the subscription.
+func replacePrices(ctx context.Context, tx *sql.Tx, subscriptionID string, prices []sql_models.SubscriptionPrice) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM subscription_prices WHERE subscription_id = $1`, subscriptionID); err != nil {
+		return fmt.Errorf("failed to replace subscription prices: %w", err)
+	}
+
+	query := `INSERT INTO subscription_prices (subscription_id, effective_from, price) VALUES ($1, $2, $3)`
+	for _, price := range prices {
+		if _, err := tx.ExecContext(ctx, query, subscriptionID, price.EffectiveFrom, price.Price); err != nil {
+			return fmt.Errorf("failed to replace subscription prices: %w", mapDatabaseError(err))
+		}
+	}
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanSubscription(row rowScanner) (sql_models.Subscription, error) {
+	var sub sql_models.Subscription
+	var endDate, deletedAt, trialEndDate sql.NullTime
+
+	if err := row.Scan(
+		&sub.ID,
+		&sub.ServiceName,
+		&sub.Price,
+		&sub.UserID,
+		&sub.StartDate,
+		&endDate,
+		&sub.CreatedAt,
+		&sub.Version,
+		&deletedAt,
+		&sub.Status,
+		&trialEndDate,
+		&sub.BillingInterval,
+		&sub.BillingIntervalCount,
+		&sub.Currency,
+		&sub.BillingAnchorDay,
+	); err != nil {
+		return sql_models.Subscription{}, err
+	}
+
+	if endDate.Valid {
+		sub.EndDate = &endDate.Time
+	}
+	if deletedAt.Valid {
+		sub.DeletedAt = &deletedAt.Time
+	}
+	if trialEndDate.Valid {
+		sub.TrialEndDate = &trialEndDate.Time
+	}
+	return sub, nil
+}
+
+func scanSubscriptions(rows *sql.Rows) ([]sql_models.Subscription, error) {
+	var subscriptions []sql_models.Subscription
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error with scanning: %w", err)
+		}
+		subscriptions = append(subscriptions, sub)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iteration error: %w", err)
+	}
+	return subscriptions, nil
 }
@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"go.uber.org/zap"
+)
+
+// runInTx executes fn in a transaction, committing on success and rolling
+// back on any error returned by fn.
+func runInTx(ctx context.Context, db *sql.DB, logger *zap.Logger, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error("Failed to begin transaction",
+			zap.Error(err))
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if err := fn(tx); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			logger.Error("Failed to rollback transaction",
+				zap.Error(rollbackErr))
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error("Failed to commit transaction",
+			zap.Error(err))
+		return fmt.Errorf("failed to commit transaction: %w", mapDatabaseError(err))
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"sort"
+	"taskTestEffectMobile/internal/models/json_models"
+	"taskTestEffectMobile/internal/models/sql_models"
+	"taskTestEffectMobile/internal/utils"
+	"time"
+)
+
+// projectCharges lists every charge of the subscriptions dated in
+// [from, to) in chronological order with a running total. Charges follow the
+// same rules as calculateCostReport: trial and paused months are free, the
+// price in effect in the month applies and partial periods are prorated
+// according to the policy.
+func projectCharges(
+	subscriptions []sql_models.Subscription,
+	history subscriptionHistory,
+	from, to time.Time,
+	proration string,
+	rates *ExchangeRates,
+) ([]json_models.UpcomingCharge, error) {
+	type charge struct {
+		sub    sql_models.Subscription
+		date   time.Time
+		amount float64
+	}
+
+	var charges []charge
+	for _, sub := range subscriptions {
+		for month := utils.MonthStart(from); month.Before(to); month = month.AddDate(0, 1, 0) {
+			if !billable(sub, history.pauses[sub.ID], month) {
+				continue
+			}
+			price := float64(priceAt(sub, history.prices[sub.ID], month))
+			for _, period := range chargesInMonth(sub, month) {
+				if period.chargedAt.Before(from) || !period.chargedAt.Before(to) {
+					continue
+				}
+				if amount := price * chargeShare(sub, period, proration); amount > 0 {
+					charges = append(charges, charge{sub: sub, date: period.chargedAt, amount: amount})
+				}
+			}
+		}
+	}
+	sort.SliceStable(charges, func(i, j int) bool {
+		if !charges[i].date.Equal(charges[j].date) {
+			return charges[i].date.Before(charges[j].date)
+		}
+		return charges[i].sub.ServiceName < charges[j].sub.ServiceName
+	})
+
+	projected := make([]json_models.UpcomingCharge, 0, len(charges))
+	runningTotal := 0.0
+	for _, charge := range charges {
+		amount, err := rates.Convert(charge.amount, charge.sub.Currency, charge.date)
+		if err != nil {
+			return nil, err
+		}
+		runningTotal += amount
+		projected = append(projected, json_models.UpcomingCharge{
+			Date:           charge.date.Format(utils.DayLayout),
+			SubscriptionID: charge.sub.ID,
+			ServiceName:    charge.sub.ServiceName,
+			Price:          roundMoney(charge.amount),
+			Currency:       charge.sub.Currency,
+			Amount:         roundMoney(amount),
+			RunningTotal:   roundMoney(runningTotal),
+		})
+	}
+	return projected, nil
+}
+
+// buildUpcomingCharges projects the charges of the user inside the filter window.
+func buildUpcomingCharges(subscriptions []sql_models.Subscription, history subscriptionHistory, filter json_models.UpcomingChargesFilter, rates *ExchangeRates) (json_models.UpcomingCharges, error) {
+	charges, err := projectCharges(subscriptions, history, filter.From, filter.To, filter.Proration, rates)
+	if err != nil {
+		return json_models.UpcomingCharges{}, err
+	}
+
+	upcoming := json_models.UpcomingCharges{
+		UserID:    filter.UserID,
+		From:      filter.From.Format(utils.DayLayout),
+		To:        filter.To.AddDate(0, 0, -1).Format(utils.DayLayout),
+		Currency:  rates.Target(),
+		Charges:   charges,
+		RatesUsed: rates.RatesUsed(),
+	}
+	if len(charges) > 0 {
+		upcoming.Total = charges[len(charges)-1].RunningTotal
+	}
+	return upcoming, nil
+}
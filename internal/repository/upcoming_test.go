@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"taskTestEffectMobile/internal/models/sql_models"
+	"testing"
+	"time"
+)
+
+func TestProjectCharges(t *testing.T) {
+	trialEnd := date(2025, time.March, 31)
+	subscriptions := []sql_models.Subscription{
+		{ID: "1", ServiceName: "Netflix", Price: 500, Currency: "RUB", StartDate: date(2025, time.January, 20)},
+		{ID: "2", ServiceName: "Gym", Price: 100, Currency: "RUB", StartDate: date(2025, time.March, 3), BillingInterval: sql_models.IntervalWeek},
+		{ID: "3", ServiceName: "Kinopoisk", Price: 300, Currency: "RUB", StartDate: date(2025, time.January, 1), TrialEndDate: &trialEnd},
+	}
+
+	charges, err := projectCharges(subscriptions, newSubscriptionHistory(), date(2025, time.March, 10), date(2025, time.March, 31),
+		ProrationNone, NewExchangeRates("RUB", "RUB", nil))
+	if err != nil {
+		t.Fatalf("projectCharges() error = %v", err)
+	}
+
+	want := []struct {
+		date         string
+		serviceName  string
+		runningTotal float64
+	}{
+		{date: "2025-03-10", serviceName: "Gym", runningTotal: 100},
+		{date: "2025-03-17", serviceName: "Gym", runningTotal: 200},
+		{date: "2025-03-20", serviceName: "Netflix", runningTotal: 700},
+		{date: "2025-03-24", serviceName: "Gym", runningTotal: 800},
+	}
+	if len(charges) != len(want) {
+		t.Fatalf("charges = %+v", charges)
+	}
+	for i, charge := range charges {
+		if charge.Date != want[i].date || charge.ServiceName != want[i].serviceName || charge.RunningTotal != want[i].runningTotal {
+			t.Errorf("charge %d = %+v, want %+v", i, charge, want[i])
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"taskTestEffectMobile/internal/models/domain_errors"
+	"taskTestEffectMobile/internal/models/sql_models"
+)
+
+type UserSettingsRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewUserSettingsRepository(db *sql.DB, logger *zap.Logger) *UserSettingsRepository {
+	return &UserSettingsRepository{
+		db:     db,
+		logger: logger.With(zap.String("layer", "repository")),
+	}
+}
+
+func (userSettingsRepository UserSettingsRepository) GetUserSettings(ctx context.Context, userID string) (sql_models.UserSettings, error) {
+	query := `SELECT user_id, time_zone, updated_at FROM user_settings WHERE user_id = $1`
+
+	var settings sql_models.UserSettings
+	err := userSettingsRepository.db.QueryRowContext(ctx, query, userID).Scan(&settings.UserID, &settings.TimeZone, &settings.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return sql_models.UserSettings{}, domain_errors.ErrUserSettingsNotFound
+	}
+	if err != nil {
+		userSettingsRepository.logger.Error("Failed to get user settings",
+			zap.String("query", query),
+			zap.String("userID", userID),
+			zap.Error(err))
+		return sql_models.UserSettings{}, fmt.Errorf("database query failed: %w", err)
+	}
+	return settings, nil
+}
+
+// UpsertUserSettings creates or replaces the settings of the user.
+func (userSettingsRepository UserSettingsRepository) UpsertUserSettings(ctx context.Context, settings sql_models.UserSettings) (sql_models.UserSettings, error) {
+	userSettingsRepository.logger.Debug("Upserting user settings",
+		zap.String("userID", settings.UserID))
+
+	query := `
+		INSERT INTO user_settings (user_id, time_zone)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET time_zone = EXCLUDED.time_zone, updated_at = NOW()
+		RETURNING user_id, time_zone, updated_at
+	`
+	var stored sql_models.UserSettings
+	err := userSettingsRepository.db.QueryRowContext(ctx, query, settings.UserID, settings.TimeZone).Scan(&stored.UserID, &stored.TimeZone, &stored.UpdatedAt)
+	if err != nil {
+		userSettingsRepository.logger.Error("Failed to upsert user settings",
+			zap.String("query", query),
+			zap.String("userID", settings.UserID),
+			zap.Error(err))
+		return sql_models.UserSettings{}, fmt.Errorf("failed to upsert user settings: %w", err)
+	}
+	return stored, nil
+}
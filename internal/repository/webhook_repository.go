@@ -0,0 +1,273 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+	"taskTestEffectMobile/internal/models/domain_errors"
+	"taskTestEffectMobile/internal/models/sql_models"
+	"time"
+)
+
+const webhookEndpointColumns = `id, url, secret, event_types, created_at`
+
+const webhookDeliveryColumns = `id, endpoint_id, event_id, event_type, payload, status, attempts, next_attempt_at, last_status_code, last_error, created_at, delivered_at`
+
+type WebhookRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewWebhookRepository(db *sql.DB, logger *zap.Logger) *WebhookRepository {
+	return &WebhookRepository{
+		db:     db,
+		logger: logger.With(zap.String("layer", "repository")),
+	}
+}
+
+func (webhookRepository WebhookRepository) CreateWebhookEndpoint(ctx context.Context, endpoint sql_models.WebhookEndpoint) (sql_models.WebhookEndpoint, error) {
+	webhookRepository.logger.Debug("Creating webhook endpoint",
+		zap.String("url", endpoint.URL))
+
+	query := `
+		INSERT INTO webhook_endpoints (url, secret, event_types)
+		VALUES ($1, $2, $3)
+		RETURNING ` + webhookEndpointColumns
+
+	row := webhookRepository.db.QueryRowContext(ctx, query, endpoint.URL, endpoint.Secret, pq.Array(endpoint.EventTypes))
+	stored, err := scanWebhookEndpoint(row)
+	if err != nil {
+		webhookRepository.logger.Error("Failed to create webhook endpoint",
+			zap.String("query", query),
+			zap.Error(err))
+		return sql_models.WebhookEndpoint{}, fmt.Errorf("failed to create webhook endpoint: %w", err)
+	}
+	return stored, nil
+}
+
+func (webhookRepository WebhookRepository) ListWebhookEndpoints(ctx context.Context) ([]sql_models.WebhookEndpoint, error) {
+	query := `SELECT ` + webhookEndpointColumns + ` FROM webhook_endpoints ORDER BY created_at`
+
+	var endpoints []sql_models.WebhookEndpoint
+	err := queryRows(ctx, webhookRepository.db, webhookRepository.logger, query, func(rows *sql.Rows) error {
+		endpoint, err := scanWebhookEndpoint(rows)
+		if err != nil {
+			return err
+		}
+		endpoints = append(endpoints, endpoint)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return endpoints, nil
+}
+
+func (webhookRepository WebhookRepository) GetWebhookEndpoint(ctx context.Context, endpointID string) (sql_models.WebhookEndpoint, error) {
+	query := `SELECT ` + webhookEndpointColumns + ` FROM webhook_endpoints WHERE id = $1`
+
+	endpoint, err := scanWebhookEndpoint(webhookRepository.db.QueryRowContext(ctx, query, endpointID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return sql_models.WebhookEndpoint{}, domain_errors.ErrWebhookEndpointNotFound
+	}
+	if err != nil {
+		webhookRepository.logger.Error("Failed to get webhook endpoint",
+			zap.String("query", query),
+			zap.String("endpointID", endpointID),
+			zap.Error(err))
+		return sql_models.WebhookEndpoint{}, fmt.Errorf("database query failed: %w", err)
+	}
+	return endpoint, nil
+}
+
+// DeleteWebhookEndpoint removes the endpoint together with its delivery log.
+func (webhookRepository WebhookRepository) DeleteWebhookEndpoint(ctx context.Context, endpointID string) error {
+	webhookRepository.logger.Debug("Deleting webhook endpoint",
+		zap.String("endpointID", endpointID))
+
+	query := `DELETE FROM webhook_endpoints WHERE id = $1`
+	result, err := webhookRepository.db.ExecContext(ctx, query, endpointID)
+	if err != nil {
+		webhookRepository.logger.Error("Failed to delete webhook endpoint",
+			zap.String("query", query),
+			zap.String("endpointID", endpointID),
+			zap.Error(err))
+		return fmt.Errorf("failed to delete webhook endpoint: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return domain_errors.ErrWebhookEndpointNotFound
+	}
+	return nil
+}
+
+// EnqueueWebhookDeliveries stores pending deliveries in one transaction. An
+// event already queued for an endpoint is skipped.
+func (webhookRepository WebhookRepository) EnqueueWebhookDeliveries(ctx context.Context, deliveries []sql_models.WebhookDelivery) error {
+	webhookRepository.logger.Debug("Enqueuing webhook deliveries",
+		zap.Int("count", len(deliveries)))
+
+	return runInTx(ctx, webhookRepository.db, webhookRepository.logger, func(tx *sql.Tx) error {
+		query := `
+			INSERT INTO webhook_deliveries (endpoint_id, event_id, event_type, payload, next_attempt_at)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (endpoint_id, event_id) DO NOTHING
+		`
+		for _, delivery := range deliveries {
+			_, err := tx.ExecContext(ctx, query,
+				delivery.EndpointID, delivery.EventID, delivery.EventType, string(delivery.Payload), delivery.NextAttemptAt)
+			if err != nil {
+				webhookRepository.logger.Error("Failed to enqueue webhook delivery",
+					zap.String("query", query),
+					zap.String("endpointID", delivery.EndpointID),
+					zap.String("eventID", delivery.EventID),
+					zap.Error(err))
+				return fmt.Errorf("failed to enqueue webhook delivery: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// ClaimWebhookDeliveries returns up to limit pending deliveries that are due
+// at now and postpones them by lease, so a delivery is not sent twice while
+// an attempt is in flight. Rows locked by another instance are skipped.
+func (webhookRepository WebhookRepository) ClaimWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]sql_models.WebhookDelivery, error) {
+	query := `
+		UPDATE webhook_deliveries
+		SET next_attempt_at = $2
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= $1
+			ORDER BY next_attempt_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + webhookDeliveryColumns
+
+	var deliveries []sql_models.WebhookDelivery
+	err := queryRows(ctx, webhookRepository.db, webhookRepository.logger, query, func(rows *sql.Rows) error {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return err
+		}
+		deliveries = append(deliveries, delivery)
+		return nil
+	}, now, now.Add(lease), limit)
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// UpdateWebhookDelivery saves the status and the outcome of the last attempt.
+func (webhookRepository WebhookRepository) UpdateWebhookDelivery(ctx context.Context, delivery sql_models.WebhookDelivery) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $2, attempts = $3, next_attempt_at = $4, last_status_code = $5, last_error = $6, delivered_at = $7
+		WHERE id = $1
+	`
+	result, err := webhookRepository.db.ExecContext(ctx, query,
+		delivery.ID, delivery.Status, delivery.Attempts, delivery.NextAttemptAt, delivery.LastStatusCode, delivery.LastError, delivery.DeliveredAt)
+	if err != nil {
+		webhookRepository.logger.Error("Failed to update webhook delivery",
+			zap.String("query", query),
+			zap.String("deliveryID", delivery.ID),
+			zap.Error(err))
+		return fmt.Errorf("failed to update webhook delivery: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return domain_errors.ErrWebhookDeliveryNotFound
+	}
+	return nil
+}
+
+// ListWebhookDeliveries returns the delivery log of the endpoint, newest first.
+func (webhookRepository WebhookRepository) ListWebhookDeliveries(ctx context.Context, endpointID string) ([]sql_models.WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries WHERE endpoint_id = $1 ORDER BY created_at DESC`
+
+	var deliveries []sql_models.WebhookDelivery
+	err := queryRows(ctx, webhookRepository.db, webhookRepository.logger, query, func(rows *sql.Rows) error {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return err
+		}
+		deliveries = append(deliveries, delivery)
+		return nil
+	}, endpointID)
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (webhookRepository WebhookRepository) GetWebhookDelivery(ctx context.Context, endpointID, deliveryID string) (sql_models.WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries WHERE id = $1 AND endpoint_id = $2`
+
+	delivery, err := scanWebhookDelivery(webhookRepository.db.QueryRowContext(ctx, query, deliveryID, endpointID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return sql_models.WebhookDelivery{}, domain_errors.ErrWebhookDeliveryNotFound
+	}
+	if err != nil {
+		webhookRepository.logger.Error("Failed to get webhook delivery",
+			zap.String("query", query),
+			zap.String("deliveryID", deliveryID),
+			zap.Error(err))
+		return sql_models.WebhookDelivery{}, fmt.Errorf("database query failed: %w", err)
+	}
+	return delivery, nil
+}
+
+func scanWebhookEndpoint(row rowScanner) (sql_models.WebhookEndpoint, error) {
+	var endpoint sql_models.WebhookEndpoint
+	if err := row.Scan(&endpoint.ID, &endpoint.URL, &endpoint.Secret, pq.Array(&endpoint.EventTypes), &endpoint.CreatedAt); err != nil {
+		return sql_models.WebhookEndpoint{}, err
+	}
+	if endpoint.EventTypes == nil {
+		endpoint.EventTypes = []string{}
+	}
+	return endpoint, nil
+}
+
+func scanWebhookDelivery(row rowScanner) (sql_models.WebhookDelivery, error) {
+	var delivery sql_models.WebhookDelivery
+	var payload []byte
+	var nextAttemptAt, deliveredAt sql.NullTime
+	var lastStatusCode sql.NullInt64
+	var lastError sql.NullString
+
+	if err := row.Scan(
+		&delivery.ID,
+		&delivery.EndpointID,
+		&delivery.EventID,
+		&delivery.EventType,
+		&payload,
+		&delivery.Status,
+		&delivery.Attempts,
+		&nextAttemptAt,
+		&lastStatusCode,
+		&lastError,
+		&delivery.CreatedAt,
+		&deliveredAt,
+	); err != nil {
+		return sql_models.WebhookDelivery{}, err
+	}
+
+	delivery.Payload = payload
+	if nextAttemptAt.Valid {
+		delivery.NextAttemptAt = &nextAttemptAt.Time
+	}
+	if lastStatusCode.Valid {
+		code := int(lastStatusCode.Int64)
+		delivery.LastStatusCode = &code
+	}
+	if lastError.Valid {
+		delivery.LastError = &lastError.String
+	}
+	if deliveredAt.Valid {
+		delivery.DeliveredAt = &deliveredAt.Time
+	}
+	return delivery, nil
+}
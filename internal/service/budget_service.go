@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"math"
+	"taskTestEffectMobile/internal/models/json_models"
+	"taskTestEffectMobile/internal/models/sql_models"
+	"taskTestEffectMobile/internal/repository"
+	"taskTestEffectMobile/internal/utils"
+	"time"
+)
+
+// budgetThresholds are the shares of a budget, in percent, that raise an alert
+// once the projected spend of a month reaches them.
+var budgetThresholds = []int{80, 100}
+
+// budgetQueueSize is how many users can wait for evaluation before further
+// checks are left to the periodic pass.
+const budgetQueueSize = 256
+
+type BudgetService struct {
+	repo          repository.BudgetStorage
+	subscriptions repository.SubscriptionStorage
+	rates         ExchangeRateService
+	users         UserSettingsService
+	proration     string
+	// queue holds the users whose budgets CheckBudgets asked to evaluate.
+	queue  chan string
+	logger *zap.Logger
+}
+
+func NewBudgetService(repo repository.BudgetStorage, subscriptions repository.SubscriptionStorage, rates ExchangeRateService, users UserSettingsService, proration string, logger *zap.Logger) *BudgetService {
+	return &BudgetService{
+		repo:          repo,
+		subscriptions: subscriptions,
+		rates:         rates,
+		users:         users,
+		proration:     proration,
+		queue:         make(chan string, budgetQueueSize),
+		logger:        logger.With(zap.String("layer", "service")),
+	}
+}
+
+// SetBudget creates the budget of the user for the service, or for all
+// subscriptions when no service is given, replacing the previous amount.
+// The budgets of the user are queued for evaluation.
+func (budgetService BudgetService) SetBudget(ctx context.Context, userID uuid.UUID, input json_models.BudgetInput) (sql_models.Budget, error) {
+	budgetService.logger.Info("Setting budget",
+		zap.String("userID", userID.String()),
+		zap.Any("service", input.ServiceName))
+
+	budget := sql_models.Budget{
+		UserID:      userID.String(),
+		ServiceName: input.ServiceName,
+		Amount:      input.Amount,
+		Currency:    budgetService.rates.BaseCurrency(),
+	}
+	if input.Currency != nil {
+		budget.Currency = *input.Currency
+	}
+
+	stored, err := budgetService.repo.UpsertBudget(ctx, budget)
+	if err != nil {
+		budgetService.logger.Error("Failed to set budget",
+			zap.String("userID", userID.String()),
+			zap.Error(err))
+		return sql_models.Budget{}, fmt.Errorf("failed to set budget: %w", err)
+	}
+
+	budgetService.CheckBudgets(stored.UserID)
+	return stored, nil
+}
+
+// ListBudgets returns the budgets of the user with the projected spend of the
+// current month in the user's time zone.
+func (budgetService BudgetService) ListBudgets(ctx context.Context, userID uuid.UUID) ([]json_models.BudgetStatus, error) {
+	budgets, err := budgetService.repo.ListBudgets(ctx, userID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list budgets: %w", err)
+	}
+
+	month := utils.MonthStart(budgetService.users.Now(ctx, userID.String()))
+	statuses := make([]json_models.BudgetStatus, 0, len(budgets))
+	for _, budget := range budgets {
+		spent, err := budgetService.projectedSpend(ctx, budget, month)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, json_models.BudgetStatus{
+			Budget:  budget,
+			Month:   month.Format(utils.MonthLayout),
+			Spent:   spent,
+			Percent: math.Round(spent/budget.Amount*1000) / 10,
+		})
+	}
+	return statuses, nil
+}
+
+func (budgetService BudgetService) DeleteBudget(ctx context.Context, userID, budgetID uuid.UUID) error {
+	budgetService.logger.Info("Deleting budget",
+		zap.String("userID", userID.String()),
+		zap.String("budgetID", budgetID.String()))
+
+	if err := budgetService.repo.DeleteBudget(ctx, userID.String(), budgetID.String()); err != nil {
+		return fmt.Errorf("failed to delete budget: %w", err)
+	}
+	return nil
+}
+
+// ListBudgetAlerts returns the alerts recorded for the user, newest first.
+func (budgetService BudgetService) ListBudgetAlerts(ctx context.Context, userID uuid.UUID) ([]sql_models.BudgetAlert, error) {
+	alerts, err := budgetService.repo.ListBudgetAlerts(ctx, userID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list budget alerts: %w", err)
+	}
+	if alerts == nil {
+		alerts = []sql_models.BudgetAlert{}
+	}
+	return alerts, nil
+}
+
+// EvaluateBudgets projects the spend of the current month for every budget of
+// the user and records an alert for each threshold it reaches. A threshold is
+// alerted once per budget and month.
+func (budgetService BudgetService) EvaluateBudgets(ctx context.Context, userID string) error {
+	budgets, err := budgetService.repo.ListBudgets(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to list budgets: %w", err)
+	}
+
+	month := utils.MonthStart(budgetService.users.Now(ctx, userID))
+	for _, budget := range budgets {
+		spent, err := budgetService.projectedSpend(ctx, budget, month)
+		if err != nil {
+			return err
+		}
+		for _, threshold := range budgetThresholds {
+			if spent*100 < budget.Amount*float64(threshold) {
+				break
+			}
+			created, err := budgetService.repo.RecordBudgetAlert(ctx, sql_models.BudgetAlert{
+				BudgetID:    budget.ID,
+				UserID:      budget.UserID,
+				ServiceName: budget.ServiceName,
+				Month:       month,
+				Threshold:   threshold,
+				Spent:       spent,
+				Amount:      budget.Amount,
+				Currency:    budget.Currency,
+			})
+			if err != nil {
+				return err
+			}
+			if created {
+				budgetService.logger.Info("Budget threshold reached",
+					zap.String("userID", userID),
+					zap.String("budgetID", budget.ID),
+					zap.Int("threshold", threshold),
+					zap.Float64("spent", spent))
+			}
+		}
+	}
+	return nil
+}
+
+// CheckBudgets queues the budgets of the user for evaluation by
+// RunEvaluation after a change to the user's subscriptions, so the change
+// does not wait for it. When the queue is full the check is dropped and left
+// to the next periodic pass.
+func (budgetService BudgetService) CheckBudgets(userID string) {
+	select {
+	case budgetService.queue <- userID:
+	default:
+		budgetService.logger.Warn("Budget evaluation queue is full, leaving the user to the periodic pass",
+			zap.String("userID", userID))
+	}
+}
+
+// RunEvaluation evaluates the budgets queued by CheckBudgets until ctx is
+// done. Every interval it also evaluates the budgets of all users, so alerts
+// follow what changes without a subscription change, such as exchange rates
+// or the start of a month. Zero interval only serves the queue.
+func (budgetService BudgetService) RunEvaluation(ctx context.Context, interval time.Duration) {
+	budgetService.logger.Info("Starting budget evaluation",
+		zap.Duration("interval", interval))
+
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case userID := <-budgetService.queue:
+			budgetService.evaluate(ctx, userID)
+		case <-tick:
+			userIDs, err := budgetService.repo.ListBudgetUserIDs(ctx)
+			if err != nil {
+				budgetService.logger.Error("Failed to list users with budgets",
+					zap.Error(err))
+				continue
+			}
+			for _, userID := range userIDs {
+				budgetService.evaluate(ctx, userID)
+			}
+		}
+	}
+}
+
+// evaluate runs EvaluateBudgets and logs a failure.
+func (budgetService BudgetService) evaluate(ctx context.Context, userID string) {
+	if err := budgetService.EvaluateBudgets(ctx, userID); err != nil {
+		budgetService.logger.Error("Failed to evaluate budgets",
+			zap.String("userID", userID),
+			zap.Error(err))
+	}
+}
+
+// projectedSpend is the cost of the month in the budget currency, aggregated
+// the same way as the cost report.
+func (budgetService BudgetService) projectedSpend(ctx context.Context, budget sql_models.Budget, month time.Time) (float64, error) {
+	rates, err := budgetService.rates.Converter(ctx, budget.Currency)
+	if err != nil {
+		return 0, err
+	}
+
+	filter := json_models.CostFilter{
+		UserID:      &budget.UserID,
+		ServiceName: budget.ServiceName,
+		StartDate:   month,
+		EndDate:     utils.MonthEnd(month),
+		Proration:   budgetService.proration,
+	}
+	report, err := budgetService.subscriptions.GetSubscriptionsCost(ctx, filter, rates)
+	if err != nil {
+		return 0, fmt.Errorf("failed to project budget spend: %w", err)
+	}
+	return report.TotalCost, nil
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"taskTestEffectMobile/internal/models/json_models"
+	"taskTestEffectMobile/internal/models/sql_models"
+	"taskTestEffectMobile/internal/repository"
+	"testing"
+	"time"
+)
+
+func TestEvaluateBudgets(t *testing.T) {
+	ctx := context.Background()
+	logger := zap.NewNop()
+	subscriptionRepo := repository.NewInMemorySubscriptionRepository(logger)
+	rates := NewExchangeRateService(repository.NewInMemoryExchangeRateRepository(logger), "RUB", logger)
+	users := NewUserSettingsService(repository.NewInMemoryUserSettingsRepository(logger), time.UTC, logger)
+	budgetService := NewBudgetService(repository.NewInMemoryBudgetRepository(logger), subscriptionRepo, *rates, *users, repository.ProrationNone, logger)
+
+	userID := uuid.New()
+	insert := func(serviceName string, price int) {
+		t.Helper()
+		_, err := subscriptionRepo.InsertSubscription(ctx, sql_models.Subscription{
+			ServiceName: serviceName,
+			Price:       price,
+			UserID:      userID.String(),
+			Currency:    "RUB",
+			StartDate:   time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC),
+		}, false)
+		if err != nil {
+			t.Fatalf("InsertSubscription() error = %v", err)
+		}
+	}
+	alertThresholds := func() []int {
+		t.Helper()
+		alerts, err := budgetService.ListBudgetAlerts(ctx, userID)
+		if err != nil {
+			t.Fatalf("ListBudgetAlerts() error = %v", err)
+		}
+		var thresholds []int
+		for _, alert := range alerts {
+			thresholds = append(thresholds, alert.Threshold)
+		}
+		return thresholds
+	}
+
+	insert("Yandex Plus", 400)
+	if _, err := budgetService.SetBudget(ctx, userID, json_models.BudgetInput{Amount: 500}); err != nil {
+		t.Fatalf("SetBudget() error = %v", err)
+	}
+	if err := budgetService.EvaluateBudgets(ctx, userID.String()); err != nil {
+		t.Fatalf("EvaluateBudgets() error = %v", err)
+	}
+	if thresholds := alertThresholds(); len(thresholds) != 1 || thresholds[0] != 80 {
+		t.Fatalf("alerts at 80%% of the budget = %v", thresholds)
+	}
+
+	insert("Kinopoisk", 200)
+	for i := 0; i < 2; i++ {
+		if err := budgetService.EvaluateBudgets(ctx, userID.String()); err != nil {
+			t.Fatalf("EvaluateBudgets() error = %v", err)
+		}
+	}
+	if thresholds := alertThresholds(); len(thresholds) != 2 {
+		t.Errorf("alerts after crossing the budget = %v, want one per threshold", thresholds)
+	}
+}
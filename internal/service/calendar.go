@@ -0,0 +1,194 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"taskTestEffectMobile/internal/models/sql_models"
+	"taskTestEffectMobile/internal/repository"
+	"taskTestEffectMobile/internal/utils"
+	"time"
+)
+
+const (
+	calendarDateLayout  = "20060102"
+	calendarStampLayout = "20060102T150405Z"
+	// calendarLineLimit is the longest content line, in octets, before folding.
+	calendarLineLimit = 75
+	// reminderHour is the local hour reminders fire at on their day.
+	reminderHour = 9
+)
+
+// renewalSchedule is the recurrence of the charges of one subscription.
+type renewalSchedule struct {
+	// first is the first charge that follows the recurrence rule.
+	first time.Time
+	// extra is a first charge off the rule, made on the start date of a
+	// subscription that starts between two renewals.
+	extra *time.Time
+	rule  string
+}
+
+// renewalScheduleOf describes when the subscription is charged. Charges in
+// trial months are left out and a paused subscription has no renewals after
+// the current month. The stub before the first renewal of a plan anchored
+// after its start day is charged only under daily proration. It reports false
+// when nothing is ever charged.
+func renewalScheduleOf(sub sql_models.Subscription, now time.Time, proration string) (renewalSchedule, bool) {
+	from := sub.StartDate
+	if sub.TrialEndDate != nil {
+		if paid := utils.MonthStart(*sub.TrialEndDate).AddDate(0, 1, 0); paid.After(from) {
+			from = paid
+		}
+	}
+	until := sub.EndDate
+	if sub.Status == sql_models.StatusPaused {
+		if monthEnd := utils.MonthEnd(now); until == nil || monthEnd.Before(*until) {
+			until = &monthEnd
+		}
+	}
+
+	var schedule renewalSchedule
+	if months := sub.IntervalMonths(); months > 0 {
+		startMonth := utils.MonthStart(sub.StartDate)
+		k := (utils.MonthsBetween(startMonth, from) - 1) / months
+		schedule.first = sub.AnchorDate(startMonth.AddDate(0, k*months, 0))
+		for schedule.first.Before(from) {
+			k++
+			schedule.first = sub.AnchorDate(startMonth.AddDate(0, k*months, 0))
+		}
+		stub := sub.AnchorDate(startMonth).After(sub.StartDate)
+		if from.Equal(sub.StartDate) && !schedule.first.Equal(sub.StartDate) && (!stub || proration == repository.ProrationDaily) {
+			start := sub.StartDate
+			schedule.extra = &start
+		}
+		schedule.rule = fmt.Sprintf("FREQ=MONTHLY;INTERVAL=%d;%s", months, byMonthDay(sub.AnchorDay()))
+	} else {
+		step := 7 * sub.IntervalCount()
+		schedule.first = sub.StartDate
+		if from.After(schedule.first) {
+			days := int(from.Sub(schedule.first).Hours() / 24)
+			schedule.first = schedule.first.AddDate(0, 0, (days+step-1)/step*step)
+		}
+		schedule.rule = fmt.Sprintf("FREQ=WEEKLY;INTERVAL=%d", sub.IntervalCount())
+	}
+
+	if until != nil {
+		if until.Before(schedule.first) {
+			if schedule.extra == nil || schedule.extra.After(*until) {
+				return renewalSchedule{}, false
+			}
+			return renewalSchedule{first: *schedule.extra}, true
+		}
+		schedule.rule += ";UNTIL=" + until.Format(calendarDateLayout)
+	}
+	return schedule, true
+}
+
+// byMonthDay returns the RRULE parts for renewals on day. Days past the 28th
+// take the last existing day of {28..day}, so the 31st falls on the last day
+// of shorter months.
+func byMonthDay(day int) string {
+	if day <= 28 {
+		return fmt.Sprintf("BYMONTHDAY=%d", day)
+	}
+	days := make([]string, 0, day-27)
+	for d := 28; d <= day; d++ {
+		days = append(days, fmt.Sprint(d))
+	}
+	return "BYMONTHDAY=" + strings.Join(days, ",") + ";BYSETPOS=-1"
+}
+
+// renderCalendar builds an RFC 5545 calendar with one recurring all-day
+// event per subscription. reminderDays adds a reminder that many days before
+// each renewal.
+func renderCalendar(subscriptions []sql_models.Subscription, reminderDays *int, now time.Time, proration string) []byte {
+	var calendar strings.Builder
+	line := func(format string, args ...interface{}) {
+		writeCalendarLine(&calendar, fmt.Sprintf(format, args...))
+	}
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//taskTestEffectMobile//Subscription renewals//EN")
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:PUBLISH")
+	line("X-WR-CALNAME:Subscription renewals")
+	for _, sub := range subscriptions {
+		schedule, ok := renewalScheduleOf(sub, now, proration)
+		if !ok {
+			continue
+		}
+
+		line("BEGIN:VEVENT")
+		line("UID:%s@subscriptions", sub.ID)
+		line("DTSTAMP:%s", now.UTC().Format(calendarStampLayout))
+		line("DTSTART;VALUE=DATE:%s", schedule.first.Format(calendarDateLayout))
+		if schedule.rule != "" {
+			line("RRULE:%s", schedule.rule)
+		}
+		if schedule.extra != nil {
+			line("RDATE;VALUE=DATE:%s", schedule.extra.Format(calendarDateLayout))
+		}
+		line("SUMMARY:%s", escapeCalendarText(fmt.Sprintf("%s renewal: %d %s", sub.ServiceName, sub.Price, sub.Currency)))
+		line("DESCRIPTION:%s", escapeCalendarText(describeInterval(sub)))
+		line("TRANSP:TRANSPARENT")
+		if reminderDays != nil {
+			line("BEGIN:VALARM")
+			line("ACTION:DISPLAY")
+			line("DESCRIPTION:%s", escapeCalendarText(sub.ServiceName+" renews soon"))
+			line("TRIGGER:%s", reminderTrigger(*reminderDays))
+			line("END:VALARM")
+		}
+		line("END:VEVENT")
+	}
+	line("END:VCALENDAR")
+	return []byte(calendar.String())
+}
+
+// reminderTrigger fires days before the event at reminderHour local time.
+func reminderTrigger(days int) string {
+	hours := reminderHour - 24*days
+	if hours < 0 {
+		return fmt.Sprintf("-PT%dH", -hours)
+	}
+	return fmt.Sprintf("PT%dH", hours)
+}
+
+func describeInterval(sub sql_models.Subscription) string {
+	unit := sub.BillingInterval
+	if unit == "" {
+		unit = sql_models.IntervalMonth
+	}
+	if count := sub.IntervalCount(); count > 1 {
+		return fmt.Sprintf("Charged every %d %ss", count, unit)
+	}
+	return "Charged every " + unit
+}
+
+// escapeCalendarText escapes a TEXT value.
+func escapeCalendarText(text string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(text)
+}
+
+// writeCalendarLine writes a content line folded at calendarLineLimit octets
+// without splitting UTF-8 sequences.
+func writeCalendarLine(calendar *strings.Builder, content string) {
+	limit := calendarLineLimit
+	for len(content) > limit {
+		cut := limit
+		for cut > 0 && !utf8RuneStart(content[cut]) {
+			cut--
+		}
+		calendar.WriteString(content[:cut])
+		calendar.WriteString("\r\n ")
+		content = content[cut:]
+		// Continuation lines start with a space, which counts toward the limit.
+		limit = calendarLineLimit - 1
+	}
+	calendar.WriteString(content)
+	calendar.WriteString("\r\n")
+}
+
+func utf8RuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
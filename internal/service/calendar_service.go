@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"taskTestEffectMobile/internal/models/json_models"
+	"taskTestEffectMobile/internal/models/sql_models"
+	"taskTestEffectMobile/internal/repository"
+)
+
+// calendarTokenBytes is the entropy of a feed token.
+const calendarTokenBytes = 32
+
+type CalendarService struct {
+	repo          repository.CalendarTokenStorage
+	subscriptions SubscriptionService
+	users         UserSettingsService
+	logger        *zap.Logger
+}
+
+func NewCalendarService(repo repository.CalendarTokenStorage, subscriptions SubscriptionService, users UserSettingsService, logger *zap.Logger) *CalendarService {
+	return &CalendarService{
+		repo:          repo,
+		subscriptions: subscriptions,
+		users:         users,
+		logger:        logger.With(zap.String("layer", "service")),
+	}
+}
+
+// IssueCalendarToken creates a new secret token for the feed of the user and
+// revokes the previous one. Only a hash of the token is stored.
+func (calendarService CalendarService) IssueCalendarToken(ctx context.Context, userID uuid.UUID, input json_models.CalendarTokenInput) (string, error) {
+	calendarService.logger.Info("Issuing calendar token",
+		zap.String("userID", userID.String()))
+
+	secret := make([]byte, calendarTokenBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate calendar token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(secret)
+
+	err := calendarService.repo.SaveCalendarToken(ctx, sql_models.CalendarToken{
+		UserID:       userID.String(),
+		TokenHash:    hashCalendarToken(token),
+		ReminderDays: input.ReminderDaysBefore,
+	})
+	if err != nil {
+		calendarService.logger.Error("Failed to issue calendar token",
+			zap.String("userID", userID.String()),
+			zap.Error(err))
+		return "", fmt.Errorf("failed to issue calendar token: %w", err)
+	}
+	return token, nil
+}
+
+// RevokeCalendarToken disables the feed of the user.
+func (calendarService CalendarService) RevokeCalendarToken(ctx context.Context, userID uuid.UUID) error {
+	calendarService.logger.Info("Revoking calendar token",
+		zap.String("userID", userID.String()))
+
+	if err := calendarService.repo.DeleteCalendarToken(ctx, userID.String()); err != nil {
+		return fmt.Errorf("failed to revoke calendar token: %w", err)
+	}
+	return nil
+}
+
+// CalendarFeed renders the iCalendar feed the token grants access to.
+func (calendarService CalendarService) CalendarFeed(ctx context.Context, token string) ([]byte, error) {
+	stored, err := calendarService.repo.GetCalendarToken(ctx, hashCalendarToken(token))
+	if err != nil {
+		return nil, err
+	}
+
+	userID, err := uuid.Parse(stored.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID in calendar token: %w", err)
+	}
+	subscriptions, err := calendarService.subscriptions.GetUserSubscriptions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := calendarService.users.Now(ctx, stored.UserID)
+	return renderCalendar(subscriptions, stored.ReminderDays, now, calendarService.subscriptions.proration), nil
+}
+
+func hashCalendarToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
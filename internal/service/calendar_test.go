@@ -0,0 +1,109 @@
+package service
+
+import (
+	"strings"
+	"taskTestEffectMobile/internal/models/sql_models"
+	"taskTestEffectMobile/internal/repository"
+	"testing"
+	"time"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestRenewalScheduleOf(t *testing.T) {
+	now := date(2025, time.March, 1)
+	trialEnd := date(2025, time.March, 31)
+	endDate := date(2025, time.March, 12)
+	extra := date(2025, time.March, 10)
+	lateStart := date(2025, time.March, 20)
+
+	tests := []struct {
+		name      string
+		sub       sql_models.Subscription
+		proration string
+		want      renewalSchedule
+		wantOK    bool
+	}{
+		{
+			name:   "monthly plan renews on the start day",
+			sub:    sql_models.Subscription{StartDate: date(2025, time.March, 10)},
+			want:   renewalSchedule{first: date(2025, time.March, 10), rule: "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=10"},
+			wantOK: true,
+		},
+		{
+			name:      "prorated stub adds an extra date",
+			sub:       sql_models.Subscription{StartDate: date(2025, time.March, 10), BillingAnchorDay: 15},
+			proration: repository.ProrationDaily,
+			want:      renewalSchedule{first: date(2025, time.March, 15), extra: &extra, rule: "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=15"},
+			wantOK:    true,
+		},
+		{
+			name:   "free stub has no extra date",
+			sub:    sql_models.Subscription{StartDate: date(2025, time.March, 10), BillingAnchorDay: 15},
+			want:   renewalSchedule{first: date(2025, time.March, 15), rule: "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=15"},
+			wantOK: true,
+		},
+		{
+			name:   "anchor before the start day charges on the start date",
+			sub:    sql_models.Subscription{StartDate: date(2025, time.March, 20), BillingAnchorDay: 15},
+			want:   renewalSchedule{first: date(2025, time.April, 15), extra: &lateStart, rule: "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=15"},
+			wantOK: true,
+		},
+		{
+			name:   "weekly plan skips trial months",
+			sub:    sql_models.Subscription{StartDate: date(2025, time.March, 3), TrialEndDate: &trialEnd, BillingInterval: sql_models.IntervalWeek},
+			want:   renewalSchedule{first: date(2025, time.April, 7), rule: "FREQ=WEEKLY;INTERVAL=1"},
+			wantOK: true,
+		},
+		{
+			name:      "ends before the first renewal",
+			sub:       sql_models.Subscription{StartDate: date(2025, time.March, 10), EndDate: &endDate, BillingAnchorDay: 15},
+			proration: repository.ProrationDaily,
+			want:      renewalSchedule{first: date(2025, time.March, 10)},
+			wantOK:    true,
+		},
+		{
+			name: "nothing is charged",
+			sub:  sql_models.Subscription{StartDate: date(2025, time.March, 3), EndDate: &endDate, TrialEndDate: &trialEnd},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := renewalScheduleOf(tt.sub, now, tt.proration)
+			if ok != tt.wantOK {
+				t.Fatalf("renewalScheduleOf() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !got.first.Equal(tt.want.first) || got.rule != tt.want.rule ||
+				(got.extra == nil) != (tt.want.extra == nil) || (got.extra != nil && !got.extra.Equal(*tt.want.extra)) {
+				t.Errorf("renewalScheduleOf() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestByMonthDay(t *testing.T) {
+	if got := byMonthDay(15); got != "BYMONTHDAY=15" {
+		t.Errorf("byMonthDay(15) = %s", got)
+	}
+	if got := byMonthDay(31); got != "BYMONTHDAY=28,29,30,31;BYSETPOS=-1" {
+		t.Errorf("byMonthDay(31) = %s", got)
+	}
+}
+
+func TestWriteCalendarLine(t *testing.T) {
+	var calendar strings.Builder
+	writeCalendarLine(&calendar, "SUMMARY:"+strings.Repeat("ж", 50))
+
+	lines := strings.Split(strings.TrimSuffix(calendar.String(), "\r\n"), "\r\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[1], " ") {
+		t.Fatalf("folded lines = %q", lines)
+	}
+	for _, line := range lines {
+		if len(line) > calendarLineLimit || !strings.HasSuffix(line, "ж") {
+			t.Errorf("line %q is %d octets or splits a character", line, len(line))
+		}
+	}
+}
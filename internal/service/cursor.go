@@ -0,0 +1,35 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"taskTestEffectMobile/internal/models/domain_errors"
+	"taskTestEffectMobile/internal/models/json_models"
+)
+
+func encodeCursor(cursor json_models.ListCursor) (string, error) {
+	raw, err := json.Marshal(cursor)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// decodeCursor parses an opaque cursor and checks it was issued for the same ordering.
+func decodeCursor(value, sort, order string) (*json_models.ListCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, domain_errors.WithDetail(domain_errors.ErrValidation, "malformed cursor")
+	}
+
+	var cursor json_models.ListCursor
+	if err := json.Unmarshal(raw, &cursor); err != nil || cursor.ID == "" {
+		return nil, domain_errors.WithDetail(domain_errors.ErrValidation, "malformed cursor")
+	}
+
+	if cursor.Sort != sort || cursor.Order != order {
+		return nil, domain_errors.WithDetail(domain_errors.ErrValidation, "cursor was issued for a different sort order")
+	}
+	return &cursor, nil
+}
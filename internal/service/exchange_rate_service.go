@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"go.uber.org/zap"
+	"taskTestEffectMobile/internal/models/domain_errors"
+	"taskTestEffectMobile/internal/models/json_models"
+	"taskTestEffectMobile/internal/models/sql_models"
+	"taskTestEffectMobile/internal/repository"
+	"taskTestEffectMobile/internal/utils"
+)
+
+type ExchangeRateService struct {
+	repo         repository.ExchangeRateStorage
+	baseCurrency string
+	logger       *zap.Logger
+}
+
+func NewExchangeRateService(repo repository.ExchangeRateStorage, baseCurrency string, logger *zap.Logger) *ExchangeRateService {
+	return &ExchangeRateService{
+		repo:         repo,
+		baseCurrency: baseCurrency,
+		logger:       logger.With(zap.String("layer", "service")),
+	}
+}
+
+// BaseCurrency returns the currency all rates are quoted in.
+func (exchangeRateService ExchangeRateService) BaseCurrency() string {
+	return exchangeRateService.baseCurrency
+}
+
+// ImportExchangeRates stores uploaded rates, replacing rates of the same
+// currency and month, and returns how many rows were stored.
+func (exchangeRateService ExchangeRateService) ImportExchangeRates(ctx context.Context, inputs []json_models.ExchangeRateInput) (int, error) {
+	exchangeRateService.logger.Info("Importing exchange rates",
+		zap.Int("count", len(inputs)))
+
+	if len(inputs) == 0 {
+		return 0, domain_errors.WithDetail(domain_errors.ErrValidation, "no exchange rates to import")
+	}
+
+	rates := make([]sql_models.ExchangeRate, 0, len(inputs))
+	for i, input := range inputs {
+		if input.Currency == exchangeRateService.baseCurrency {
+			return 0, domain_errors.WithDetail(domain_errors.ErrValidation, "row %d: %s is the base currency, its rate is always 1", i+1, input.Currency)
+		}
+		validFrom, err := utils.ParseDate(input.ValidFrom)
+		if err != nil {
+			return 0, domain_errors.WithDetail(domain_errors.ErrValidation, "row %d: invalid valid_from format", i+1)
+		}
+		// Rates are monthly, so a full date applies from the start of its month.
+		rates = append(rates, sql_models.ExchangeRate{Currency: input.Currency, ValidFrom: utils.MonthStart(validFrom), Rate: input.Rate})
+	}
+
+	if err := exchangeRateService.repo.UpsertExchangeRates(ctx, rates); err != nil {
+		exchangeRateService.logger.Error("Failed to import exchange rates",
+			zap.Error(err))
+		return 0, fmt.Errorf("failed to import exchange rates: %w", err)
+	}
+
+	exchangeRateService.logger.Info("Exchange rates imported",
+		zap.Int("count", len(rates)))
+	return len(rates), nil
+}
+
+func (exchangeRateService ExchangeRateService) ListExchangeRates(ctx context.Context) ([]sql_models.ExchangeRate, error) {
+	rates, err := exchangeRateService.repo.ListExchangeRates(ctx)
+	if err != nil {
+		exchangeRateService.logger.Error("Failed to list exchange rates",
+			zap.Error(err))
+		return nil, fmt.Errorf("failed to list exchange rates: %w", err)
+	}
+	if rates == nil {
+		rates = []sql_models.ExchangeRate{}
+	}
+	return rates, nil
+}
+
+// Converter returns an ExchangeRates converting into target, or into the
+// base currency when target is empty.
+func (exchangeRateService ExchangeRateService) Converter(ctx context.Context, target string) (*repository.ExchangeRates, error) {
+	if target == "" {
+		target = exchangeRateService.baseCurrency
+	}
+
+	rates, err := exchangeRateService.repo.ListExchangeRates(ctx)
+	if err != nil {
+		exchangeRateService.logger.Error("Failed to load exchange rates",
+			zap.Error(err))
+		return nil, fmt.Errorf("failed to load exchange rates: %w", err)
+	}
+	return repository.NewExchangeRates(exchangeRateService.baseCurrency, target, rates), nil
+}
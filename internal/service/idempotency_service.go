@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"go.uber.org/zap"
+	"taskTestEffectMobile/internal/models/domain_errors"
+	"taskTestEffectMobile/internal/models/sql_models"
+	"taskTestEffectMobile/internal/repository"
+	"time"
+)
+
+type IdempotencyService struct {
+	repo   repository.IdempotencyStorage
+	ttl    time.Duration
+	logger *zap.Logger
+}
+
+func NewIdempotencyService(repo repository.IdempotencyStorage, ttl time.Duration, logger *zap.Logger) *IdempotencyService {
+	return &IdempotencyService{
+		repo:   repo,
+		ttl:    ttl,
+		logger: logger.With(zap.String("layer", "service")),
+	}
+}
+
+// HashRequest fingerprints a request so a reused key with another body can be detected.
+func HashRequest(method, path string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method + " " + path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Begin reserves the key for a new request. It returns the stored record when
+// the request is a retry whose response should be replayed.
+func (idempotencyService IdempotencyService) Begin(ctx context.Context, key, requestHash string) (*sql_models.IdempotencyRecord, error) {
+	record, reserved, err := idempotencyService.repo.Reserve(ctx, key, requestHash, time.Now().Add(idempotencyService.ttl))
+	if err != nil {
+		idempotencyService.logger.Error("Failed to reserve idempotency key",
+			zap.String("key", key),
+			zap.Error(err))
+		return nil, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+	if reserved {
+		return nil, nil
+	}
+
+	if record.RequestHash != requestHash {
+		idempotencyService.logger.Warn("Idempotency key reused with a different request",
+			zap.String("key", key))
+		return nil, domain_errors.ErrIdempotencyKeyReused
+	}
+	if !record.Completed() {
+		idempotencyService.logger.Warn("Idempotent request is still in progress",
+			zap.String("key", key))
+		return nil, domain_errors.ErrIdempotencyInProgress
+	}
+
+	idempotencyService.logger.Info("Replaying idempotent response",
+		zap.String("key", key),
+		zap.Int("statusCode", record.StatusCode))
+	return &record, nil
+}
+
+// Finish stores the response for replay. Server errors are not stored so the
+// client can retry them with the same key.
+func (idempotencyService IdempotencyService) Finish(ctx context.Context, key string, statusCode int, contentType string, body []byte) error {
+	if statusCode >= 500 {
+		if err := idempotencyService.repo.Release(ctx, key); err != nil {
+			idempotencyService.logger.Error("Failed to release idempotency key",
+				zap.String("key", key),
+				zap.Error(err))
+			return fmt.Errorf("failed to release idempotency key: %w", err)
+		}
+		return nil
+	}
+
+	if err := idempotencyService.repo.Complete(ctx, key, statusCode, contentType, body); err != nil {
+		idempotencyService.logger.Error("Failed to store idempotent response",
+			zap.String("key", key),
+			zap.Error(err))
+		return fmt.Errorf("failed to store idempotent response: %w", err)
+	}
+	return nil
+}
+
+// RunCleanup deletes expired keys every interval until ctx is done.
+func (idempotencyService IdempotencyService) RunCleanup(ctx context.Context, interval time.Duration) {
+	idempotencyService.logger.Info("Starting idempotency key cleanup",
+		zap.Duration("interval", interval))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		deleted, err := idempotencyService.repo.DeleteExpired(ctx, time.Now())
+		if err != nil {
+			idempotencyService.logger.Error("Failed to delete expired idempotency keys",
+				zap.Error(err))
+		} else if deleted > 0 {
+			idempotencyService.logger.Info("Expired idempotency keys deleted",
+				zap.Int64("deleted", deleted))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
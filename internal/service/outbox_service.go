@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go.uber.org/zap"
+	"io"
+	"sync"
+	"taskTestEffectMobile/internal/models/sql_models"
+	"taskTestEffectMobile/internal/repository"
+	"time"
+)
+
+// outboxBatchSize is the most events published in one transaction.
+const outboxBatchSize = 100
+
+// EventPublisher receives the events relayed from the outbox. Delivery is at
+// least once: an event is published again when the relay fails before it is
+// marked published, so publishers should deduplicate by EventID.
+type EventPublisher interface {
+	Publish(ctx context.Context, event sql_models.OutboxEvent) error
+}
+
+// MultiPublisher publishes every event to each publisher in turn and stops at
+// the first error.
+type MultiPublisher []EventPublisher
+
+func (publishers MultiPublisher) Publish(ctx context.Context, event sql_models.OutboxEvent) error {
+	for _, publisher := range publishers {
+		if err := publisher.Publish(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriterPublisher writes every event as a line of JSON, for local use with
+// stdout or a file.
+type WriterPublisher struct {
+	mu     sync.Mutex
+	writer io.Writer
+}
+
+func NewWriterPublisher(writer io.Writer) *WriterPublisher {
+	return &WriterPublisher{writer: writer}
+}
+
+func (writerPublisher *WriterPublisher) Publish(ctx context.Context, event sql_models.OutboxEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal outbox event: %w", err)
+	}
+
+	writerPublisher.mu.Lock()
+	defer writerPublisher.mu.Unlock()
+
+	if _, err := writerPublisher.writer.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write outbox event: %w", err)
+	}
+	return nil
+}
+
+// OutboxService relays the events written with subscription changes to a
+// publisher.
+type OutboxService struct {
+	repo      repository.OutboxStorage
+	publisher EventPublisher
+	users     UserSettingsService
+	retention time.Duration
+	logger    *zap.Logger
+}
+
+func NewOutboxService(repo repository.OutboxStorage, publisher EventPublisher, users UserSettingsService, retention time.Duration, logger *zap.Logger) *OutboxService {
+	return &OutboxService{
+		repo:      repo,
+		publisher: publisher,
+		users:     users,
+		retention: retention,
+		logger:    logger.With(zap.String("layer", "service")),
+	}
+}
+
+// RelayEvents publishes the pending events in order until the outbox is
+// drained. An event that fails to publish blocks the ones after it and is
+// retried by the next call.
+func (outboxService OutboxService) RelayEvents(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		published, err := outboxService.repo.RelayOutboxEvents(ctx, outboxBatchSize, func(event sql_models.OutboxEvent) error {
+			event, err := outboxService.withDerivedPayload(ctx, event)
+			if err != nil {
+				return err
+			}
+			return outboxService.publisher.Publish(ctx, event)
+		})
+		total += published
+		if err != nil {
+			return total, fmt.Errorf("failed to relay outbox events: %w", err)
+		}
+		if published < outboxBatchSize {
+			return total, nil
+		}
+	}
+}
+
+// withDerivedPayload fills the computed fields of the stored snapshot, status
+// and monthly price, as the API returns them at the time of the event in the
+// time zone of the owner.
+func (outboxService OutboxService) withDerivedPayload(ctx context.Context, event sql_models.OutboxEvent) (sql_models.OutboxEvent, error) {
+	var sub sql_models.Subscription
+	if err := json.Unmarshal(event.Payload, &sub); err != nil {
+		return sql_models.OutboxEvent{}, fmt.Errorf("failed to unmarshal outbox payload: %w", err)
+	}
+	sub = derivedFields(sub, event.CreatedAt.In(outboxService.users.Location(ctx, sub.UserID)))
+
+	payload, err := json.Marshal(sub)
+	if err != nil {
+		return sql_models.OutboxEvent{}, fmt.Errorf("failed to marshal outbox payload: %w", err)
+	}
+	event.Payload = payload
+	return event, nil
+}
+
+// RunRelay calls RelayEvents every interval until ctx is done, and removes
+// events published longer than the retention ago. Zero retention keeps them.
+func (outboxService OutboxService) RunRelay(ctx context.Context, interval time.Duration) {
+	outboxService.logger.Info("Starting outbox relay",
+		zap.Duration("interval", interval),
+		zap.Duration("retention", outboxService.retention))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if published, err := outboxService.RelayEvents(ctx); err != nil {
+			outboxService.logger.Error("Failed to relay outbox events",
+				zap.Int("published", published),
+				zap.Error(err))
+		} else if published > 0 {
+			outboxService.logger.Info("Outbox events relayed",
+				zap.Int("published", published))
+		}
+
+		if outboxService.retention > 0 {
+			if _, err := outboxService.repo.DeletePublishedOutboxEvents(ctx, time.Now().Add(-outboxService.retention)); err != nil {
+				outboxService.logger.Error("Failed to delete published outbox events",
+					zap.Error(err))
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"taskTestEffectMobile/internal/models/sql_models"
+	"taskTestEffectMobile/internal/repository"
+	"testing"
+	"time"
+)
+
+// recordingPublisher keeps the published events and fails the one at failAt.
+type recordingPublisher struct {
+	events []sql_models.OutboxEvent
+	failAt int
+}
+
+func (publisher *recordingPublisher) Publish(ctx context.Context, event sql_models.OutboxEvent) error {
+	if len(publisher.events)+1 == publisher.failAt {
+		publisher.failAt = 0
+		return errors.New("broker unavailable")
+	}
+	publisher.events = append(publisher.events, event)
+	return nil
+}
+
+func TestRelayEvents(t *testing.T) {
+	ctx := context.Background()
+	logger := zap.NewNop()
+	repo := repository.NewInMemorySubscriptionRepository(logger)
+
+	// The stored status stays active; the payload reports it as expired.
+	endDate := time.Date(2025, time.June, 30, 0, 0, 0, 0, time.UTC)
+	var ids []string
+	for _, serviceName := range []string{"Yandex Plus", "Kinopoisk"} {
+		id, err := repo.InsertSubscription(ctx, sql_models.Subscription{
+			ServiceName: serviceName,
+			Price:       300,
+			UserID:      uuid.NewString(),
+			StartDate:   time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC),
+			EndDate:     &endDate,
+			Status:      sql_models.StatusActive,
+		}, false)
+		if err != nil {
+			t.Fatalf("InsertSubscription() error = %v", err)
+		}
+		ids = append(ids, id)
+	}
+	if _, err := repo.DeleteSubscription(ctx, uuid.MustParse(ids[0]), nil); err != nil {
+		t.Fatalf("DeleteSubscription() error = %v", err)
+	}
+
+	publisher := &recordingPublisher{failAt: 2}
+	users := NewUserSettingsService(repository.NewInMemoryUserSettingsRepository(logger), time.UTC, logger)
+	outboxService := NewOutboxService(repo, publisher, *users, 0, logger)
+
+	if published, err := outboxService.RelayEvents(ctx); err == nil || published != 1 {
+		t.Fatalf("RelayEvents() = %d, %v; want 1 event and an error", published, err)
+	}
+	if published, err := outboxService.RelayEvents(ctx); err != nil || published != 2 {
+		t.Fatalf("RelayEvents() after the failure = %d, %v; want 2 events", published, err)
+	}
+	if published, err := outboxService.RelayEvents(ctx); err != nil || published != 0 {
+		t.Fatalf("RelayEvents() on a drained outbox = %d, %v", published, err)
+	}
+
+	want := []struct {
+		eventType      string
+		subscriptionID string
+	}{
+		{eventType: sql_models.WebhookSubscriptionCreated, subscriptionID: ids[0]},
+		{eventType: sql_models.WebhookSubscriptionCreated, subscriptionID: ids[1]},
+		{eventType: sql_models.WebhookSubscriptionDeleted, subscriptionID: ids[0]},
+	}
+	if len(publisher.events) != len(want) {
+		t.Fatalf("published events = %+v", publisher.events)
+	}
+	for i, event := range publisher.events {
+		if event.EventType != want[i].eventType || event.SubscriptionID != want[i].subscriptionID {
+			t.Errorf("event %d = %s %s, want %+v", i, event.EventType, event.SubscriptionID, want[i])
+		}
+	}
+
+	var created sql_models.Subscription
+	if err := json.Unmarshal(publisher.events[0].Payload, &created); err != nil {
+		t.Fatalf("failed to decode payload: %v", err)
+	}
+	if created.Status != sql_models.StatusExpired || created.MonthlyPrice != 300 {
+		t.Errorf("payload lacks the derived fields: %+v", created)
+	}
+}
@@ -5,52 +5,168 @@ import (
 	"fmt"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
+	"taskTestEffectMobile/internal/models/domain_errors"
 	"taskTestEffectMobile/internal/models/json_models"
 	"taskTestEffectMobile/internal/models/sql_models"
 	"taskTestEffectMobile/internal/repository"
+	"taskTestEffectMobile/internal/utils"
 	"time"
 )
 
 type SubscriptionService struct {
-	repo   repository.SubscriptionRepository
-	logger *zap.Logger
+	repo      repository.SubscriptionStorage
+	rates     ExchangeRateService
+	users     UserSettingsService
+	budgets   BudgetService
+	proration string
+	logger    *zap.Logger
 }
 
-func NewSubscriptionService(repo repository.SubscriptionRepository, logger *zap.Logger) *SubscriptionService {
+func NewSubscriptionService(repo repository.SubscriptionStorage, rates ExchangeRateService, users UserSettingsService, budgets BudgetService, proration string, logger *zap.Logger) *SubscriptionService {
 	return &SubscriptionService{
-		repo:   repo,
-		logger: logger.With(zap.String("layer", "service")),
+		repo:      repo,
+		rates:     rates,
+		users:     users,
+		budgets:   budgets,
+		proration: proration,
+		logger:    logger.With(zap.String("layer", "service")),
 	}
 }
 
 func (subscriptionService SubscriptionService) CreateSubscription(ctx context.Context, sub json_models.CreateSubscription) (string, error) {
 	subscriptionService.logger.Info("Creating subscription",
 		zap.String("userID", sub.UserID),
-		zap.String("service", sub.ServiceName))
+		zap.String("service", sub.ServiceName),
+		zap.Bool("force", sub.Force))
 
-	startDate, err := time.Parse("01-2006", sub.StartDate)
+	startDate, err := utils.ParseDate(sub.StartDate)
 	if err != nil {
 		subscriptionService.logger.Error("Invalid start date format",
 			zap.String("date", sub.StartDate),
 			zap.Error(err))
-		return "", fmt.Errorf("invalid start date format: %w", err)
+		return "", domain_errors.WithDetail(domain_errors.ErrValidation, "invalid start date format")
+	}
+	// Both stores compare user IDs as strings, so they are kept in canonical form.
+	userID, err := uuid.Parse(sub.UserID)
+	if err != nil {
+		return "", domain_errors.WithDetail(domain_errors.ErrValidation, "invalid user ID format")
+	}
+
+	subscription := sql_models.Subscription{
+		ServiceName: sub.ServiceName,
+		Price:       sub.Price,
+		UserID:      userID.String(),
+		Currency:    sub.Currency,
+		StartDate:   startDate,
+		Status:      sql_models.StatusActive,
+
+		BillingInterval:      sub.BillingInterval,
+		BillingIntervalCount: sub.BillingIntervalCount,
+		BillingAnchorDay:     sub.BillingAnchorDay,
+	}
+	if subscription.Currency == "" {
+		subscription.Currency = subscriptionService.rates.BaseCurrency()
+	}
+	if subscription.BillingInterval == "" {
+		subscription.BillingInterval = sql_models.IntervalMonth
+	}
+	if subscription.BillingIntervalCount == 0 {
+		subscription.BillingIntervalCount = 1
+	}
+	if subscription.BillingAnchorDay == 0 {
+		subscription.BillingAnchorDay = startDate.Day()
+	}
+
+	if sub.EndDate != nil {
+		endDate, err := utils.ParseEndDate(*sub.EndDate)
+		if err != nil {
+			subscriptionService.logger.Error("Invalid end date format",
+				zap.String("date", *sub.EndDate),
+				zap.Error(err))
+			return "", domain_errors.WithDetail(domain_errors.ErrValidation, "invalid end date format")
+		}
+		subscription.EndDate = &endDate
+	}
+
+	if err := checkDateRange(subscription.StartDate, subscription.EndDate); err != nil {
+		subscriptionService.logger.Warn("Invalid date range",
+			zap.String("startDate", sub.StartDate),
+			zap.Any("endDate", sub.EndDate))
+		return "", err
+	}
+
+	if sub.TrialEndDate != nil {
+		// The trial is billed by month, so only the month of the date counts.
+		trialEndDate, err := utils.ParseDate(*sub.TrialEndDate)
+		if err != nil {
+			return "", domain_errors.WithDetail(domain_errors.ErrValidation, "invalid trial end date format")
+		}
+		trialEndDate = utils.MonthStart(trialEndDate)
+		if trialEndDate.Before(utils.MonthStart(startDate)) || (subscription.EndDate != nil && trialEndDate.After(*subscription.EndDate)) {
+			return "", domain_errors.WithDetail(domain_errors.ErrValidation, "trial end date must be between start and end dates")
+		}
+		subscription.TrialEndDate = &trialEndDate
+		subscription.Status = sql_models.StatusTrial
+	}
+
+	id, err := subscriptionService.repo.InsertSubscription(ctx, subscription, sub.Force)
+	if err != nil {
+		subscriptionService.logger.Error("Failed to create subscription",
+			zap.String("userID", sub.UserID),
+			zap.String("service", sub.ServiceName),
+			zap.Error(err))
+		return "", fmt.Errorf("failed to create subscription: %w", err)
 	}
 
-	if sub.EndDate == nil {
-		subscriptionService.logger.Debug("Creating subscription without end date")
-		return subscriptionService.repo.InsertSubscription(ctx, sub.ServiceName, sub.Price, sub.UserID, startDate, nil)
+	subscriptionService.budgets.CheckBudgets(subscription.UserID)
+	return id, nil
+}
+
+func (subscriptionService SubscriptionService) GetSubscription(ctx context.Context, subscriptionUUID uuid.UUID, includeDeleted bool) (sql_models.Subscription, error) {
+	subscriptionService.logger.Info("Getting subscription",
+		zap.String("subscriptionID", subscriptionUUID.String()),
+		zap.Bool("includeDeleted", includeDeleted))
+
+	subscription, err := subscriptionService.repo.GetSubscription(ctx, subscriptionUUID, includeDeleted)
+	if err != nil {
+		subscriptionService.logger.Error("Failed to get subscription",
+			zap.String("subscriptionID", subscriptionUUID.String()),
+			zap.Error(err))
+		return sql_models.Subscription{}, fmt.Errorf("failed to get subscription: %w", err)
 	}
 
-	endDate, err := time.Parse("01-2006", *sub.EndDate)
+	return subscriptionService.withDerivedFields(ctx, subscription), nil
+}
+
+// GetSubscriptionPrices returns the effective-dated price records of the subscription, oldest first.
+func (subscriptionService SubscriptionService) GetSubscriptionPrices(ctx context.Context, subscriptionUUID uuid.UUID) ([]sql_models.SubscriptionPrice, error) {
+	subscriptionService.logger.Info("Getting subscription prices",
+		zap.String("subscriptionID", subscriptionUUID.String()))
+
+	prices, err := subscriptionService.repo.GetSubscriptionPrices(ctx, subscriptionUUID)
 	if err != nil {
-		subscriptionService.logger.Error("Invalid end date format",
-			zap.String("date", *sub.EndDate),
+		subscriptionService.logger.Error("Failed to get subscription prices",
+			zap.String("subscriptionID", subscriptionUUID.String()),
 			zap.Error(err))
-		return "", fmt.Errorf("invalid end date format: %w", err)
+		return nil, fmt.Errorf("failed to get subscription prices: %w", err)
 	}
 
-	subscriptionService.logger.Debug("Creating subscription with end date")
-	return subscriptionService.repo.InsertSubscription(ctx, sub.ServiceName, sub.Price, sub.UserID, startDate, &endDate)
+	return prices, nil
+}
+
+func (subscriptionService SubscriptionService) GetSubscriptionHistory(ctx context.Context, subscriptionUUID uuid.UUID) ([]sql_models.SubscriptionEvent, error) {
+	subscriptionService.logger.Info("Getting subscription history",
+		zap.String("subscriptionID", subscriptionUUID.String()))
+
+	events, err := subscriptionService.repo.GetSubscriptionHistory(ctx, subscriptionUUID)
+	if err != nil {
+		subscriptionService.logger.Error("Failed to get subscription history",
+			zap.String("subscriptionID", subscriptionUUID.String()),
+			zap.Error(err))
+		return nil, fmt.Errorf("failed to get subscription history: %w", err)
+	}
+
+	return events, nil
 }
 
 func (subscriptionService SubscriptionService) GetUserSubscriptions(ctx context.Context, userID uuid.UUID) ([]sql_models.Subscription, error) {
@@ -65,13 +181,141 @@ func (subscriptionService SubscriptionService) GetUserSubscriptions(ctx context.
 		return nil, fmt.Errorf("failed to get subscriptions: %w", err)
 	}
 
+	subscriptionService.withDerivedFieldsAll(ctx, subscriptions)
+
 	subscriptionService.logger.Info("Successfully retrieved subscriptions",
 		zap.String("userID", userID.String()),
 		zap.Int("count", len(subscriptions)))
 	return subscriptions, nil
 }
 
-func (subscriptionService SubscriptionService) UpdateSubscription(ctx context.Context, req json_models.PutSubscription) error {
+const (
+	defaultListLimit = 20
+	// defaultUpcomingDays is the projection length of UpcomingCharges.
+	defaultUpcomingDays = 30
+	// purgeActor is recorded as the actor of events written by RunPurge.
+	purgeActor = "system:purge"
+)
+
+func (subscriptionService SubscriptionService) ListSubscriptions(ctx context.Context, req json_models.ListSubscriptionsRequest) (json_models.SubscriptionPage, error) {
+	subscriptionService.logger.Info("Listing subscriptions",
+		zap.Any("request", req))
+
+	filter := json_models.SubscriptionListFilter{
+		ServiceName: req.ServiceName,
+		MinPrice:    req.MinPrice,
+		MaxPrice:    req.MaxPrice,
+		Sort:        req.Sort,
+		Descending:  req.Order == "desc",
+		Limit:       req.Limit,
+
+		IncludeDeleted: req.IncludeDeleted,
+	}
+	if req.UserID != nil {
+		userID, err := uuid.Parse(*req.UserID)
+		if err != nil {
+			return json_models.SubscriptionPage{}, domain_errors.WithDetail(domain_errors.ErrValidation, "invalid user ID format")
+		}
+		normalized := userID.String()
+		filter.UserID = &normalized
+	}
+	if filter.Sort == "" {
+		filter.Sort = repository.SortByCreatedAt
+	}
+	order := "asc"
+	if filter.Descending {
+		order = "desc"
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultListLimit
+	}
+
+	// Lower bounds take the first day of a month, upper bounds its last day,
+	// so a month covers all of it while a day matches only itself.
+	dates := []struct {
+		value  *string
+		target **time.Time
+		parse  func(string) (time.Time, error)
+		name   string
+	}{
+		{req.ActiveAt, &filter.ActiveFrom, utils.ParseDate, "active-at"},
+		{req.ActiveAt, &filter.ActiveTo, utils.ParseEndDate, "active-at"},
+		{req.StartFrom, &filter.StartFrom, utils.ParseDate, "start-from"},
+		{req.StartTo, &filter.StartTo, utils.ParseEndDate, "start-to"},
+		{req.EndFrom, &filter.EndFrom, utils.ParseDate, "end-from"},
+		{req.EndTo, &filter.EndTo, utils.ParseEndDate, "end-to"},
+	}
+	for _, date := range dates {
+		if date.value == nil {
+			continue
+		}
+		parsed, err := date.parse(*date.value)
+		if err != nil {
+			return json_models.SubscriptionPage{}, domain_errors.WithDetail(domain_errors.ErrValidation, "invalid %s format", date.name)
+		}
+		*date.target = &parsed
+	}
+
+	if filter.StartFrom != nil && filter.StartTo != nil {
+		if err := checkDateRange(*filter.StartFrom, filter.StartTo); err != nil {
+			return json_models.SubscriptionPage{}, fmt.Errorf("start-to is before start-from: %w", err)
+		}
+	}
+	if filter.EndFrom != nil && filter.EndTo != nil {
+		if err := checkDateRange(*filter.EndFrom, filter.EndTo); err != nil {
+			return json_models.SubscriptionPage{}, fmt.Errorf("end-to is before end-from: %w", err)
+		}
+	}
+
+	if filter.MinPrice != nil && filter.MaxPrice != nil && *filter.MinPrice > *filter.MaxPrice {
+		return json_models.SubscriptionPage{}, domain_errors.WithDetail(domain_errors.ErrValidation, "min-price is greater than max-price")
+	}
+
+	if req.Cursor != "" {
+		cursor, err := decodeCursor(req.Cursor, filter.Sort, order)
+		if err != nil {
+			subscriptionService.logger.Warn("Invalid list cursor",
+				zap.String("cursor", req.Cursor),
+				zap.Error(err))
+			return json_models.SubscriptionPage{}, err
+		}
+		filter.After = cursor
+	}
+
+	pageSize := filter.Limit
+	filter.Limit++
+	subscriptions, err := subscriptionService.repo.ListSubscriptions(ctx, filter)
+	if err != nil {
+		subscriptionService.logger.Error("Failed to list subscriptions",
+			zap.Error(err))
+		return json_models.SubscriptionPage{}, fmt.Errorf("failed to list subscriptions: %w", err)
+	}
+
+	page := json_models.SubscriptionPage{
+		Data:       subscriptions,
+		Pagination: json_models.Pagination{Limit: pageSize},
+	}
+	if page.Data == nil {
+		page.Data = []sql_models.Subscription{}
+	}
+	subscriptionService.withDerivedFieldsAll(ctx, page.Data)
+	if len(subscriptions) > pageSize {
+		page.Data = subscriptions[:pageSize]
+		nextCursor, err := encodeCursor(repository.NewListCursor(page.Data[pageSize-1], filter.Sort, order))
+		if err != nil {
+			return json_models.SubscriptionPage{}, err
+		}
+		page.Pagination.HasMore = true
+		page.Pagination.NextCursor = &nextCursor
+	}
+
+	subscriptionService.logger.Info("Successfully listed subscriptions",
+		zap.Int("count", len(page.Data)),
+		zap.Bool("hasMore", page.Pagination.HasMore))
+	return page, nil
+}
+
+func (subscriptionService SubscriptionService) UpdateSubscription(ctx context.Context, req json_models.PutSubscription, expectedVersion *int) (sql_models.Subscription, error) {
 	subscriptionService.logger.Info("Updating subscription",
 		zap.String("subscriptionID", req.SubscriptionID),
 		zap.String("service", req.ServiceName))
@@ -79,53 +323,146 @@ func (subscriptionService SubscriptionService) UpdateSubscription(ctx context.Co
 	var startDate, endDate *time.Time
 
 	if req.StartDate != "" {
-		sd, err := time.Parse("01-2006", req.StartDate)
+		sd, err := utils.ParseDate(req.StartDate)
 		if err != nil {
 			subscriptionService.logger.Error("Invalid start date format",
 				zap.String("date", req.StartDate),
 				zap.Error(err))
-			return fmt.Errorf("invalid start date format: %w", err)
+			return sql_models.Subscription{}, domain_errors.WithDetail(domain_errors.ErrValidation, "invalid start date format")
 		}
 		startDate = &sd
 	}
 
 	if req.EndDate != nil {
-		ed, err := time.Parse("01-2006", *req.EndDate)
+		ed, err := utils.ParseEndDate(*req.EndDate)
 		if err != nil {
 			subscriptionService.logger.Error("Invalid end date format",
 				zap.String("date", *req.EndDate),
 				zap.Error(err))
-			return fmt.Errorf("invalid end date format: %w", err)
+			return sql_models.Subscription{}, domain_errors.WithDetail(domain_errors.ErrValidation, "invalid end date format")
 		}
 		endDate = &ed
 	}
 
+	now, err := subscriptionService.ownerNow(ctx, req.SubscriptionID)
+	if err != nil {
+		return sql_models.Subscription{}, fmt.Errorf("failed to update subscription: %w", err)
+	}
+
+	priceEffectiveFrom, err := parsePriceEffectiveFrom(req.PriceEffectiveFrom, now)
+	if err != nil {
+		return sql_models.Subscription{}, err
+	}
+
 	updateData := json_models.SubscriptionUpdate{
-		ServiceName: req.ServiceName,
-		Price:       req.Price,
-		StartDate:   startDate,
-		EndDate:     endDate,
+		ServiceName:     &req.ServiceName,
+		Price:           &req.Price,
+		StartDate:       startDate,
+		EndDate:         endDate,
+		ExpectedVersion: expectedVersion,
+		Currency:        req.Currency,
+
+		PriceEffectiveFrom: priceEffectiveFrom,
+		CurrentMonth:       utils.MonthStart(now),
+
+		BillingInterval:      req.BillingInterval,
+		BillingIntervalCount: req.BillingIntervalCount,
+		BillingAnchorDay:     req.BillingAnchorDay,
 	}
 
-	if err := subscriptionService.repo.UpdateSubscription(ctx, req.SubscriptionID, updateData); err != nil {
+	subscription, err := subscriptionService.repo.UpdateSubscription(ctx, req.SubscriptionID, updateData)
+	if err != nil {
 		subscriptionService.logger.Error("Failed to update subscription",
 			zap.String("subscriptionID", req.SubscriptionID),
 			zap.String("service", req.ServiceName),
 			zap.Error(err))
-		return fmt.Errorf("failed to update subscription: %w", err)
+		return sql_models.Subscription{}, fmt.Errorf("failed to update subscription: %w", err)
 	}
 
 	subscriptionService.logger.Info("Subscription updated successfully",
 		zap.String("subscriptionID", req.SubscriptionID),
 		zap.String("service", req.ServiceName))
-	return nil
+	subscriptionService.budgets.CheckBudgets(subscription.UserID)
+	return subscriptionService.withDerivedFields(ctx, subscription), nil
 }
 
-func (subscriptionService SubscriptionService) DeleteSubscription(ctx context.Context, subscriptionUUID uuid.UUID) error {
+// PatchSubscription applies a JSON Merge Patch to the subscription and returns the result.
+func (subscriptionService SubscriptionService) PatchSubscription(ctx context.Context, subscriptionUUID uuid.UUID, patch json_models.PatchSubscription, expectedVersion *int) (sql_models.Subscription, error) {
+	subscriptionService.logger.Info("Patching subscription",
+		zap.String("subscriptionID", subscriptionUUID.String()))
+
+	updateData := json_models.SubscriptionUpdate{
+		ClearEndDate:    patch.EndDate.Set && patch.EndDate.Null,
+		ExpectedVersion: expectedVersion,
+	}
+	if patch.ServiceName.HasValue() {
+		updateData.ServiceName = &patch.ServiceName.Value
+	}
+	if patch.Price.HasValue() {
+		now, err := subscriptionService.ownerNow(ctx, subscriptionUUID.String())
+		if err != nil {
+			return sql_models.Subscription{}, fmt.Errorf("failed to patch subscription: %w", err)
+		}
+		updateData.Price = &patch.Price.Value
+		updateData.CurrentMonth = utils.MonthStart(now)
+
+		var effectiveFrom *string
+		if patch.PriceEffectiveFrom.HasValue() {
+			effectiveFrom = &patch.PriceEffectiveFrom.Value
+		}
+		priceEffectiveFrom, err := parsePriceEffectiveFrom(effectiveFrom, now)
+		if err != nil {
+			return sql_models.Subscription{}, err
+		}
+		updateData.PriceEffectiveFrom = priceEffectiveFrom
+	}
+	if patch.Currency.HasValue() {
+		updateData.Currency = &patch.Currency.Value
+	}
+	if patch.BillingInterval.HasValue() {
+		updateData.BillingInterval = &patch.BillingInterval.Value
+	}
+	if patch.BillingIntervalCount.HasValue() {
+		updateData.BillingIntervalCount = &patch.BillingIntervalCount.Value
+	}
+	if patch.BillingAnchorDay.HasValue() {
+		updateData.BillingAnchorDay = &patch.BillingAnchorDay.Value
+	}
+	if patch.StartDate.HasValue() {
+		startDate, err := utils.ParseDate(patch.StartDate.Value)
+		if err != nil {
+			return sql_models.Subscription{}, domain_errors.WithDetail(domain_errors.ErrValidation, "invalid start date format")
+		}
+		updateData.StartDate = &startDate
+	}
+	if patch.EndDate.HasValue() {
+		endDate, err := utils.ParseEndDate(patch.EndDate.Value)
+		if err != nil {
+			return sql_models.Subscription{}, domain_errors.WithDetail(domain_errors.ErrValidation, "invalid end date format")
+		}
+		updateData.EndDate = &endDate
+	}
+
+	subscription, err := subscriptionService.repo.UpdateSubscription(ctx, subscriptionUUID.String(), updateData)
+	if err != nil {
+		subscriptionService.logger.Error("Failed to patch subscription",
+			zap.String("subscriptionID", subscriptionUUID.String()),
+			zap.Error(err))
+		return sql_models.Subscription{}, fmt.Errorf("failed to patch subscription: %w", err)
+	}
+
+	subscriptionService.logger.Info("Subscription patched successfully",
+		zap.String("subscriptionID", subscriptionUUID.String()))
+	subscriptionService.budgets.CheckBudgets(subscription.UserID)
+	return subscriptionService.withDerivedFields(ctx, subscription), nil
+}
+
+func (subscriptionService SubscriptionService) DeleteSubscription(ctx context.Context, subscriptionUUID uuid.UUID, expectedVersion *int) error {
 	subscriptionService.logger.Info("Deleting subscription",
 		zap.String("userID", subscriptionUUID.String()))
 
-	if err := subscriptionService.repo.DeleteSubscription(ctx, subscriptionUUID); err != nil {
+	deleted, err := subscriptionService.repo.DeleteSubscription(ctx, subscriptionUUID, expectedVersion)
+	if err != nil {
 		subscriptionService.logger.Error("Failed to delete subscription",
 			zap.String("userID", subscriptionUUID.String()),
 			zap.Error(err))
@@ -134,41 +471,275 @@ func (subscriptionService SubscriptionService) DeleteSubscription(ctx context.Co
 
 	subscriptionService.logger.Info("Subscription deleted successfully",
 		zap.String("userID", subscriptionUUID.String()))
+	subscriptionService.budgets.CheckBudgets(deleted.UserID)
 	return nil
 }
 
-func (subscriptionService SubscriptionService) CalculateSubscriptionsCost(
-	ctx context.Context,
-	userID *uuid.UUID,
-	serviceName *string,
-	startDateStr string,
-	endDateStr *string,
-) (int, error) {
+// RestoreSubscription brings a soft-deleted subscription back.
+func (subscriptionService SubscriptionService) RestoreSubscription(ctx context.Context, subscriptionUUID uuid.UUID) (sql_models.Subscription, error) {
+	subscriptionService.logger.Info("Restoring subscription",
+		zap.String("subscriptionID", subscriptionUUID.String()))
+
+	subscription, err := subscriptionService.repo.RestoreSubscription(ctx, subscriptionUUID)
+	if err != nil {
+		subscriptionService.logger.Error("Failed to restore subscription",
+			zap.String("subscriptionID", subscriptionUUID.String()),
+			zap.Error(err))
+		return sql_models.Subscription{}, fmt.Errorf("failed to restore subscription: %w", err)
+	}
+
+	subscriptionService.logger.Info("Subscription restored successfully",
+		zap.String("subscriptionID", subscriptionUUID.String()))
+	subscriptionService.budgets.CheckBudgets(subscription.UserID)
+	return subscriptionService.withDerivedFields(ctx, subscription), nil
+}
+
+// PauseSubscription stops billing from the next month until the subscription is resumed.
+func (subscriptionService SubscriptionService) PauseSubscription(ctx context.Context, subscriptionUUID uuid.UUID, expectedVersion *int) (sql_models.Subscription, error) {
+	return subscriptionService.changeStatus(ctx, subscriptionUUID, sql_models.StatusPaused, expectedVersion)
+}
+
+// ResumeSubscription resumes a paused subscription or ends a trial early.
+// Billing restarts with the current month.
+func (subscriptionService SubscriptionService) ResumeSubscription(ctx context.Context, subscriptionUUID uuid.UUID, expectedVersion *int) (sql_models.Subscription, error) {
+	return subscriptionService.changeStatus(ctx, subscriptionUUID, sql_models.StatusActive, expectedVersion)
+}
+
+// CancelSubscription cancels the subscription; the current month is the last billed one.
+func (subscriptionService SubscriptionService) CancelSubscription(ctx context.Context, subscriptionUUID uuid.UUID, expectedVersion *int) (sql_models.Subscription, error) {
+	return subscriptionService.changeStatus(ctx, subscriptionUUID, sql_models.StatusCancelled, expectedVersion)
+}
+
+func (subscriptionService SubscriptionService) changeStatus(ctx context.Context, subscriptionUUID uuid.UUID, target string, expectedVersion *int) (sql_models.Subscription, error) {
+	subscriptionService.logger.Info("Changing subscription status",
+		zap.String("subscriptionID", subscriptionUUID.String()),
+		zap.String("status", target))
+
+	current, err := subscriptionService.repo.GetSubscription(ctx, subscriptionUUID, false)
+	if err != nil {
+		return sql_models.Subscription{}, fmt.Errorf("failed to change subscription status: %w", err)
+	}
+
+	// Transitions act on the current month of the subscriber.
+	now := subscriptionService.users.Now(ctx, current.UserID)
+	subscription, err := subscriptionService.repo.TransitionSubscription(ctx, subscriptionUUID, target, now, expectedVersion)
+	if err != nil {
+		subscriptionService.logger.Error("Failed to change subscription status",
+			zap.String("subscriptionID", subscriptionUUID.String()),
+			zap.String("status", target),
+			zap.Error(err))
+		return sql_models.Subscription{}, fmt.Errorf("failed to change subscription status: %w", err)
+	}
+
+	subscriptionService.budgets.CheckBudgets(subscription.UserID)
+	return derivedFields(subscription, now), nil
+}
+
+// ownerNow returns the current time in the time zone of the subscription
+// owner. The owner never changes, so it is safe to read outside the update.
+func (subscriptionService SubscriptionService) ownerNow(ctx context.Context, subscriptionID string) (time.Time, error) {
+	subscriptionUUID, err := uuid.Parse(subscriptionID)
+	if err != nil {
+		return time.Time{}, domain_errors.WithDetail(domain_errors.ErrValidation, "invalid subscription ID format")
+	}
+	current, err := subscriptionService.repo.GetSubscription(ctx, subscriptionUUID, false)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return subscriptionService.users.Now(ctx, current.UserID), nil
+}
+
+// withDerivedFields fills the fields that are computed rather than stored,
+// evaluated at the current time in the time zone of the subscriber.
+func (subscriptionService SubscriptionService) withDerivedFields(ctx context.Context, sub sql_models.Subscription) sql_models.Subscription {
+	return derivedFields(sub, subscriptionService.users.Now(ctx, sub.UserID))
+}
+
+// withDerivedFieldsAll applies withDerivedFields in place, looking up the
+// time zone of each user once.
+func (subscriptionService SubscriptionService) withDerivedFieldsAll(ctx context.Context, subscriptions []sql_models.Subscription) {
+	nowByUser := make(map[string]time.Time)
+	for i, sub := range subscriptions {
+		now, ok := nowByUser[sub.UserID]
+		if !ok {
+			now = subscriptionService.users.Now(ctx, sub.UserID)
+			nowByUser[sub.UserID] = now
+		}
+		subscriptions[i] = derivedFields(sub, now)
+	}
+}
+
+// derivedFields sets the status at now, so finished subscriptions are
+// reported as expired, and the monthly-equivalent price.
+func derivedFields(sub sql_models.Subscription, now time.Time) sql_models.Subscription {
+	sub.Status = sub.StatusAt(now)
+	sub.MonthlyPrice = sub.MonthlyEquivalentPrice()
+	return sub
+}
+
+// PurgeDeletedSubscriptions permanently removes subscriptions that were
+// soft-deleted more than retention ago.
+func (subscriptionService SubscriptionService) PurgeDeletedSubscriptions(ctx context.Context, retention time.Duration) (int64, error) {
+	purged, err := subscriptionService.repo.PurgeDeletedSubscriptions(ctx, time.Now().Add(-retention))
+	if err != nil {
+		subscriptionService.logger.Error("Failed to purge deleted subscriptions",
+			zap.Duration("retention", retention),
+			zap.Error(err))
+		return 0, fmt.Errorf("failed to purge deleted subscriptions: %w", err)
+	}
+	return purged, nil
+}
+
+// RunPurge calls PurgeDeletedSubscriptions every interval until ctx is done.
+func (subscriptionService SubscriptionService) RunPurge(ctx context.Context, interval, retention time.Duration) {
+	subscriptionService.logger.Info("Starting deleted subscriptions purge",
+		zap.Duration("interval", interval),
+		zap.Duration("retention", retention))
+
+	ctx = utils.WithRequestMetadata(ctx, "", purgeActor)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		// Errors are already logged; the next tick simply retries.
+		_, _ = subscriptionService.PurgeDeletedSubscriptions(ctx, retention)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (subscriptionService SubscriptionService) CalculateSubscriptionsCost(ctx context.Context, req json_models.CostRequest) (json_models.CostReport, error) {
 	subscriptionService.logger.Info("Calculating subscriptions cost",
-		zap.Any("userID", userID),
-		zap.Any("serviceName", serviceName),
-		zap.String("startDate", startDateStr),
-		zap.Any("endDate", endDateStr))
+		zap.Any("request", req))
 
-	startDate, err := time.Parse("01-2006", startDateStr)
+	filter := json_models.CostFilter{
+		ServiceName:    req.ServiceName,
+		IncludeDeleted: req.IncludeDeleted,
+		Proration:      subscriptionService.proration,
+	}
+
+	if req.UserID != nil {
+		userID, err := uuid.Parse(*req.UserID)
+		if err != nil {
+			return json_models.CostReport{}, domain_errors.WithDetail(domain_errors.ErrValidation, "invalid user ID format")
+		}
+		normalized := userID.String()
+		filter.UserID = &normalized
+	}
+
+	startDate, err := utils.ParseDate(req.StartDate)
 	if err != nil {
 		subscriptionService.logger.Error("Invalid start date format",
-			zap.String("date", startDateStr),
+			zap.String("date", req.StartDate),
 			zap.Error(err))
-		return 0, fmt.Errorf("invalid start date format: %w", err)
+		return json_models.CostReport{}, domain_errors.WithDetail(domain_errors.ErrValidation, "invalid start date format")
 	}
+	filter.StartDate = startDate
 
-	var endDate *time.Time
-	if endDateStr != nil {
-		parsedEndDate, err := time.Parse("01-2006", *endDateStr)
+	if req.EndDate != nil {
+		parsedEndDate, err := utils.ParseEndDate(*req.EndDate)
 		if err != nil {
 			subscriptionService.logger.Error("Invalid end date format",
-				zap.String("date", *endDateStr),
+				zap.String("date", *req.EndDate),
 				zap.Error(err))
-			return 0, fmt.Errorf("invalid end date format: %w", err)
+			return json_models.CostReport{}, domain_errors.WithDetail(domain_errors.ErrValidation, "invalid end date format")
+		}
+		if err := checkDateRange(startDate, &parsedEndDate); err != nil {
+			subscriptionService.logger.Warn("Invalid date range",
+				zap.String("startDate", req.StartDate),
+				zap.String("endDate", *req.EndDate))
+			return json_models.CostReport{}, err
+		}
+		filter.EndDate = parsedEndDate
+	} else {
+		// Without an end the report runs to the current month of the user,
+		// or of the default time zone for a report across users.
+		var userID string
+		if filter.UserID != nil {
+			userID = *filter.UserID
 		}
-		endDate = &parsedEndDate
+		filter.EndDate = utils.MonthEnd(subscriptionService.users.Now(ctx, userID))
 	}
 
-	return subscriptionService.repo.GetSubscriptionsCost(ctx, userID, serviceName, startDate, endDate)
+	var currency string
+	if req.Currency != nil {
+		currency = *req.Currency
+	}
+	rates, err := subscriptionService.rates.Converter(ctx, currency)
+	if err != nil {
+		return json_models.CostReport{}, err
+	}
+
+	return subscriptionService.repo.GetSubscriptionsCost(ctx, filter, rates)
+}
+
+// checkDateRange enforces that a range does not end before the day it starts.
+// Both days are inclusive, so equal dates describe a single day.
+func checkDateRange(startDate time.Time, endDate *time.Time) error {
+	if endDate != nil && endDate.Before(startDate) {
+		return domain_errors.WithDetail(domain_errors.ErrInvalidDateRange, "end date %s is before start date %s", endDate.Format(utils.DayLayout), startDate.Format(utils.DayLayout))
+	}
+	return nil
+}
+
+// UpcomingCharges projects the charges of a user for the next req.Days days,
+// starting today in the user's time zone.
+func (subscriptionService SubscriptionService) UpcomingCharges(ctx context.Context, req json_models.UpcomingChargesRequest) (json_models.UpcomingCharges, error) {
+	subscriptionService.logger.Info("Projecting upcoming charges",
+		zap.Any("request", req))
+
+	userID, err := uuid.Parse(req.UserID)
+	if err != nil {
+		return json_models.UpcomingCharges{}, domain_errors.WithDetail(domain_errors.ErrValidation, "invalid user ID format")
+	}
+	days := req.Days
+	if days == 0 {
+		days = defaultUpcomingDays
+	}
+
+	today := utils.DayStart(subscriptionService.users.Now(ctx, userID.String()))
+	filter := json_models.UpcomingChargesFilter{
+		UserID:    userID.String(),
+		From:      today,
+		To:        today.AddDate(0, 0, days),
+		Proration: subscriptionService.proration,
+	}
+
+	var currency string
+	if req.Currency != nil {
+		currency = *req.Currency
+	}
+	rates, err := subscriptionService.rates.Converter(ctx, currency)
+	if err != nil {
+		return json_models.UpcomingCharges{}, err
+	}
+
+	upcoming, err := subscriptionService.repo.GetUpcomingCharges(ctx, filter, rates)
+	if err != nil {
+		subscriptionService.logger.Error("Failed to project upcoming charges",
+			zap.String("userID", filter.UserID),
+			zap.Error(err))
+		return json_models.UpcomingCharges{}, fmt.Errorf("failed to project upcoming charges: %w", err)
+	}
+	return upcoming, nil
+}
+
+// parsePriceEffectiveFrom returns the first month a price change applies to.
+// Without an explicit month the change applies from the month of now, the
+// current time of the subscription owner.
+func parsePriceEffectiveFrom(value *string, now time.Time) (*time.Time, error) {
+	if value == nil {
+		effectiveFrom := utils.MonthStart(now)
+		return &effectiveFrom, nil
+	}
+	effectiveFrom, err := utils.ParseDate(*value)
+	if err != nil {
+		return nil, domain_errors.WithDetail(domain_errors.ErrValidation, "invalid price effective date format")
+	}
+	effectiveFrom = utils.MonthStart(effectiveFrom)
+	return &effectiveFrom, nil
 }
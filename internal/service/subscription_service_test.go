@@ -0,0 +1,42 @@
+package service
+
+import (
+	"errors"
+	"taskTestEffectMobile/internal/models/domain_errors"
+	"testing"
+	"time"
+)
+
+func TestParsePriceEffectiveFrom(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+	// 31 March 22:30 UTC is already 1 April in Moscow.
+	now := time.Date(2025, time.March, 31, 22, 30, 0, 0, time.UTC).In(moscow)
+	month := func(value string) *string { return &value }
+
+	tests := []struct {
+		name    string
+		value   *string
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "current month of the owner", want: date(2025, time.April, 1)},
+		{name: "month", value: month("06-2025"), want: date(2025, time.June, 1)},
+		{name: "day resolves to its month", value: month("2025-06-15"), want: date(2025, time.June, 1)},
+		{name: "invalid", value: month("June"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePriceEffectiveFrom(tt.value, now)
+			if tt.wantErr {
+				if !errors.Is(err, domain_errors.ErrValidation) {
+					t.Errorf("parsePriceEffectiveFrom() error = %v, want %v", err, domain_errors.ErrValidation)
+				}
+				return
+			}
+			if err != nil || !got.Equal(tt.want) {
+				t.Errorf("parsePriceEffectiveFrom() = %v, %v; want %v", got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"taskTestEffectMobile/internal/models/domain_errors"
+	"taskTestEffectMobile/internal/models/json_models"
+	"taskTestEffectMobile/internal/models/sql_models"
+	"taskTestEffectMobile/internal/repository"
+	"time"
+)
+
+type UserSettingsService struct {
+	repo            repository.UserSettingsStorage
+	defaultTimeZone *time.Location
+	logger          *zap.Logger
+}
+
+func NewUserSettingsService(repo repository.UserSettingsStorage, defaultTimeZone *time.Location, logger *zap.Logger) *UserSettingsService {
+	return &UserSettingsService{
+		repo:            repo,
+		defaultTimeZone: defaultTimeZone,
+		logger:          logger.With(zap.String("layer", "service")),
+	}
+}
+
+// GetUserSettings returns the settings of the user, falling back to the
+// defaults when the user has not saved any.
+func (userSettingsService UserSettingsService) GetUserSettings(ctx context.Context, userID uuid.UUID) (sql_models.UserSettings, error) {
+	settings, err := userSettingsService.repo.GetUserSettings(ctx, userID.String())
+	if errors.Is(err, domain_errors.ErrNotFound) {
+		return sql_models.UserSettings{UserID: userID.String(), TimeZone: userSettingsService.defaultTimeZone.String()}, nil
+	}
+	if err != nil {
+		userSettingsService.logger.Error("Failed to get user settings",
+			zap.String("userID", userID.String()),
+			zap.Error(err))
+		return sql_models.UserSettings{}, fmt.Errorf("failed to get user settings: %w", err)
+	}
+	return settings, nil
+}
+
+func (userSettingsService UserSettingsService) UpdateUserSettings(ctx context.Context, userID uuid.UUID, input json_models.UserSettingsInput) (sql_models.UserSettings, error) {
+	userSettingsService.logger.Info("Updating user settings",
+		zap.String("userID", userID.String()),
+		zap.String("timeZone", input.TimeZone))
+
+	if _, err := time.LoadLocation(input.TimeZone); err != nil {
+		return sql_models.UserSettings{}, domain_errors.WithDetail(domain_errors.ErrValidation, "unknown time zone %q", input.TimeZone)
+	}
+
+	settings, err := userSettingsService.repo.UpsertUserSettings(ctx, sql_models.UserSettings{UserID: userID.String(), TimeZone: input.TimeZone})
+	if err != nil {
+		userSettingsService.logger.Error("Failed to update user settings",
+			zap.String("userID", userID.String()),
+			zap.Error(err))
+		return sql_models.UserSettings{}, fmt.Errorf("failed to update user settings: %w", err)
+	}
+	return settings, nil
+}
+
+// Location returns the time zone of the user. Users without settings, and
+// lookups that fail, use the default time zone, as does an empty userID.
+func (userSettingsService UserSettingsService) Location(ctx context.Context, userID string) *time.Location {
+	if userID == "" {
+		return userSettingsService.defaultTimeZone
+	}
+	settings, err := userSettingsService.repo.GetUserSettings(ctx, userID)
+	if err != nil {
+		if !errors.Is(err, domain_errors.ErrNotFound) {
+			userSettingsService.logger.Warn("Failed to load user time zone, using the default",
+				zap.String("userID", userID),
+				zap.Error(err))
+		}
+		return userSettingsService.defaultTimeZone
+	}
+	location, err := time.LoadLocation(settings.TimeZone)
+	if err != nil {
+		userSettingsService.logger.Warn("Stored user time zone is unknown, using the default",
+			zap.String("userID", userID),
+			zap.String("timeZone", settings.TimeZone))
+		return userSettingsService.defaultTimeZone
+	}
+	return location
+}
+
+// Now returns the current time in the time zone of the user.
+func (userSettingsService UserSettingsService) Now(ctx context.Context, userID string) time.Time {
+	return time.Now().In(userSettingsService.Location(ctx, userID))
+}
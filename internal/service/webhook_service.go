@@ -0,0 +1,425 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"strconv"
+	"taskTestEffectMobile/internal/core/configs"
+	"taskTestEffectMobile/internal/models/domain_errors"
+	"taskTestEffectMobile/internal/models/json_models"
+	"taskTestEffectMobile/internal/models/sql_models"
+	"taskTestEffectMobile/internal/repository"
+	"taskTestEffectMobile/internal/utils"
+	"time"
+)
+
+const (
+	// webhookBatchSize is the most deliveries sent by one dispatch.
+	webhookBatchSize = 50
+	// webhookLeaseMargin extends the lease of a batch beyond the time its
+	// attempts may take, leaving room to record the outcomes.
+	webhookLeaseMargin = time.Minute
+	// webhookMaxBackoff caps the wait between two attempts.
+	webhookMaxBackoff = 6 * time.Hour
+	// webhookSecretBytes is the entropy of generated endpoint secrets.
+	webhookSecretBytes = 32
+	// webhookErrorLimit is the longest error message kept in the delivery log.
+	webhookErrorLimit = 512
+)
+
+// Headers sent with every delivery. The signature header holds
+// "t=<unix time>,v1=<hex HMAC-SHA256 of "<unix time>.<body>">".
+const (
+	webhookEventHeader     = "X-Webhook-Event"
+	webhookIDHeader        = "X-Webhook-Id"
+	webhookDeliveryHeader  = "X-Webhook-Delivery"
+	webhookSignatureHeader = "X-Webhook-Signature"
+)
+
+// renewalEventNamespace derives stable IDs of renewal notices, so a renewal
+// is announced to an endpoint once however often it is looked up.
+var renewalEventNamespace = uuid.MustParse("6f0c7f3e-3b8e-4c55-9a43-0d1f6c1e2b7a")
+
+type WebhookService struct {
+	repo          repository.WebhookStorage
+	subscriptions repository.SubscriptionStorage
+	rates         ExchangeRateService
+	proration     string
+	config        configs.WebhookConfig
+	client        *http.Client
+	logger        *zap.Logger
+}
+
+func NewWebhookService(repo repository.WebhookStorage, subscriptions repository.SubscriptionStorage, rates ExchangeRateService, proration string, config configs.WebhookConfig, logger *zap.Logger) *WebhookService {
+	return &WebhookService{
+		repo:          repo,
+		subscriptions: subscriptions,
+		rates:         rates,
+		proration:     proration,
+		config:        config,
+		client:        &http.Client{Timeout: config.Timeout},
+		logger:        logger.With(zap.String("layer", "service")),
+	}
+}
+
+// RegisterEndpoint stores a new endpoint. A secret is generated unless one is
+// given; it is returned only here.
+func (webhookService WebhookService) RegisterEndpoint(ctx context.Context, input json_models.WebhookEndpointInput) (sql_models.WebhookEndpoint, error) {
+	webhookService.logger.Info("Registering webhook endpoint",
+		zap.String("url", input.URL),
+		zap.Strings("eventTypes", input.EventTypes))
+
+	endpoint := sql_models.WebhookEndpoint{
+		URL:        input.URL,
+		EventTypes: input.EventTypes,
+	}
+	if endpoint.EventTypes == nil {
+		endpoint.EventTypes = []string{}
+	}
+	if input.Secret != nil {
+		endpoint.Secret = *input.Secret
+	} else {
+		secret := make([]byte, webhookSecretBytes)
+		if _, err := rand.Read(secret); err != nil {
+			return sql_models.WebhookEndpoint{}, fmt.Errorf("failed to generate webhook secret: %w", err)
+		}
+		endpoint.Secret = "whsec_" + base64.RawURLEncoding.EncodeToString(secret)
+	}
+
+	stored, err := webhookService.repo.CreateWebhookEndpoint(ctx, endpoint)
+	if err != nil {
+		webhookService.logger.Error("Failed to register webhook endpoint",
+			zap.String("url", input.URL),
+			zap.Error(err))
+		return sql_models.WebhookEndpoint{}, fmt.Errorf("failed to register webhook endpoint: %w", err)
+	}
+	return stored, nil
+}
+
+// ListEndpoints returns the registered endpoints without their secrets.
+func (webhookService WebhookService) ListEndpoints(ctx context.Context) ([]sql_models.WebhookEndpoint, error) {
+	endpoints, err := webhookService.repo.ListWebhookEndpoints(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook endpoints: %w", err)
+	}
+	if endpoints == nil {
+		endpoints = []sql_models.WebhookEndpoint{}
+	}
+	for i := range endpoints {
+		endpoints[i].Secret = ""
+	}
+	return endpoints, nil
+}
+
+func (webhookService WebhookService) DeleteEndpoint(ctx context.Context, endpointID uuid.UUID) error {
+	webhookService.logger.Info("Deleting webhook endpoint",
+		zap.String("endpointID", endpointID.String()))
+
+	if err := webhookService.repo.DeleteWebhookEndpoint(ctx, endpointID.String()); err != nil {
+		return fmt.Errorf("failed to delete webhook endpoint: %w", err)
+	}
+	return nil
+}
+
+// ListDeliveries returns the delivery log of the endpoint, newest first.
+func (webhookService WebhookService) ListDeliveries(ctx context.Context, endpointID uuid.UUID) ([]sql_models.WebhookDelivery, error) {
+	if _, err := webhookService.repo.GetWebhookEndpoint(ctx, endpointID.String()); err != nil {
+		return nil, err
+	}
+
+	deliveries, err := webhookService.repo.ListWebhookDeliveries(ctx, endpointID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	if deliveries == nil {
+		deliveries = []sql_models.WebhookDelivery{}
+	}
+	return deliveries, nil
+}
+
+// Redeliver queues the delivery again with a fresh set of attempts, whatever
+// its current status. The same event ID and payload are sent.
+func (webhookService WebhookService) Redeliver(ctx context.Context, endpointID, deliveryID uuid.UUID) (sql_models.WebhookDelivery, error) {
+	webhookService.logger.Info("Redelivering webhook",
+		zap.String("endpointID", endpointID.String()),
+		zap.String("deliveryID", deliveryID.String()))
+
+	delivery, err := webhookService.repo.GetWebhookDelivery(ctx, endpointID.String(), deliveryID.String())
+	if err != nil {
+		return sql_models.WebhookDelivery{}, err
+	}
+
+	now := time.Now()
+	delivery.Status = sql_models.DeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = &now
+	delivery.DeliveredAt = nil
+	if err := webhookService.repo.UpdateWebhookDelivery(ctx, delivery); err != nil {
+		return sql_models.WebhookDelivery{}, fmt.Errorf("failed to redeliver webhook: %w", err)
+	}
+	return delivery, nil
+}
+
+// Publish queues the outbox event for every endpoint subscribed to its type.
+// It makes WebhookService an EventPublisher; an event relayed again is not
+// queued twice because its ID is kept.
+func (webhookService WebhookService) Publish(ctx context.Context, event sql_models.OutboxEvent) error {
+	return webhookService.enqueue(ctx, json_models.WebhookEvent{
+		ID:        event.EventID,
+		Type:      event.EventType,
+		CreatedAt: event.CreatedAt.UTC(),
+		Data:      event.Payload,
+	})
+}
+
+func (webhookService WebhookService) enqueue(ctx context.Context, event json_models.WebhookEvent) error {
+	endpoints, err := webhookService.repo.ListWebhookEndpoints(ctx)
+	if err != nil {
+		return err
+	}
+
+	var payload []byte
+	now := time.Now()
+	var deliveries []sql_models.WebhookDelivery
+	for _, endpoint := range endpoints {
+		if !endpoint.Accepts(event.Type) {
+			continue
+		}
+		if payload == nil {
+			if payload, err = json.Marshal(event); err != nil {
+				return fmt.Errorf("failed to marshal webhook event: %w", err)
+			}
+		}
+		deliveries = append(deliveries, sql_models.WebhookDelivery{
+			EndpointID:    endpoint.ID,
+			EventID:       event.ID,
+			EventType:     event.Type,
+			Payload:       payload,
+			NextAttemptAt: &now,
+		})
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return webhookService.repo.EnqueueWebhookDeliveries(ctx, deliveries)
+}
+
+// QueueRenewalNotices publishes subscription.renewing for every charge due
+// within the next RenewalNoticeDays days (UTC). Each renewal is announced
+// once.
+func (webhookService WebhookService) QueueRenewalNotices(ctx context.Context) error {
+	if webhookService.config.RenewalNoticeDays == 0 {
+		return nil
+	}
+
+	rates, err := webhookService.rates.Converter(ctx, "")
+	if err != nil {
+		return err
+	}
+	today := utils.DayStart(time.Now().UTC())
+	upcoming, err := webhookService.subscriptions.GetUpcomingCharges(ctx, json_models.UpcomingChargesFilter{
+		From:      today,
+		To:        today.AddDate(0, 0, webhookService.config.RenewalNoticeDays+1),
+		Proration: webhookService.proration,
+	}, rates)
+	if err != nil {
+		return fmt.Errorf("failed to look up upcoming renewals: %w", err)
+	}
+
+	for _, charge := range upcoming.Charges {
+		event := json_models.WebhookEvent{
+			ID:        uuid.NewSHA1(renewalEventNamespace, []byte(charge.SubscriptionID+"/"+charge.Date)).String(),
+			Type:      sql_models.WebhookSubscriptionRenewing,
+			CreatedAt: time.Now().UTC(),
+			Data:      charge,
+		}
+		if err := webhookService.enqueue(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DispatchWebhooks sends the deliveries that are due and records the outcome
+// of each attempt. It returns the number of attempts made.
+func (webhookService WebhookService) DispatchWebhooks(ctx context.Context) (int, error) {
+	now := time.Now()
+	// The lease keeps a delivery from being claimed again while it is sent.
+	// The batch is sent one delivery after another, so it covers a full
+	// timeout for each of them.
+	lease := webhookBatchSize*webhookService.config.Timeout + webhookLeaseMargin
+	deliveries, err := webhookService.repo.ClaimWebhookDeliveries(ctx, now, lease, webhookBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+
+	endpoints := make(map[string]sql_models.WebhookEndpoint)
+	for _, delivery := range deliveries {
+		endpoint, ok := endpoints[delivery.EndpointID]
+		if !ok {
+			if endpoint, err = webhookService.repo.GetWebhookEndpoint(ctx, delivery.EndpointID); err != nil {
+				if errors.Is(err, domain_errors.ErrWebhookEndpointNotFound) {
+					// The endpoint was deleted together with its deliveries.
+					continue
+				}
+				return 0, fmt.Errorf("failed to load webhook endpoint: %w", err)
+			}
+			endpoints[endpoint.ID] = endpoint
+		}
+
+		delivery = webhookService.attempt(ctx, endpoint, delivery)
+		if err := webhookService.repo.UpdateWebhookDelivery(ctx, delivery); err != nil {
+			webhookService.logger.Error("Failed to record webhook attempt",
+				zap.String("deliveryID", delivery.ID),
+				zap.Error(err))
+		}
+	}
+	return len(deliveries), nil
+}
+
+// attempt sends the delivery once and returns it updated with the outcome.
+// A failed attempt is retried after an exponential backoff until MaxAttempts
+// is reached.
+func (webhookService WebhookService) attempt(ctx context.Context, endpoint sql_models.WebhookEndpoint, delivery sql_models.WebhookDelivery) sql_models.WebhookDelivery {
+	delivery.Attempts++
+	statusCode, err := webhookService.send(ctx, endpoint, delivery)
+	if statusCode != 0 {
+		delivery.LastStatusCode = &statusCode
+	}
+
+	now := time.Now()
+	if err == nil {
+		delivery.Status = sql_models.DeliverySucceeded
+		delivery.NextAttemptAt = nil
+		delivery.LastError = nil
+		delivery.DeliveredAt = &now
+		return delivery
+	}
+
+	message := err.Error()
+	if len(message) > webhookErrorLimit {
+		message = message[:webhookErrorLimit]
+	}
+	delivery.LastError = &message
+	if delivery.Attempts >= webhookService.config.MaxAttempts {
+		delivery.Status = sql_models.DeliveryFailed
+		delivery.NextAttemptAt = nil
+		webhookService.logger.Warn("Webhook delivery failed",
+			zap.String("deliveryID", delivery.ID),
+			zap.String("url", endpoint.URL),
+			zap.Int("attempts", delivery.Attempts),
+			zap.Error(err))
+		return delivery
+	}
+
+	next := now.Add(webhookService.backoff(delivery.Attempts))
+	delivery.NextAttemptAt = &next
+	return delivery
+}
+
+// backoff returns the wait after the given number of failed attempts.
+func (webhookService WebhookService) backoff(attempts int) time.Duration {
+	wait := webhookService.config.RetryBackoff
+	for i := 1; i < attempts && wait < webhookMaxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > webhookMaxBackoff {
+		wait = webhookMaxBackoff
+	}
+	return wait
+}
+
+// send posts the signed payload to the endpoint. Any response other than 2xx
+// is an error.
+func (webhookService WebhookService) send(ctx context.Context, endpoint sql_models.WebhookEndpoint, delivery sql_models.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("invalid webhook request: %w", err)
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhookEventHeader, delivery.EventType)
+	req.Header.Set(webhookIDHeader, delivery.EventID)
+	req.Header.Set(webhookDeliveryHeader, delivery.ID)
+	req.Header.Set(webhookSignatureHeader, fmt.Sprintf("t=%d,v1=%s", timestamp, signWebhook(endpoint.Secret, timestamp, delivery.Payload)))
+
+	resp, err := webhookService.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("endpoint responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// signWebhook returns the hex HMAC-SHA256 of "<timestamp>.<payload>".
+func signWebhook(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// RunDispatcher calls DispatchWebhooks every DispatchInterval until ctx is done.
+func (webhookService WebhookService) RunDispatcher(ctx context.Context) {
+	webhookService.logger.Info("Starting webhook dispatcher",
+		zap.Duration("interval", webhookService.config.DispatchInterval))
+
+	ticker := time.NewTicker(webhookService.config.DispatchInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := webhookService.DispatchWebhooks(ctx); err != nil {
+			webhookService.logger.Error("Failed to dispatch webhooks",
+				zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunRenewalNotices calls QueueRenewalNotices every RenewalScanInterval until
+// ctx is done.
+func (webhookService WebhookService) RunRenewalNotices(ctx context.Context) {
+	webhookService.logger.Info("Starting renewal notices",
+		zap.Int("days", webhookService.config.RenewalNoticeDays),
+		zap.Duration("interval", webhookService.config.RenewalScanInterval))
+
+	ticker := time.NewTicker(webhookService.config.RenewalScanInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := webhookService.QueueRenewalNotices(ctx); err != nil {
+			webhookService.logger.Error("Failed to queue renewal notices",
+				zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"taskTestEffectMobile/internal/core/configs"
+	"taskTestEffectMobile/internal/models/json_models"
+	"taskTestEffectMobile/internal/models/sql_models"
+	"taskTestEffectMobile/internal/repository"
+	"testing"
+	"time"
+)
+
+const testWebhookSecret = "whsec_test_secret_value"
+
+// webhookReceiver answers with the queued status codes and keeps the requests.
+type webhookReceiver struct {
+	mu       sync.Mutex
+	statuses []int
+	bodies   [][]byte
+	headers  []http.Header
+}
+
+func (receiver *webhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	receiver.mu.Lock()
+	defer receiver.mu.Unlock()
+	receiver.bodies = append(receiver.bodies, body)
+	receiver.headers = append(receiver.headers, r.Header.Clone())
+	status := http.StatusOK
+	if len(receiver.statuses) > 0 {
+		status, receiver.statuses = receiver.statuses[0], receiver.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func newTestWebhookService() *WebhookService {
+	logger := zap.NewNop()
+	rates := NewExchangeRateService(repository.NewInMemoryExchangeRateRepository(logger), "RUB", logger)
+	config := configs.WebhookConfig{Timeout: time.Second, MaxAttempts: 2, RetryBackoff: time.Minute}
+	return NewWebhookService(repository.NewInMemoryWebhookRepository(logger), repository.NewInMemorySubscriptionRepository(logger),
+		*rates, repository.ProrationNone, config, logger)
+}
+
+func TestDispatchWebhooks(t *testing.T) {
+	ctx := context.Background()
+	receiver := &webhookReceiver{statuses: []int{http.StatusInternalServerError}}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	webhookService := newTestWebhookService()
+	secret := testWebhookSecret
+	endpoint, err := webhookService.RegisterEndpoint(ctx, json_models.WebhookEndpointInput{
+		URL:        server.URL,
+		EventTypes: []string{sql_models.WebhookSubscriptionCreated},
+		Secret:     &secret,
+	})
+	if err != nil {
+		t.Fatalf("RegisterEndpoint() error = %v", err)
+	}
+	for _, eventType := range []string{sql_models.WebhookSubscriptionDeleted, sql_models.WebhookSubscriptionCreated} {
+		event := sql_models.OutboxEvent{EventID: uuid.NewString(), EventType: eventType, Payload: []byte(`{"id": "1"}`), CreatedAt: time.Now()}
+		if err := webhookService.Publish(ctx, event); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+	}
+
+	if sent, err := webhookService.DispatchWebhooks(ctx); err != nil || sent != 1 {
+		t.Fatalf("DispatchWebhooks() = %d, %v; want 1 attempt", sent, err)
+	}
+	deliveries, err := webhookService.ListDeliveries(ctx, uuid.MustParse(endpoint.ID))
+	if err != nil {
+		t.Fatalf("ListDeliveries() error = %v", err)
+	}
+	if len(deliveries) != 1 || deliveries[0].Status != sql_models.DeliveryPending || deliveries[0].Attempts != 1 ||
+		deliveries[0].LastStatusCode == nil || *deliveries[0].LastStatusCode != http.StatusInternalServerError {
+		t.Fatalf("deliveries after a failed attempt = %+v", deliveries)
+	}
+
+	if sent, err := webhookService.DispatchWebhooks(ctx); err != nil || sent != 0 {
+		t.Fatalf("DispatchWebhooks() during backoff = %d, %v; want no attempts", sent, err)
+	}
+
+	if _, err := webhookService.Redeliver(ctx, uuid.MustParse(endpoint.ID), uuid.MustParse(deliveries[0].ID)); err != nil {
+		t.Fatalf("Redeliver() error = %v", err)
+	}
+	if sent, err := webhookService.DispatchWebhooks(ctx); err != nil || sent != 1 {
+		t.Fatalf("DispatchWebhooks() after redelivery = %d, %v; want 1 attempt", sent, err)
+	}
+	deliveries, _ = webhookService.ListDeliveries(ctx, uuid.MustParse(endpoint.ID))
+	if len(deliveries) != 1 || deliveries[0].Status != sql_models.DeliverySucceeded || deliveries[0].DeliveredAt == nil {
+		t.Fatalf("deliveries after redelivery = %+v", deliveries)
+	}
+
+	if len(receiver.bodies) != 2 {
+		t.Fatalf("endpoint received %d requests, want 2", len(receiver.bodies))
+	}
+	header := receiver.headers[1]
+	if header.Get(webhookEventHeader) != sql_models.WebhookSubscriptionCreated || header.Get(webhookIDHeader) != receiver.headers[0].Get(webhookIDHeader) {
+		t.Errorf("headers = %v", header)
+	}
+	var timestamp int64
+	var signature string
+	if _, err := fmt.Sscanf(header.Get(webhookSignatureHeader), "t=%d,v1=%s", &timestamp, &signature); err != nil {
+		t.Fatalf("signature header %q: %v", header.Get(webhookSignatureHeader), err)
+	}
+	if signature != signWebhook(testWebhookSecret, timestamp, receiver.bodies[1]) {
+		t.Errorf("signature %s does not match the body", signature)
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	webhookService := WebhookService{config: configs.WebhookConfig{RetryBackoff: time.Minute}}
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: time.Minute},
+		{attempts: 3, want: 4 * time.Minute},
+		{attempts: 20, want: webhookMaxBackoff},
+	}
+	for _, tt := range tests {
+		if got := webhookService.backoff(tt.attempts); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"fmt"
+	"time"
+)
+
+// MonthStart truncates t to the first day of its month in UTC.
+func MonthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// MonthsBetween returns the number of calendar months from "from" to "to"
+// inclusive. It returns 0 when "to" is before "from".
+func MonthsBetween(from, to time.Time) int {
+	months := (to.Year()-from.Year())*12 + int(to.Month()) - int(from.Month()) + 1
+	if months < 0 {
+		return 0
+	}
+	return months
+}
+
+// Accepted date layouts. MonthLayout keeps month precision, the others carry a day.
+const (
+	MonthLayout = "01-2006"
+	DayLayout   = "2006-01-02"
+)
+
+// ParseDate parses a date given as MM-YYYY, YYYY-MM-DD or RFC 3339. A month
+// resolves to its first day; an RFC 3339 timestamp keeps only its calendar date.
+func ParseDate(value string) (time.Time, error) {
+	date, _, err := parseDate(value)
+	return date, err
+}
+
+// ParseEndDate parses an inclusive end date like ParseDate, except that a
+// month resolves to its last day so it keeps covering the whole month.
+func ParseEndDate(value string) (time.Time, error) {
+	date, monthOnly, err := parseDate(value)
+	if err != nil || !monthOnly {
+		return date, err
+	}
+	return MonthEnd(date), nil
+}
+
+func parseDate(value string) (time.Time, bool, error) {
+	if date, err := time.Parse(MonthLayout, value); err == nil {
+		return date, true, nil
+	}
+	if date, err := time.Parse(DayLayout, value); err == nil {
+		return date, false, nil
+	}
+	date, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("%q is not a MM-YYYY, YYYY-MM-DD or RFC 3339 date", value)
+	}
+	return DayStart(date), false, nil
+}
+
+// DayStart truncates t to midnight UTC of its calendar date.
+func DayStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// MonthEnd returns the last day of the month of t in UTC.
+func MonthEnd(t time.Time) time.Time {
+	return MonthStart(t).AddDate(0, 1, -1)
+}
@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDates(t *testing.T) {
+	tests := []struct {
+		value     string
+		wantStart time.Time
+		wantEnd   time.Time
+		wantErr   bool
+	}{
+		{value: "02-2025", wantStart: time.Date(2025, time.February, 1, 0, 0, 0, 0, time.UTC), wantEnd: time.Date(2025, time.February, 28, 0, 0, 0, 0, time.UTC)},
+		{value: "2025-02-10", wantStart: time.Date(2025, time.February, 10, 0, 0, 0, 0, time.UTC), wantEnd: time.Date(2025, time.February, 10, 0, 0, 0, 0, time.UTC)},
+		{value: "2025-02-10T23:30:00+03:00", wantStart: time.Date(2025, time.February, 10, 0, 0, 0, 0, time.UTC), wantEnd: time.Date(2025, time.February, 10, 0, 0, 0, 0, time.UTC)},
+		{value: "2025-02", wantErr: true},
+		{value: "13-2025", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			start, err := ParseDate(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			end, err := ParseEndDate(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseEndDate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !start.Equal(tt.wantStart) {
+				t.Errorf("ParseDate() = %v, want %v", start, tt.wantStart)
+			}
+			if !end.Equal(tt.wantEnd) {
+				t.Errorf("ParseEndDate() = %v, want %v", end, tt.wantEnd)
+			}
+		})
+	}
+}
@@ -10,7 +10,7 @@ import (
 func QueryParser(r *http.Request, dest interface{}) error {
 	decoder := schema.NewDecoder()
 	decoder.RegisterConverter(time.Time{}, func(s string) reflect.Value {
-		t, err := time.Parse("2006-01-02", s)
+		t, err := ParseDate(s)
 		if err != nil {
 			return reflect.Value{}
 		}
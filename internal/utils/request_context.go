@@ -0,0 +1,27 @@
+package utils
+
+import "context"
+
+type contextKey string
+
+const (
+	requestIDKey contextKey = "request_id"
+	actorKey     contextKey = "actor"
+)
+
+// WithRequestMetadata stores the request ID and the acting user in ctx so
+// lower layers can attribute changes without extra parameters.
+func WithRequestMetadata(ctx context.Context, requestID, actor string) context.Context {
+	ctx = context.WithValue(ctx, requestIDKey, requestID)
+	return context.WithValue(ctx, actorKey, actor)
+}
+
+func RequestIDFromContext(ctx context.Context) string {
+	value, _ := ctx.Value(requestIDKey).(string)
+	return value
+}
+
+func ActorFromContext(ctx context.Context) string {
+	value, _ := ctx.Value(actorKey).(string)
+	return value
+}
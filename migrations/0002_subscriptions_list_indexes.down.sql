@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_subscriptions_start_date_id;
+DROP INDEX IF EXISTS idx_subscriptions_price_id;
+DROP INDEX IF EXISTS idx_subscriptions_created_at_id;
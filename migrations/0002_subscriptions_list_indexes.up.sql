@@ -0,0 +1,3 @@
+CREATE INDEX idx_subscriptions_created_at_id ON subscriptions(created_at, id);
+CREATE INDEX idx_subscriptions_price_id ON subscriptions(price, id);
+CREATE INDEX idx_subscriptions_start_date_id ON subscriptions(start_date, id);
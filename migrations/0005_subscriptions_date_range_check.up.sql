@@ -0,0 +1,5 @@
+-- NOT VALID enforces the rule for new writes without failing on legacy rows;
+-- run VALIDATE CONSTRAINT once they are fixed.
+ALTER TABLE subscriptions
+    ADD CONSTRAINT subscriptions_end_after_start
+    CHECK (end_date IS NULL OR end_date >= start_date) NOT VALID;
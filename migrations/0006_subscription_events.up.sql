@@ -0,0 +1,12 @@
+CREATE TABLE subscription_events (
+    id BIGSERIAL PRIMARY KEY,
+    subscription_id UUID NOT NULL,
+    event_type VARCHAR(32) NOT NULL,
+    before_state JSONB NULL,
+    after_state JSONB NULL,
+    actor VARCHAR(255) NOT NULL DEFAULT '',
+    request_id VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_subscription_events_subscription_id ON subscription_events(subscription_id, id);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS subscription_pauses;
+ALTER TABLE subscriptions DROP CONSTRAINT IF EXISTS subscriptions_status_check;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS trial_end_date;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS status;
@@ -0,0 +1,13 @@
+ALTER TABLE subscriptions ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'active';
+ALTER TABLE subscriptions ADD COLUMN trial_end_date DATE NULL;
+ALTER TABLE subscriptions ADD CONSTRAINT subscriptions_status_check
+    CHECK (status IN ('trial', 'active', 'paused', 'cancelled', 'expired'));
+
+CREATE TABLE subscription_pauses (
+    id BIGSERIAL PRIMARY KEY,
+    subscription_id UUID NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    paused_from DATE NOT NULL,
+    paused_until DATE NULL
+);
+
+CREATE INDEX idx_subscription_pauses_subscription_id ON subscription_pauses(subscription_id);
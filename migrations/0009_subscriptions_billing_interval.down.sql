@@ -0,0 +1,3 @@
+ALTER TABLE subscriptions DROP CONSTRAINT IF EXISTS subscriptions_billing_interval_check;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS billing_interval_count;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS billing_interval;
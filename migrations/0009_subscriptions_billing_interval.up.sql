@@ -0,0 +1,4 @@
+ALTER TABLE subscriptions ADD COLUMN billing_interval VARCHAR(16) NOT NULL DEFAULT 'month';
+ALTER TABLE subscriptions ADD COLUMN billing_interval_count INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE subscriptions ADD CONSTRAINT subscriptions_billing_interval_check
+    CHECK (billing_interval IN ('week', 'month', 'quarter', 'year') AND billing_interval_count > 0);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS exchange_rates;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS currency;
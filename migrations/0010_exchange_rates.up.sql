@@ -0,0 +1,9 @@
+ALTER TABLE subscriptions ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'RUB';
+
+CREATE TABLE exchange_rates (
+    currency CHAR(3) NOT NULL,
+    valid_from DATE NOT NULL,
+    rate NUMERIC(20, 8) NOT NULL CHECK (rate > 0),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (currency, valid_from)
+);
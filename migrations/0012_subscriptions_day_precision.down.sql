@@ -0,0 +1,3 @@
+UPDATE subscriptions
+SET start_date = date_trunc('month', start_date)::date,
+    end_date = date_trunc('month', end_date)::date;
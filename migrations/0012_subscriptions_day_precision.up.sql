@@ -0,0 +1,5 @@
+-- Month-precision end dates used to point at the first day of the last month.
+-- With day-precision dates the end is inclusive, so move them to the month end.
+UPDATE subscriptions
+SET end_date = (date_trunc('month', end_date) + INTERVAL '1 month - 1 day')::date
+WHERE end_date IS NOT NULL;
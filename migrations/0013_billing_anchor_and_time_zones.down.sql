@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS user_settings;
+ALTER TABLE subscriptions DROP CONSTRAINT IF EXISTS subscriptions_billing_anchor_day_check;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS billing_anchor_day;
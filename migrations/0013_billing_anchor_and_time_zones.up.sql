@@ -0,0 +1,10 @@
+-- Existing plans renew on the first of the month, as billing did before anchors.
+ALTER TABLE subscriptions ADD COLUMN billing_anchor_day SMALLINT NOT NULL DEFAULT 1;
+ALTER TABLE subscriptions ADD CONSTRAINT subscriptions_billing_anchor_day_check
+    CHECK (billing_anchor_day BETWEEN 1 AND 31);
+
+CREATE TABLE user_settings (
+    user_id UUID PRIMARY KEY,
+    time_zone VARCHAR(64) NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,8 @@
+-- One calendar feed per user. Only the SHA-256 of the secret token is stored;
+-- issuing a new token replaces, and so revokes, the previous one.
+CREATE TABLE calendar_tokens (
+    user_id UUID PRIMARY KEY,
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    reminder_days INTEGER CHECK (reminder_days BETWEEN 0 AND 30),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
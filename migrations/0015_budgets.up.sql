@@ -0,0 +1,30 @@
+-- Monthly spending limits of a user: overall when service_name is NULL,
+-- otherwise for the subscriptions to one service (matched case-insensitively).
+CREATE TABLE budgets (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL,
+    service_name VARCHAR(255),
+    amount NUMERIC(20, 2) NOT NULL CHECK (amount > 0),
+    currency CHAR(3) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX budgets_user_service_key ON budgets (user_id, COALESCE(LOWER(service_name), ''));
+
+-- A threshold of a budget is alerted at most once per month.
+CREATE TABLE budget_alerts (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    budget_id UUID NOT NULL REFERENCES budgets(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL,
+    service_name VARCHAR(255),
+    month DATE NOT NULL,
+    threshold INTEGER NOT NULL,
+    spent NUMERIC(20, 2) NOT NULL,
+    amount NUMERIC(20, 2) NOT NULL,
+    currency CHAR(3) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (budget_id, month, threshold)
+);
+
+CREATE INDEX idx_budget_alerts_user_id ON budget_alerts (user_id, created_at DESC);
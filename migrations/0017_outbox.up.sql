@@ -0,0 +1,14 @@
+-- Events written in the transaction of the subscription change they describe
+-- and relayed to the publishers in id order.
+CREATE TABLE outbox_events (
+    id BIGSERIAL PRIMARY KEY,
+    event_id UUID NOT NULL UNIQUE DEFAULT uuid_generate_v4(),
+    event_type VARCHAR(64) NOT NULL,
+    subscription_id UUID NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    published_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_outbox_events_unpublished ON outbox_events (id) WHERE published_at IS NULL;
+CREATE INDEX idx_outbox_events_published_at ON outbox_events (published_at) WHERE published_at IS NOT NULL;